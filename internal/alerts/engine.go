@@ -0,0 +1,358 @@
+package alerts
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+	"github.com/ethan-mdev/service-watch/internal/logger"
+	"github.com/ethan-mdev/service-watch/internal/sse"
+)
+
+// maxAlerts is how many alerts are kept; the oldest resolved ones go first.
+const maxAlerts = 500
+
+// Alert is a condition raised by a rule, from the first firing event until it resolves.
+type Alert struct {
+	ID             string            `json:"id"`
+	Rule           string            `json:"rule"`
+	Labels         map[string]string `json:"labels,omitempty"` // Values of the rule's groupBy fields
+	Severity       string            `json:"severity"`
+	State          string            `json:"state"` // firing|resolved
+	Summary        string            `json:"summary"`
+	Count          int               `json:"count"` // Firing events grouped into this alert
+	FirstSeen      time.Time         `json:"firstSeen"`
+	LastSeen       time.Time         `json:"lastSeen"`
+	LastEvent      interface{}       `json:"lastEvent,omitempty"` // Data of the latest firing event
+	ResolvedAt     *time.Time        `json:"resolvedAt,omitempty"`
+	ResolvedBy     string            `json:"resolvedBy,omitempty"` // Event type that resolved it
+	AcknowledgedAt *time.Time        `json:"acknowledgedAt,omitempty"`
+	AcknowledgedBy string            `json:"acknowledgedBy,omitempty"`
+	SilencedUntil  *time.Time        `json:"silencedUntil,omitempty"`
+	EscalatedAt    *time.Time        `json:"escalatedAt,omitempty"`
+}
+
+// Silenced reports whether the alert is silenced at t.
+func (a Alert) Silenced(t time.Time) bool {
+	return a.SilencedUntil != nil && t.Before(*a.SilencedUntil)
+}
+
+// Engine evaluates rules against logged events and tracks the resulting alerts.
+// Alert changes are logged as alert_* events, which also pushes them over SSE
+// and makes them available to webhooks and email.
+type Engine struct {
+	rules []Rule
+	log   *logger.Logger
+
+	mutex  sync.Mutex
+	alerts []*Alert          // Oldest first
+	firing map[string]*Alert // By rule and group
+}
+
+// New creates an engine for rules.
+func New(rules []Rule, log *logger.Logger) *Engine {
+	for i := range rules {
+		rules[i] = rules[i].WithDefaults()
+	}
+	return &Engine{
+		rules:  rules,
+		log:    log,
+		firing: make(map[string]*Alert),
+	}
+}
+
+// Run subscribes to the broadcaster and evaluates events until ctx is cancelled.
+func (e *Engine) Run(ctx context.Context, broadcaster *sse.Broadcaster) {
+	sub := broadcaster.Subscribe(ctx, "alerts", e.log)
+	defer sub.Close()
+
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-sub.Events:
+			// Our own events never fire or resolve anything
+			if !strings.HasPrefix(event.Type, "alert_") {
+				e.Evaluate(event)
+			}
+		case now := <-ticker.C:
+			e.escalate(now)
+		}
+	}
+}
+
+// Evaluate applies an event to every rule: resolving firing alerts it matches,
+// then firing or updating alerts for rules it triggers.
+func (e *Engine) Evaluate(event core.Event) {
+	now := event.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	data, _ := event.Data.(map[string]interface{})
+
+	for _, rule := range e.rules {
+		labels := groupLabels(rule, data)
+		key := alertKey(rule, labels)
+
+		for _, cond := range rule.Resolve {
+			if cond.Matches(event) {
+				e.resolve(key, event.Type, now)
+				break
+			}
+		}
+		if rule.Matches(event) {
+			e.fire(rule, key, labels, event, now)
+		}
+	}
+}
+
+func (e *Engine) fire(rule Rule, key string, labels map[string]string, event core.Event, now time.Time) {
+	e.mutex.Lock()
+	alert, exists := e.firing[key]
+	if exists {
+		alert.Count++
+		alert.LastSeen = now
+		alert.LastEvent = event.Data
+		alert.Summary = summarize(event)
+		snapshot := *alert
+		e.mutex.Unlock()
+
+		if !snapshot.Silenced(now) {
+			e.log.Info("alert_updated", alertData(snapshot))
+		}
+		return
+	}
+
+	alert = &Alert{
+		ID:        newAlertID(),
+		Rule:      rule.Name,
+		Labels:    labels,
+		Severity:  rule.Severity,
+		State:     "firing",
+		Summary:   summarize(event),
+		Count:     1,
+		FirstSeen: now,
+		LastSeen:  now,
+		LastEvent: event.Data,
+	}
+	e.firing[key] = alert
+	e.alerts = append(e.alerts, alert)
+	e.prune()
+	snapshot := *alert
+	e.mutex.Unlock()
+
+	e.log.Error("alert_firing", alertData(snapshot))
+}
+
+func (e *Engine) resolve(key, eventType string, now time.Time) {
+	e.mutex.Lock()
+	alert, exists := e.firing[key]
+	if !exists {
+		e.mutex.Unlock()
+		return
+	}
+	delete(e.firing, key)
+	alert.State = "resolved"
+	alert.ResolvedAt = &now
+	alert.ResolvedBy = eventType
+	snapshot := *alert
+	e.mutex.Unlock()
+
+	e.log.Info("alert_resolved", alertData(snapshot))
+}
+
+// escalate raises firing alerts that nobody acknowledged or silenced within their rule's escalateAfter.
+func (e *Engine) escalate(now time.Time) {
+	var escalated []Alert
+	e.mutex.Lock()
+	for _, rule := range e.rules {
+		if rule.EscalateAfter <= 0 {
+			continue
+		}
+		for _, alert := range e.firing {
+			if alert.Rule != rule.Name || alert.EscalatedAt != nil || alert.AcknowledgedAt != nil || alert.Silenced(now) {
+				continue
+			}
+			if now.Sub(alert.FirstSeen) < time.Duration(rule.EscalateAfter) {
+				continue
+			}
+			at := now
+			alert.EscalatedAt = &at
+			if severityRank(rule.EscalateTo) > severityRank(alert.Severity) {
+				alert.Severity = rule.EscalateTo
+			}
+			escalated = append(escalated, *alert)
+		}
+	}
+	e.mutex.Unlock()
+
+	for _, alert := range escalated {
+		e.log.Error("alert_escalated", alertData(alert))
+	}
+}
+
+// List returns all alerts, newest first.
+func (e *Engine) List() []Alert {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	results := make([]Alert, 0, len(e.alerts))
+	for i := len(e.alerts) - 1; i >= 0; i-- {
+		results = append(results, *e.alerts[i])
+	}
+	return results
+}
+
+// Get returns an alert by ID.
+func (e *Engine) Get(id string) (Alert, error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	alert := e.find(id)
+	if alert == nil {
+		return Alert{}, fmt.Errorf("alert not found: %s", id)
+	}
+	return *alert, nil
+}
+
+// Rules returns the rules in effect.
+func (e *Engine) Rules() []Rule {
+	return e.rules
+}
+
+// Acknowledge marks a firing alert as being handled, which stops its escalation.
+func (e *Engine) Acknowledge(id, by string) (Alert, error) {
+	return e.change(id, "alert_acknowledged", func(alert *Alert, now time.Time) {
+		alert.AcknowledgedAt = &now
+		alert.AcknowledgedBy = by
+	})
+}
+
+// Silence suppresses updates and escalation of a firing alert for d.
+func (e *Engine) Silence(id string, d time.Duration) (Alert, error) {
+	return e.change(id, "alert_silenced", func(alert *Alert, now time.Time) {
+		until := now.Add(d)
+		alert.SilencedUntil = &until
+	})
+}
+
+// Unsilence lifts the silence of a firing alert.
+func (e *Engine) Unsilence(id string) (Alert, error) {
+	return e.change(id, "alert_unsilenced", func(alert *Alert, now time.Time) {
+		alert.SilencedUntil = nil
+	})
+}
+
+// change applies fn to a firing alert and logs eventType.
+func (e *Engine) change(id, eventType string, fn func(alert *Alert, now time.Time)) (Alert, error) {
+	e.mutex.Lock()
+	alert := e.find(id)
+	if alert == nil {
+		e.mutex.Unlock()
+		return Alert{}, fmt.Errorf("alert not found: %s", id)
+	}
+	if alert.State != "firing" {
+		e.mutex.Unlock()
+		return Alert{}, fmt.Errorf("alert is already resolved: %s", id)
+	}
+	fn(alert, time.Now())
+	snapshot := *alert
+	e.mutex.Unlock()
+
+	e.log.Info(eventType, alertData(snapshot))
+	return snapshot, nil
+}
+
+func (e *Engine) find(id string) *Alert {
+	for _, alert := range e.alerts {
+		if alert.ID == id {
+			return alert
+		}
+	}
+	return nil
+}
+
+// prune drops the oldest resolved alerts beyond maxAlerts. Called with mutex held.
+func (e *Engine) prune() {
+	excess := len(e.alerts) - maxAlerts
+	if excess <= 0 {
+		return
+	}
+	kept := e.alerts[:0]
+	for _, alert := range e.alerts {
+		if excess > 0 && alert.State == "resolved" {
+			excess--
+			continue
+		}
+		kept = append(kept, alert)
+	}
+	e.alerts = kept
+}
+
+// groupLabels picks the values of a rule's groupBy fields out of event data.
+func groupLabels(rule Rule, data map[string]interface{}) map[string]string {
+	labels := make(map[string]string, len(rule.GroupBy))
+	for _, field := range rule.GroupBy {
+		if value, ok := data[field]; ok {
+			labels[field] = fmt.Sprint(value)
+		}
+	}
+	return labels
+}
+
+func alertKey(rule Rule, labels map[string]string) string {
+	parts := []string{rule.Name}
+	for _, field := range rule.GroupBy {
+		parts = append(parts, field+"="+labels[field])
+	}
+	return strings.Join(parts, "|")
+}
+
+// summarize describes a firing event in one line.
+func summarize(event core.Event) string {
+	data, _ := event.Data.(map[string]interface{})
+	summary := event.Type
+	if name, ok := data["serviceName"].(string); ok && name != "" {
+		summary += " on " + name
+	}
+	for _, field := range []string{"message", "reason", "error"} {
+		if detail, ok := data[field].(string); ok && detail != "" {
+			return summary + ": " + detail
+		}
+	}
+	return summary
+}
+
+// alertData is the event data logged for alert changes. Labels are flattened so
+// webhook and email service filters apply to alerts too.
+func alertData(alert Alert) map[string]interface{} {
+	data := map[string]interface{}{
+		"alertId":  alert.ID,
+		"rule":     alert.Rule,
+		"severity": alert.Severity,
+		"state":    alert.State,
+		"summary":  alert.Summary,
+		"count":    alert.Count,
+		"alert":    alert,
+	}
+	for key, value := range alert.Labels {
+		if _, taken := data[key]; !taken {
+			data[key] = value
+		}
+	}
+	return data
+}
+
+func newAlertID() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
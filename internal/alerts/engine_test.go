@@ -0,0 +1,247 @@
+package alerts
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+	"github.com/ethan-mdev/service-watch/internal/logger"
+	"github.com/ethan-mdev/service-watch/internal/notify"
+)
+
+var epoch = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestEngine(t *testing.T, rules ...Rule) *Engine {
+	t.Helper()
+	log, err := logger.Start(filepath.Join(t.TempDir(), "events.jsonl"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { log.Close() })
+	return New(rules, log)
+}
+
+func event(eventType string, at time.Duration, data map[string]interface{}) core.Event {
+	return core.Event{Type: eventType, Time: epoch.Add(at), Data: data}
+}
+
+var restartFailed = Rule{
+	Name:          "restart_failed",
+	Filter:        notify.Filter{Events: []string{"restart_failed"}},
+	Severity:      "warning",
+	Resolve:       []Condition{{Event: "service_state_changed", Data: map[string]interface{}{"state": "running"}}},
+	EscalateAfter: core.Duration(10 * time.Minute),
+}
+
+func TestEvaluateGroupsAndResolves(t *testing.T) {
+	e := newTestEngine(t, restartFailed)
+
+	e.Evaluate(event("restart_failed", 0, map[string]interface{}{"serviceName": "nginx", "error": "timeout"}))
+	e.Evaluate(event("restart_failed", time.Minute, map[string]interface{}{"serviceName": "nginx", "error": "refused"}))
+	e.Evaluate(event("restart_failed", time.Minute, map[string]interface{}{"serviceName": "db"}))
+	e.Evaluate(event("restart_success", 2*time.Minute, map[string]interface{}{"serviceName": "nginx"})) // Matches nothing
+
+	alerts := e.List()
+	if len(alerts) != 2 {
+		t.Fatalf("got %d alerts, want one per service", len(alerts))
+	}
+	nginx := alerts[1]
+	if nginx.Labels["serviceName"] != "nginx" || nginx.Count != 2 || nginx.State != "firing" {
+		t.Errorf("nginx alert = %+v, want 2 firing events", nginx)
+	}
+	if !nginx.FirstSeen.Equal(epoch) || !nginx.LastSeen.Equal(epoch.Add(time.Minute)) || nginx.Summary != "restart_failed on nginx: refused" {
+		t.Errorf("nginx alert = %+v, want first/last seen and latest summary", nginx)
+	}
+
+	// Only the matching condition and group resolves
+	e.Evaluate(event("service_state_changed", 3*time.Minute, map[string]interface{}{"serviceName": "nginx", "state": "stopped"}))
+	e.Evaluate(event("service_state_changed", 4*time.Minute, map[string]interface{}{"serviceName": "nginx", "state": "running"}))
+	nginx, _ = e.Get(nginx.ID)
+	if nginx.State != "resolved" || nginx.ResolvedBy != "service_state_changed" || !nginx.ResolvedAt.Equal(epoch.Add(4*time.Minute)) {
+		t.Errorf("nginx alert = %+v, want resolved at the running event", nginx)
+	}
+	if db, _ := e.Get(alerts[0].ID); db.State != "firing" {
+		t.Errorf("db alert = %+v, want still firing", db)
+	}
+
+	// Firing again after resolving raises a new alert
+	e.Evaluate(event("restart_failed", 5*time.Minute, map[string]interface{}{"serviceName": "nginx"}))
+	if alerts := e.List(); len(alerts) != 3 || alerts[0].ID == nginx.ID || alerts[0].Count != 1 {
+		t.Errorf("alerts = %+v, want a new nginx alert", alerts)
+	}
+}
+
+func TestEvaluateGroupsByFields(t *testing.T) {
+	e := newTestEngine(t, Rule{
+		Name:     "threshold_exceeded",
+		Filter:   notify.Filter{Events: []string{"threshold_exceeded"}},
+		Severity: "warning",
+		GroupBy:  []string{"serviceName", "rule"},
+	})
+	for _, data := range []map[string]interface{}{
+		{"serviceName": "nginx", "rule": "cpu"},
+		{"serviceName": "nginx", "rule": "memory"},
+		{"serviceName": "nginx", "rule": "cpu"},
+		{"serviceName": "db", "rule": "cpu"},
+	} {
+		e.Evaluate(event("threshold_exceeded", 0, data))
+	}
+	counts := make(map[string]int)
+	for _, alert := range e.List() {
+		counts[alert.Labels["serviceName"]+"/"+alert.Labels["rule"]] = alert.Count
+	}
+	want := map[string]int{"nginx/cpu": 2, "nginx/memory": 1, "db/cpu": 1}
+	if fmt.Sprint(counts) != fmt.Sprint(want) {
+		t.Errorf("counts = %v, want %v", counts, want)
+	}
+}
+
+func TestEscalate(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(e *Engine, id string)
+		at      time.Duration
+		want    bool
+		wantSev string
+	}{
+		{name: "too soon", at: 9 * time.Minute, wantSev: "warning"},
+		{name: "due", at: 10 * time.Minute, want: true, wantSev: "critical"},
+		{
+			name:    "acknowledged",
+			change:  func(e *Engine, id string) { e.Acknowledge(id, "ops") },
+			at:      time.Hour,
+			wantSev: "warning",
+		},
+		{
+			// Silences run from the wall clock, so this one lasts the whole test
+			name:    "silenced",
+			change:  func(e *Engine, id string) { e.Silence(id, time.Until(epoch.Add(2*time.Hour))) },
+			at:      time.Hour,
+			wantSev: "warning",
+		},
+		{
+			name:    "unsilenced",
+			change:  func(e *Engine, id string) { e.Silence(id, time.Hour); e.Unsilence(id) },
+			at:      time.Hour,
+			want:    true,
+			wantSev: "critical",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEngine(t, restartFailed)
+			e.Evaluate(event("restart_failed", 0, map[string]interface{}{"serviceName": "nginx"}))
+			id := e.List()[0].ID
+			if tt.change != nil {
+				tt.change(e, id)
+			}
+
+			e.escalate(epoch.Add(tt.at))
+			alert, _ := e.Get(id)
+			if escalated := alert.EscalatedAt != nil; escalated != tt.want || alert.Severity != tt.wantSev {
+				t.Errorf("escalated %v, severity %s, want %v, %s", escalated, alert.Severity, tt.want, tt.wantSev)
+			}
+
+			// Escalating again changes nothing
+			if tt.want {
+				at := *alert.EscalatedAt
+				e.escalate(epoch.Add(tt.at + time.Hour))
+				if alert, _ := e.Get(id); !alert.EscalatedAt.Equal(at) {
+					t.Errorf("escalated again at %v", alert.EscalatedAt)
+				}
+			}
+		})
+	}
+}
+
+func TestPruneKeepsFiring(t *testing.T) {
+	e := newTestEngine(t, restartFailed)
+	e.Evaluate(event("restart_failed", 0, map[string]interface{}{"serviceName": "old"}))
+	e.Evaluate(event("service_state_changed", 0, map[string]interface{}{"serviceName": "old", "state": "running"}))
+	oldest := e.List()[0].ID
+
+	for n := 0; n < maxAlerts+1; n++ {
+		e.Evaluate(event("restart_failed", time.Minute, map[string]interface{}{"serviceName": fmt.Sprint("svc", n)}))
+	}
+
+	// The resolved alert goes first, then the limit gives way to firing ones
+	alerts := e.List()
+	if len(alerts) != maxAlerts+1 {
+		t.Errorf("got %d alerts, want %d", len(alerts), maxAlerts+1)
+	}
+	if _, err := e.Get(oldest); err == nil {
+		t.Error("resolved alert kept over the limit")
+	}
+	for _, alert := range alerts {
+		if alert.State != "firing" {
+			t.Errorf("alert %s is %s, want only firing alerts kept", alert.ID, alert.State)
+		}
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			json: `[{"name":"a","events":["service_failed"],"severity":"critical","escalateAfter":"5m"},
+				{"name":"b","events":["restart_*"],"severity":"info"}]`,
+		},
+		{
+			name:    "duplicate name",
+			json:    `[{"name":"a","events":["service_failed"],"severity":"critical"},{"name":"a","events":["restart_failed"],"severity":"info"}]`,
+			wantErr: "duplicate name a",
+		},
+		{
+			name:    "bad severity",
+			json:    `[{"name":"a","events":["service_failed"],"severity":"urgent"}]`,
+			wantErr: "severity must be one of",
+		},
+		{
+			name:    "bad escalateTo",
+			json:    `[{"name":"a","events":["service_failed"],"severity":"info","escalateTo":"page"}]`,
+			wantErr: "escalateTo must be one of",
+		},
+		{
+			name:    "no events",
+			json:    `[{"name":"a","severity":"info"}]`,
+			wantErr: "needs at least one event",
+		},
+		{
+			name:    "not JSON",
+			json:    `{`,
+			wantErr: "invalid alert rules",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "alerts.json")
+			if err := os.WriteFile(path, []byte(tt.json), 0644); err != nil {
+				t.Fatal(err)
+			}
+			rules, err := LoadRules(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || len(rules) != 2 {
+				t.Errorf("got %d rules, err %v", len(rules), err)
+			}
+		})
+	}
+
+	// No file falls back to the defaults
+	rules, err := LoadRules(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil || len(rules) != len(DefaultRules()) {
+		t.Errorf("got %d rules, err %v, want the defaults", len(rules), err)
+	}
+}
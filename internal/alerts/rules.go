@@ -0,0 +1,165 @@
+package alerts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+	"github.com/ethan-mdev/service-watch/internal/notify"
+)
+
+// Severities, lowest first.
+var severities = []string{"info", "warning", "critical"}
+
+// Condition matches events by type and, optionally, by data fields.
+type Condition struct {
+	Event string                 `json:"event"`          // Event type, "restart_*" style prefixes allowed
+	Data  map[string]interface{} `json:"data,omitempty"` // Fields that must be equal, e.g. {"healthy": true}
+}
+
+// Matches reports whether an event satisfies the condition.
+func (c Condition) Matches(event core.Event) bool {
+	if !(notify.Filter{Events: []string{c.Event}}).Matches(event) {
+		return false
+	}
+	data, _ := event.Data.(map[string]interface{})
+	for key, want := range c.Data {
+		// Compare as text so 1 from JSON config equals int 1 from the monitor
+		if got, ok := data[key]; !ok || fmt.Sprint(got) != fmt.Sprint(want) {
+			return false
+		}
+	}
+	return true
+}
+
+// Rule turns matching events into an alert. Events that share the rule and the
+// values of its GroupBy fields update one alert instead of raising new ones.
+type Rule struct {
+	notify.Filter               // Events that fire the alert
+	Name          string        `json:"name"`
+	Severity      string        `json:"severity"`                // info|warning|critical
+	GroupBy       []string      `json:"groupBy,omitempty"`       // Data fields identifying one alert (default serviceName)
+	Resolve       []Condition   `json:"resolve,omitempty"`       // Events that resolve a firing alert with the same group
+	EscalateAfter core.Duration `json:"escalateAfter,omitempty"` // Raise unacknowledged alerts after this long (0 to never)
+	EscalateTo    string        `json:"escalateTo,omitempty"`    // Severity to raise to (default critical)
+}
+
+// WithDefaults returns the rule with empty fields filled in.
+func (r Rule) WithDefaults() Rule {
+	if len(r.GroupBy) == 0 {
+		r.GroupBy = []string{"serviceName"}
+	}
+	if r.EscalateTo == "" {
+		r.EscalateTo = "critical"
+	}
+	return r
+}
+
+// Validate reports rules that can't fire or escalate.
+func (r Rule) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("alert rule needs a name")
+	}
+	if len(r.Events) == 0 {
+		return fmt.Errorf("alert rule %s needs at least one event", r.Name)
+	}
+	if severityRank(r.Severity) < 0 {
+		return fmt.Errorf("alert rule %s: severity must be one of info, warning, critical", r.Name)
+	}
+	if r.EscalateTo != "" && severityRank(r.EscalateTo) < 0 {
+		return fmt.Errorf("alert rule %s: escalateTo must be one of info, warning, critical", r.Name)
+	}
+	if r.EscalateAfter < 0 {
+		return fmt.Errorf("alert rule %s: escalateAfter must not be negative", r.Name)
+	}
+	for _, cond := range r.Resolve {
+		if cond.Event == "" {
+			return fmt.Errorf("alert rule %s: resolve conditions need an event", r.Name)
+		}
+	}
+	return nil
+}
+
+// DefaultRules are used when no rules file exists.
+func DefaultRules() []Rule {
+	return []Rule{
+		{
+			Name:     "service_failed",
+			Filter:   notify.Filter{Events: []string{"service_failed"}},
+			Severity: "critical",
+			Resolve: []Condition{
+				{Event: "restart_success"},
+				{Event: "service_state_changed", Data: map[string]interface{}{"state": "running"}},
+			},
+		},
+		{
+			Name:          "restart_failed",
+			Filter:        notify.Filter{Events: []string{"restart_failed"}},
+			Severity:      "warning",
+			Resolve:       []Condition{{Event: "restart_success"}},
+			EscalateAfter: core.Duration(10 * time.Minute),
+		},
+		{
+			Name:     "service_unhealthy",
+			Filter:   notify.Filter{Events: []string{"service_unhealthy"}},
+			Severity: "warning",
+			Resolve: []Condition{
+				{Event: "health_check", Data: map[string]interface{}{"healthy": true}},
+				{Event: "restart_success"},
+			},
+			EscalateAfter: core.Duration(10 * time.Minute),
+		},
+		{
+			Name:          "threshold_exceeded",
+			Filter:        notify.Filter{Events: []string{"threshold_exceeded"}},
+			Severity:      "warning",
+			GroupBy:       []string{"serviceName", "rule"},
+			Resolve:       []Condition{{Event: "threshold_cleared"}},
+			EscalateAfter: core.Duration(15 * time.Minute),
+		},
+		{
+			Name:     "service_flapping",
+			Filter:   notify.Filter{Events: []string{"service_flapping"}},
+			Severity: "warning",
+			Resolve:  []Condition{{Event: "quarantine_released"}},
+		},
+	}
+}
+
+// LoadRules reads alert rules from a JSON file. A missing file means DefaultRules.
+func LoadRules(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return DefaultRules(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("invalid alert rules: %w", err)
+	}
+	seen := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid alert rules: %w", err)
+		}
+		if seen[rule.Name] {
+			return nil, fmt.Errorf("invalid alert rules: duplicate name %s", rule.Name)
+		}
+		seen[rule.Name] = true
+	}
+	return rules, nil
+}
+
+func severityRank(severity string) int {
+	for i, s := range severities {
+		if s == severity {
+			return i
+		}
+	}
+	return -1
+}
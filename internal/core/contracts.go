@@ -1,6 +1,9 @@
 package core
 
-import "context"
+import (
+	"context"
+	"errors"
+)
 
 // ServiceManager abstracts OS service control.
 type ServiceManager interface {
@@ -16,6 +19,24 @@ type ServiceManager interface {
 	Restart(ctx context.Context, name string) error
 }
 
+// StateWatcher is an optional ServiceManager capability for push-based state updates.
+// Managers that don't implement it are polled.
+type StateWatcher interface {
+	// Streams service state changes until ctx is cancelled. The channel is closed
+	// when the stream ends, after which callers should fall back to polling.
+	Watch(ctx context.Context) <-chan StateChange
+}
+
+// NameNormalizer is an optional ServiceManager capability for backends that accept
+// more than one spelling of a service name, such as "nginx" and "nginx.service".
+type NameNormalizer interface {
+	// Returns the canonical spelling of a service name.
+	NormalizeName(name string) string
+}
+
+// ErrNotInWatchlist is wrapped by WatchlistManager errors about a service that isn't watched.
+var ErrNotInWatchlist = errors.New("service not in watchlist")
+
 // WatchlistManager abstracts watchlist management.
 type WatchlistManager interface {
 	// Lists all watchlist items with current service details populated.
@@ -30,4 +51,67 @@ type WatchlistManager interface {
 	Update(ctx context.Context, name string, autoRestart bool) error
 	// Increments the restart count and last restart time for a watchlist item.
 	IncrementRestartCount(ctx context.Context, name string) error
+	// Increments the count of restart attempts since the service was last healthy.
+	IncrementFailCount(ctx context.Context, name string) error
+	// Clears the fail count once a service has been healthy long enough.
+	ResetFailCount(ctx context.Context, name string) error
+	// Sets the restart policy for a watchlist item; nil restores the default.
+	SetRestartPolicy(ctx context.Context, name string, policy *RestartPolicy) error
+	// Sets the hooks run around auto-restarts of a watchlist item; nil removes them.
+	SetRestartHooks(ctx context.Context, name string, hooks *RestartHooks) error
+	// Replaces the health checks of a watchlist item.
+	SetHealthChecks(ctx context.Context, name string, checks []HealthCheck) error
+	// Replaces the resource threshold rules of a watchlist item.
+	SetThresholdRules(ctx context.Context, name string, rules []ThresholdRule) error
+	// Quarantines a watchlist item, suspending auto-restart; nil releases it.
+	SetQuarantine(ctx context.Context, name string, quarantine *Quarantine) error
+	// Adds a maintenance window to a watchlist item, assigning its ID if empty.
+	AddMaintenanceWindow(ctx context.Context, name string, window MaintenanceWindow) (MaintenanceWindow, error)
+	// Removes a maintenance window from a watchlist item.
+	RemoveMaintenanceWindow(ctx context.Context, name string, id string) error
+	// Sets the watchlist items a watchlist item depends on, rejecting unknown items and cycles.
+	SetDependencies(ctx context.Context, name string, dependsOn []string) error
+	// Sets whether dependents are restarted when a watchlist item comes back up.
+	SetRestartDependents(ctx context.Context, name string, restartDependents bool) error
+	// Replaces the tags, i.e. the groups, of a watchlist item.
+	SetTags(ctx context.Context, name string, tags []string) error
+	// Applies every change in update to a watchlist item, or none if any is invalid.
+	UpdateItem(ctx context.Context, name string, update WatchlistUpdate) error
+}
+
+// RestartHistory is an optional WatchlistManager capability for stores that keep
+// a record of every restart rather than just the count.
+type RestartHistory interface {
+	// Lists the restarts of a watchlist item, newest first, up to limit.
+	Restarts(ctx context.Context, name string, limit int) ([]RestartRecord, error)
+}
+
+// StatusProvider exposes the monitor's runtime view of watchlist items.
+type StatusProvider interface {
+	// Gets the runtime status of a watched service, if the monitor has seen it.
+	Status(name string) (ItemStatus, bool)
+}
+
+// StatsProvider exposes the monitor's latest samples for metrics exposition.
+type StatsProvider interface {
+	// Gets the latest watchlist and host samples and check loop counters.
+	Stats() MonitorStats
+}
+
+// ScheduleManager abstracts persistence of scheduled service actions.
+type ScheduleManager interface {
+	// Lists all schedules.
+	List(ctx context.Context) ([]Schedule, error)
+	// Gets a specific schedule by ID.
+	Get(ctx context.Context, id string) (Schedule, error)
+	// Adds a schedule, assigning its ID if empty.
+	Add(ctx context.Context, schedule Schedule) (Schedule, error)
+	// Removes a schedule.
+	Remove(ctx context.Context, id string) error
+}
+
+// ScheduleStatusProvider exposes the scheduler's runtime view of schedules.
+type ScheduleStatusProvider interface {
+	// Gets the runtime status of a schedule, if the scheduler has picked it up.
+	ScheduleStatus(id string) (ScheduleStatus, bool)
 }
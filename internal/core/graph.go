@@ -0,0 +1,200 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DependencyOrder sorts watchlist items so every item comes after the items it depends on.
+// Items without an order between them are sorted by name. Dependencies that aren't
+// among items are ignored. A cycle is reported as an error naming its members.
+func DependencyOrder(items []WatchlistItem) ([]string, error) {
+	deps := make(map[string][]string, len(items))
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		deps[item.ServiceName] = item.DependsOn
+		names = append(names, item.ServiceName)
+	}
+	sort.Strings(names)
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	marks := make(map[string]int, len(items))
+	order := make([]string, 0, len(items))
+	var path []string
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch marks[name] {
+		case done:
+			return nil
+		case visiting:
+			// Report the cycle starting from its first member on the path
+			for i, n := range path {
+				if n == name {
+					return fmt.Errorf("dependency cycle: %s -> %s", strings.Join(path[i:], " -> "), name)
+				}
+			}
+		}
+
+		marks[name] = visiting
+		path = append(path, name)
+		sorted := append([]string(nil), deps[name]...)
+		sort.Strings(sorted)
+		for _, dep := range sorted {
+			if _, exists := deps[dep]; !exists {
+				continue
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		marks[name] = done
+		order = append(order, name)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// Dependents returns the names of items that list name in DependsOn, sorted.
+func Dependents(items []WatchlistItem, name string) []string {
+	var out []string
+	for _, item := range items {
+		for _, dep := range item.DependsOn {
+			if dep == name {
+				out = append(out, item.ServiceName)
+				break
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ValidateDependencies reports why serviceName can't depend on dependsOn: each
+// dependency has to be another item, listed once, and the graph has to stay acyclic.
+func ValidateDependencies(items []WatchlistItem, serviceName string, dependsOn []string) error {
+	watched := make(map[string]bool, len(items))
+	for _, item := range items {
+		watched[item.ServiceName] = true
+	}
+	if !watched[serviceName] {
+		return fmt.Errorf("%w: %s", ErrNotInWatchlist, serviceName)
+	}
+
+	seen := make(map[string]bool, len(dependsOn))
+	for _, dep := range dependsOn {
+		switch {
+		case dep == serviceName:
+			return fmt.Errorf("service can't depend on itself: %s", dep)
+		case seen[dep]:
+			return fmt.Errorf("duplicate dependency: %s", dep)
+		case !watched[dep]:
+			return fmt.Errorf("dependency not in watchlist: %s", dep)
+		}
+		seen[dep] = true
+	}
+
+	// Check the graph as it would be after the change
+	after := append([]WatchlistItem(nil), items...)
+	for i := range after {
+		if after[i].ServiceName == serviceName {
+			after[i].DependsOn = dependsOn
+		}
+	}
+	_, err := DependencyOrder(after)
+	return err
+}
+
+// ValidateRemoval reports why serviceName can't be removed from the watchlist.
+func ValidateRemoval(items []WatchlistItem, serviceName string) error {
+	found := false
+	for _, item := range items {
+		found = found || item.ServiceName == serviceName
+	}
+	if !found {
+		return fmt.Errorf("%w: %s", ErrNotInWatchlist, serviceName)
+	}
+	if dependents := Dependents(items, serviceName); len(dependents) > 0 {
+		return fmt.Errorf("service is a dependency of: %s", strings.Join(dependents, ", "))
+	}
+	return nil
+}
+
+// GroupMembers returns the names of items tagged tag, dependencies first.
+func GroupMembers(items []WatchlistItem, tag string) ([]string, error) {
+	order, err := DependencyOrder(items)
+	if err != nil {
+		return nil, err
+	}
+
+	tagged := make(map[string]bool)
+	for _, item := range items {
+		if item.HasTag(tag) {
+			tagged[item.ServiceName] = true
+		}
+	}
+	var members []string
+	for _, name := range order {
+		if tagged[name] {
+			members = append(members, name)
+		}
+	}
+	return members, nil
+}
+
+// GroupLevels returns the names of items tagged tag in dependency levels: the first
+// level depends on no other item, and every later level only on items in earlier
+// ones, directly or through untagged items. Each level is sorted by name.
+func GroupLevels(items []WatchlistItem, tag string) ([][]string, error) {
+	order, err := DependencyOrder(items)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]WatchlistItem, len(items))
+	for _, item := range items {
+		byName[item.ServiceName] = item
+	}
+	// Dependencies come first in order, so their depth is known by the time it's needed
+	depth := make(map[string]int, len(items))
+	var levels [][]string
+	for _, name := range order {
+		item := byName[name]
+		d := 0
+		for _, dep := range item.DependsOn {
+			if depDepth, exists := depth[dep]; exists {
+				d = max(d, depDepth+1)
+			}
+		}
+		depth[name] = d
+		if !item.HasTag(tag) {
+			continue
+		}
+		for len(levels) <= d {
+			levels = append(levels, nil)
+		}
+		levels[d] = append(levels[d], name)
+	}
+
+	// Depths only reached through untagged items leave gaps
+	out := levels[:0]
+	for _, level := range levels {
+		if len(level) > 0 {
+			sort.Strings(level)
+			out = append(out, level)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,96 @@
+package core
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestValidateDependencies(t *testing.T) {
+	items := []WatchlistItem{
+		{ServiceName: "db"},
+		{ServiceName: "cache"},
+		{ServiceName: "api", DependsOn: []string{"db", "cache"}},
+		{ServiceName: "web", DependsOn: []string{"api"}},
+	}
+	tests := []struct {
+		name      string
+		service   string
+		dependsOn []string
+		wantErr   string
+	}{
+		{name: "new dependency", service: "cache", dependsOn: []string{"db"}},
+		{name: "clear", service: "web", dependsOn: nil},
+		{name: "not watched", service: "nope", wantErr: "service not in watchlist: nope"},
+		{name: "itself", service: "db", dependsOn: []string{"db"}, wantErr: "service can't depend on itself: db"},
+		{name: "duplicate", service: "web", dependsOn: []string{"api", "api"}, wantErr: "duplicate dependency: api"},
+		{name: "dependency not watched", service: "web", dependsOn: []string{"queue"}, wantErr: "dependency not in watchlist: queue"},
+		{name: "cycle", service: "db", dependsOn: []string{"web"}, wantErr: "dependency cycle: api -> db -> web -> api"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateDependencies(items, tt.service, tt.dependsOn)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+	if len(items[0].DependsOn) != 0 {
+		t.Error("ValidateDependencies changed the items it was given")
+	}
+}
+
+func TestValidateRemoval(t *testing.T) {
+	items := []WatchlistItem{
+		{ServiceName: "db"},
+		{ServiceName: "api", DependsOn: []string{"db"}},
+		{ServiceName: "worker", DependsOn: []string{"db"}},
+	}
+	if err := ValidateRemoval(items, "api"); err != nil {
+		t.Errorf("removing api: %v", err)
+	}
+	if err := ValidateRemoval(items, "db"); err == nil || err.Error() != "service is a dependency of: api, worker" {
+		t.Errorf("removing db: %v", err)
+	}
+	if err := ValidateRemoval(items, "nope"); !errors.Is(err, ErrNotInWatchlist) {
+		t.Errorf("removing nope: %v, want ErrNotInWatchlist", err)
+	}
+}
+
+func TestGroupLevels(t *testing.T) {
+	items := []WatchlistItem{
+		{ServiceName: "db", Tags: []string{"stack"}},
+		{ServiceName: "cache", Tags: []string{"stack"}},
+		{ServiceName: "proxy", DependsOn: []string{"api"}},
+		{ServiceName: "api", DependsOn: []string{"db", "cache"}, Tags: []string{"stack"}},
+		{ServiceName: "worker", DependsOn: []string{"db"}, Tags: []string{"stack"}},
+		{ServiceName: "web", DependsOn: []string{"proxy"}, Tags: []string{"stack", "front"}},
+	}
+	tests := []struct {
+		tag  string
+		want [][]string
+	}{
+		{tag: "stack", want: [][]string{{"cache", "db"}, {"api", "worker"}, {"web"}}},
+		{tag: "front", want: [][]string{{"web"}}},
+		{tag: "none", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			levels, err := GroupLevels(items, tt.tag)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(levels, tt.want) {
+				t.Errorf("levels = %v, want %v", levels, tt.want)
+			}
+		})
+	}
+
+	items[0].DependsOn = []string{"web"}
+	if _, err := GroupLevels(items, "stack"); err == nil {
+		t.Error("no error for a cycle")
+	}
+}
@@ -1,5 +1,16 @@
 package core
 
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
 // Service represents a system service.
 type Service struct {
 	Name          string  `json:"name"`
@@ -11,20 +22,760 @@ type Service struct {
 	CPUPercent    float64 `json:"cpuPercent,omitempty"`    // CPU usage percentage
 	MemoryMB      float64 `json:"memoryMB,omitempty"`      // Memory usage in MB
 	UptimeSeconds int64   `json:"uptimeSeconds,omitempty"` // How long service has been running
+	Backend       string  `json:"backend,omitempty"`       // Backend that owns the service when several are combined
+}
+
+// MetricValue returns a resource metric by its JSON name (cpuPercent or memoryMB).
+func (s Service) MetricValue(metric string) float64 {
+	switch metric {
+	case "cpuPercent":
+		return s.CPUPercent
+	case "memoryMB":
+		return s.MemoryMB
+	default:
+		return 0
+	}
 }
 
 // WatchlistItem represents an item in the watchlist.
 type WatchlistItem struct {
-	ServiceName  string   `json:"serviceName"`           // Name of the service being watched
-	AutoRestart  bool     `json:"autoRestart"`           // Should we auto-restart if it crashes?
-	RestartCount int      `json:"restartCount"`          // How many times have we restarted it?
-	FailCount    int      `json:"failCount,omitempty"`   // Consecutive failure count
-	LastRestart  string   `json:"lastRestart,omitempty"` // ISO timestamp of last restart
-	Service      *Service `json:"service,omitempty"`     // Current service state when fetched
+	ServiceName       string              `json:"serviceName"`                 // Name of the service being watched
+	Tags              []string            `json:"tags,omitempty"`              // Groups the item belongs to
+	AutoRestart       bool                `json:"autoRestart"`                 // Should we auto-restart if it crashes?
+	RestartPolicy     *RestartPolicy      `json:"restartPolicy,omitempty"`     // How to retry; nil uses DefaultRestartPolicy
+	Hooks             *RestartHooks       `json:"hooks,omitempty"`             // Commands and calls run around auto-restarts
+	HealthChecks      []HealthCheck       `json:"healthChecks,omitempty"`      // Active probes run while the service is up
+	Thresholds        []ThresholdRule     `json:"thresholds,omitempty"`        // Resource rules evaluated on each sample
+	Quarantine        *Quarantine         `json:"quarantine,omitempty"`        // Set while auto-restart is suspended for flapping
+	Maintenance       []MaintenanceWindow `json:"maintenance,omitempty"`       // Periods when auto-restart and alerts are suppressed
+	DependsOn         []string            `json:"dependsOn,omitempty"`         // Watchlist items that must be up before this one is started
+	RestartDependents bool                `json:"restartDependents,omitempty"` // Restart items depending on this one when it comes back up
+	RestartCount      int                 `json:"restartCount"`                // How many times have we restarted it?
+	FailCount         int                 `json:"failCount,omitempty"`         // Restart attempts since the service was last healthy
+	LastRestart       string              `json:"lastRestart,omitempty"`       // ISO timestamp of last restart
+	Service           *Service            `json:"service,omitempty"`           // Current service state when fetched
+	Status            *ItemStatus         `json:"status,omitempty"`            // Monitor's runtime view when fetched
+}
+
+// tagPattern keeps tags usable as URL path segments and query values.
+var tagPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// ValidateTags reports tags that can't be used to address a group.
+func ValidateTags(tags []string) error {
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		if !tagPattern.MatchString(tag) {
+			return fmt.Errorf("invalid tag %q: use letters, digits, '.', '_' and '-'", tag)
+		}
+		if seen[tag] {
+			return fmt.Errorf("duplicate tag: %s", tag)
+		}
+		seen[tag] = true
+	}
+	return nil
+}
+
+// HasTag reports whether the item belongs to the group tag.
+func (i WatchlistItem) HasTag(tag string) bool {
+	for _, t := range i.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// WatchlistUpdate is a set of changes to a watchlist item's config, applied
+// together or not at all. Nil fields are left as they are, so a nil
+// *RestartPolicy behind RestartPolicy restores the default.
+type WatchlistUpdate struct {
+	AutoRestart       *bool
+	RestartPolicy     **RestartPolicy
+	Hooks             **RestartHooks
+	HealthChecks      *[]HealthCheck
+	Thresholds        *[]ThresholdRule
+	Tags              *[]string
+	DependsOn         *[]string
+	RestartDependents *bool
+}
+
+// Apply checks every change against the watchlist items, which include item,
+// and only then applies them to item. On error item is left unchanged.
+func (u WatchlistUpdate) Apply(items []WatchlistItem, item *WatchlistItem) error {
+	if u.RestartPolicy != nil && *u.RestartPolicy != nil {
+		if err := (*u.RestartPolicy).Validate(); err != nil {
+			return fmt.Errorf("invalid restartPolicy: %w", err)
+		}
+	}
+	if u.Hooks != nil && *u.Hooks != nil {
+		if err := (*u.Hooks).Validate(); err != nil {
+			return fmt.Errorf("invalid hooks: %w", err)
+		}
+	}
+	if u.HealthChecks != nil {
+		if err := ValidateHealthChecks(*u.HealthChecks); err != nil {
+			return fmt.Errorf("invalid healthChecks: %w", err)
+		}
+	}
+	if u.Thresholds != nil {
+		if err := ValidateThresholdRules(*u.Thresholds); err != nil {
+			return fmt.Errorf("invalid thresholds: %w", err)
+		}
+	}
+	if u.Tags != nil {
+		if err := ValidateTags(*u.Tags); err != nil {
+			return fmt.Errorf("invalid tags: %w", err)
+		}
+	}
+	if u.DependsOn != nil {
+		if err := ValidateDependencies(items, item.ServiceName, *u.DependsOn); err != nil {
+			return fmt.Errorf("invalid dependsOn: %w", err)
+		}
+	}
+
+	if u.AutoRestart != nil {
+		if *u.AutoRestart {
+			item.FailCount = 0
+		}
+		item.AutoRestart = *u.AutoRestart
+	}
+	if u.RestartPolicy != nil {
+		item.RestartPolicy = *u.RestartPolicy
+	}
+	if u.Hooks != nil {
+		item.Hooks = *u.Hooks
+	}
+	if u.HealthChecks != nil {
+		item.HealthChecks = *u.HealthChecks
+	}
+	if u.Thresholds != nil {
+		item.Thresholds = *u.Thresholds
+	}
+	if u.Tags != nil {
+		item.Tags = *u.Tags
+	}
+	if u.DependsOn != nil {
+		item.DependsOn = *u.DependsOn
+	}
+	if u.RestartDependents != nil {
+		item.RestartDependents = *u.RestartDependents
+	}
+	return nil
+}
+
+// RestartRecord is one restart of a watchlist item, as kept by stores with RestartHistory.
+type RestartRecord struct {
+	ServiceName  string    `json:"serviceName"`
+	Time         time.Time `json:"time"`
+	RestartCount int       `json:"restartCount"` // The item's count after this restart
+}
+
+// ItemStatus is the monitor's runtime view of a watchlist item. It is not persisted.
+type ItemStatus struct {
+	Health      []HealthStatus     `json:"health,omitempty"`
+	Thresholds  []ThresholdStatus  `json:"thresholds,omitempty"`
+	FlapScore   float64            `json:"flapScore"`             // Weighted percent of recent state changes, newer ones counting more
+	Flapping    bool               `json:"flapping,omitempty"`    // FlapScore crossed the high threshold and hasn't dropped below the low one
+	Maintenance *ActiveMaintenance `json:"maintenance,omitempty"` // Window currently in effect
+	WaitingOn   []string           `json:"waitingOn,omitempty"`   // Dependencies holding back a restart
+}
+
+// HostResources is a sample of host CPU and memory usage.
+type HostResources struct {
+	CPUPercent  float64 `json:"cpuPercent"`
+	TotalMB     uint64  `json:"totalMB"`
+	UsedMB      uint64  `json:"usedMB"`
+	UsedPercent float64 `json:"usedPercent"`
+}
+
+// MonitorStats is the monitor's latest view of the watchlist and host, plus
+// counters about its own loop. It is not persisted.
+type MonitorStats struct {
+	Items        []WatchlistItem // As of the last check, with service details
+	Host         HostResources
+	LastCheck    time.Time
+	Checks       int64         // Checks completed since startup
+	CheckTime    time.Duration // Time spent in all checks since startup
+	LastDuration time.Duration // How long the last check took
+}
+
+// Quarantine records why auto-restart was suspended for a flapping service.
+type Quarantine struct {
+	Since     string  `json:"since"` // ISO timestamp
+	Reason    string  `json:"reason"`
+	FlapScore float64 `json:"flapScore"` // Score when the item was quarantined
+}
+
+// RestartPolicy controls how the monitor retries a service that stopped.
+// Zero fields fall back to DefaultRestartPolicy.
+type RestartPolicy struct {
+	MaxAttempts  int      `json:"maxAttempts,omitempty"`  // Give up after this many attempts without a healthy period (-1 = never)
+	InitialDelay Duration `json:"initialDelay,omitempty"` // Wait before the second attempt; the first one is immediate
+	Multiplier   float64  `json:"multiplier,omitempty"`   // Delay growth factor per attempt
+	MaxDelay     Duration `json:"maxDelay,omitempty"`     // Upper bound on the delay between attempts
+	ResetAfter   Duration `json:"resetAfter,omitempty"`   // Running this long clears the attempt count
+}
+
+// DefaultRestartPolicy is used for items without a policy and for unset policy fields.
+func DefaultRestartPolicy() RestartPolicy {
+	return RestartPolicy{
+		MaxAttempts:  3,
+		InitialDelay: Duration(5 * time.Second),
+		Multiplier:   2,
+		MaxDelay:     Duration(5 * time.Minute),
+		ResetAfter:   Duration(5 * time.Minute),
+	}
+}
+
+// WithDefaults returns the policy with unset fields filled from DefaultRestartPolicy.
+func (p RestartPolicy) WithDefaults() RestartPolicy {
+	def := DefaultRestartPolicy()
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = def.MaxAttempts
+	}
+	if p.InitialDelay == 0 {
+		p.InitialDelay = def.InitialDelay
+	}
+	if p.Multiplier == 0 {
+		p.Multiplier = def.Multiplier
+	}
+	if p.MaxDelay == 0 {
+		p.MaxDelay = def.MaxDelay
+	}
+	if p.ResetAfter == 0 {
+		p.ResetAfter = def.ResetAfter
+	}
+	return p
+}
+
+// Validate reports settings that can't be enforced.
+func (p RestartPolicy) Validate() error {
+	switch {
+	case p.MaxAttempts < -1:
+		return fmt.Errorf("maxAttempts must be -1 (unlimited) or more")
+	case p.InitialDelay < 0 || p.MaxDelay < 0 || p.ResetAfter < 0:
+		return fmt.Errorf("delays must not be negative")
+	case p.Multiplier != 0 && p.Multiplier < 1:
+		return fmt.Errorf("multiplier must be at least 1")
+	}
+	return nil
+}
+
+// Delay returns how long to wait before the next attempt when `attempts` have already been made.
+func (p RestartPolicy) Delay(attempts int) time.Duration {
+	p = p.WithDefaults()
+	if attempts <= 0 {
+		return 0
+	}
+	delay := float64(p.InitialDelay) * math.Pow(p.Multiplier, float64(attempts-1))
+	if delay > float64(p.MaxDelay) {
+		return time.Duration(p.MaxDelay)
+	}
+	return time.Duration(delay)
+}
+
+// Policy returns the item's restart policy with defaults applied.
+func (i WatchlistItem) Policy() RestartPolicy {
+	if i.RestartPolicy == nil {
+		return DefaultRestartPolicy()
+	}
+	return i.RestartPolicy.WithDefaults()
+}
+
+// RestartHooks are run around the monitor's restarts of an item, in order within each stage.
+type RestartHooks struct {
+	PreRestart  []Hook `json:"preRestart,omitempty"`  // Before starting the service, e.g. to clean a lock file
+	PostSuccess []Hook `json:"postSuccess,omitempty"` // After the service restarted, e.g. to re-enable it in a load balancer
+	PostFailure []Hook `json:"postFailure,omitempty"` // After a restart attempt failed
+}
+
+// Validate reports hooks that can't be run.
+func (h RestartHooks) Validate() error {
+	for _, stage := range [][]Hook{h.PreRestart, h.PostSuccess, h.PostFailure} {
+		for _, hook := range stage {
+			if err := hook.Validate(); err != nil {
+				return err
+			}
+		}
+	}
+	for _, hook := range append(h.PostSuccess, h.PostFailure...) {
+		if hook.AbortOnFailure {
+			return fmt.Errorf("abortOnFailure only applies to preRestart hooks")
+		}
+	}
+	return nil
+}
+
+// MaxHookTimeout is the longest a hook may run. A restart waits for its hooks.
+const MaxHookTimeout = Duration(5 * time.Minute)
+
+// Hook is a command or HTTP call run around an auto-restart.
+type Hook struct {
+	Name           string            `json:"name,omitempty"`           // Label used in events; defaults to type and target
+	Type           string            `json:"type"`                     // exec|http
+	Command        []string          `json:"command,omitempty"`        // exec: argv to run
+	URL            string            `json:"url,omitempty"`            // http: URL to call
+	Method         string            `json:"method,omitempty"`         // http: request method (default POST)
+	Headers        map[string]string `json:"headers,omitempty"`        // http: extra request headers
+	Body           string            `json:"body,omitempty"`           // http: request body
+	Timeout        Duration          `json:"timeout,omitempty"`        // Default 10s, at most MaxHookTimeout
+	AbortOnFailure bool              `json:"abortOnFailure,omitempty"` // preRestart only: skip the restart if the hook fails
+}
+
+// WithDefaults returns the hook with unset fields filled in.
+func (h Hook) WithDefaults() Hook {
+	if h.Timeout == 0 {
+		h.Timeout = Duration(10 * time.Second)
+	}
+	// Hooks saved before the limit existed
+	if h.Timeout > MaxHookTimeout {
+		h.Timeout = MaxHookTimeout
+	}
+	if h.Type == "http" && h.Method == "" {
+		h.Method = "POST"
+	}
+	return h
+}
+
+// Label identifies the hook in events.
+func (h Hook) Label() string {
+	if h.Name != "" {
+		return h.Name
+	}
+	switch h.Type {
+	case "http":
+		return "http " + h.URL
+	case "exec":
+		return "exec " + strings.Join(h.Command, " ")
+	default:
+		return h.Type
+	}
+}
+
+// Validate reports hooks that can't be run.
+func (h Hook) Validate() error {
+	switch h.Type {
+	case "http":
+		if h.URL == "" {
+			return fmt.Errorf("http hook needs a url")
+		}
+	case "exec":
+		if len(h.Command) == 0 {
+			return fmt.Errorf("exec hook needs a command")
+		}
+	default:
+		return fmt.Errorf("unknown hook type: %q", h.Type)
+	}
+	if h.Timeout < 0 || h.Timeout > MaxHookTimeout {
+		return fmt.Errorf("timeout must be between 0 and %s", time.Duration(MaxHookTimeout))
+	}
+	return nil
+}
+
+// HealthCheck is an active probe of a watched service.
+type HealthCheck struct {
+	Name             string   `json:"name,omitempty"`             // Label used in events; defaults to type and target
+	Type             string   `json:"type"`                       // http|tcp|exec
+	URL              string   `json:"url,omitempty"`              // http: URL to GET
+	ExpectStatus     int      `json:"expectStatus,omitempty"`     // http: expected status (default any 2xx)
+	BodyRegex        string   `json:"bodyRegex,omitempty"`        // http: pattern the response body must match
+	Address          string   `json:"address,omitempty"`          // tcp: host:port to connect to
+	Command          []string `json:"command,omitempty"`          // exec: argv to run
+	ExpectExitCode   int      `json:"expectExitCode,omitempty"`   // exec: expected exit code (default 0)
+	Interval         Duration `json:"interval,omitempty"`         // Time between probes (default 30s)
+	Timeout          Duration `json:"timeout,omitempty"`          // Per-probe timeout (default 5s)
+	FailureThreshold int      `json:"failureThreshold,omitempty"` // Consecutive failures before unhealthy (default 3)
+}
+
+// WithDefaults returns the check with unset timing fields filled in.
+func (c HealthCheck) WithDefaults() HealthCheck {
+	if c.Interval == 0 {
+		c.Interval = Duration(30 * time.Second)
+	}
+	if c.Timeout == 0 {
+		c.Timeout = Duration(5 * time.Second)
+	}
+	if c.FailureThreshold == 0 {
+		c.FailureThreshold = 3
+	}
+	return c
+}
+
+// Label identifies the check in events and status.
+func (c HealthCheck) Label() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	switch c.Type {
+	case "http":
+		return "http " + c.URL
+	case "tcp":
+		return "tcp " + c.Address
+	case "exec":
+		return "exec " + strings.Join(c.Command, " ")
+	default:
+		return c.Type
+	}
+}
+
+// Validate reports checks that can't be run.
+func (c HealthCheck) Validate() error {
+	switch c.Type {
+	case "http":
+		if c.URL == "" {
+			return fmt.Errorf("http health check needs a url")
+		}
+		if c.BodyRegex != "" {
+			if _, err := regexp.Compile(c.BodyRegex); err != nil {
+				return fmt.Errorf("invalid bodyRegex: %w", err)
+			}
+		}
+	case "tcp":
+		if c.Address == "" {
+			return fmt.Errorf("tcp health check needs an address")
+		}
+	case "exec":
+		if len(c.Command) == 0 {
+			return fmt.Errorf("exec health check needs a command")
+		}
+	default:
+		return fmt.Errorf("unknown health check type: %q", c.Type)
+	}
+	if c.Interval < 0 || c.Timeout < 0 || c.FailureThreshold < 0 {
+		return fmt.Errorf("interval, timeout and failureThreshold must not be negative")
+	}
+	return nil
+}
+
+// ValidateHealthChecks reports the first check that can't be run.
+func ValidateHealthChecks(checks []HealthCheck) error {
+	for _, check := range checks {
+		if err := check.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HealthStatus is the latest outcome of a health check.
+type HealthStatus struct {
+	Check               string    `json:"check"`               // HealthCheck.Label
+	Healthy             bool      `json:"healthy"`             // False once FailureThreshold is reached
+	ConsecutiveFailures int       `json:"consecutiveFailures"` // Failures since the last success
+	LastCheck           time.Time `json:"lastCheck,omitempty"`
+	LastDuration        Duration  `json:"lastDuration,omitempty"`
+	LastError           string    `json:"lastError,omitempty"`
+}
+
+// ThresholdRule acts on a service whose resource usage stays past a limit,
+// e.g. memoryMB > 1500 for 5m -> restart, or cpuPercent > 90 for 10 checks -> alert.
+type ThresholdRule struct {
+	Name     string   `json:"name,omitempty"`   // Label used in events; defaults to the condition
+	Metric   string   `json:"metric"`           // cpuPercent|memoryMB
+	Operator string   `json:"operator"`         // >|>=|<|<=
+	Value    float64  `json:"value"`            // Limit to compare against
+	For      Duration `json:"for,omitempty"`    // Condition must hold for this long (at most MaxThresholdFor)...
+	Checks   int      `json:"checks,omitempty"` // ...or for this many consecutive samples (default 1, at most MaxThresholdChecks)
+	Action   string   `json:"action"`           // restart|alert
+}
+
+// Label identifies the rule in events and status.
+func (r ThresholdRule) Label() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	label := fmt.Sprintf("%s %s %g", r.Metric, r.Operator, r.Value)
+	if r.For > 0 {
+		label += " for " + time.Duration(r.For).String()
+	} else if r.Checks > 1 {
+		label += fmt.Sprintf(" for %d checks", r.Checks)
+	}
+	return label
+}
+
+// Breached reports whether v is past the rule's limit.
+func (r ThresholdRule) Breached(v float64) bool {
+	switch r.Operator {
+	case ">":
+		return v > r.Value
+	case ">=":
+		return v >= r.Value
+	case "<":
+		return v < r.Value
+	case "<=":
+		return v <= r.Value
+	default:
+		return false
+	}
+}
+
+// Limits on a threshold rule's window. The monitor keeps MaxThresholdChecks samples
+// per item, which also covers MaxThresholdFor at its 2s poll.
+const (
+	MaxThresholdChecks = 2000
+	MaxThresholdFor    = Duration(time.Hour)
+)
+
+// Validate reports rules that can't be evaluated.
+func (r ThresholdRule) Validate() error {
+	switch r.Metric {
+	case "cpuPercent", "memoryMB":
+	default:
+		return fmt.Errorf("unknown threshold metric: %q", r.Metric)
+	}
+	switch r.Operator {
+	case ">", ">=", "<", "<=":
+	default:
+		return fmt.Errorf("unknown threshold operator: %q", r.Operator)
+	}
+	switch r.Action {
+	case "restart", "alert":
+	default:
+		return fmt.Errorf("unknown threshold action: %q", r.Action)
+	}
+	if r.For < 0 || r.Checks < 0 {
+		return fmt.Errorf("for and checks must not be negative")
+	}
+	if r.For > MaxThresholdFor {
+		return fmt.Errorf("for must be at most %s", time.Duration(MaxThresholdFor))
+	}
+	if r.Checks > MaxThresholdChecks {
+		return fmt.Errorf("checks must be at most %d", MaxThresholdChecks)
+	}
+	return nil
+}
+
+// ValidateThresholdRules reports the first rule that can't be evaluated.
+func ValidateThresholdRules(rules []ThresholdRule) error {
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ThresholdStatus reports whether a threshold rule is currently exceeded.
+type ThresholdStatus struct {
+	Rule   string    `json:"rule"`            // ThresholdRule.Label
+	Firing bool      `json:"firing"`          // Condition has held for the rule's window
+	Since  time.Time `json:"since,omitempty"` // When the rule started firing
+	Value  float64   `json:"value"`           // Latest sample of the rule's metric
+}
+
+// MaintenanceWindow suppresses auto-restart and alerts for an item, either once
+// between Start and End or every time Cron fires for Duration.
+type MaintenanceWindow struct {
+	ID       string   `json:"id"`
+	Reason   string   `json:"reason,omitempty"`
+	Start    string   `json:"start,omitempty"`    // Ad-hoc: ISO timestamp the window opens
+	End      string   `json:"end,omitempty"`      // Ad-hoc: ISO timestamp the window closes; empty keeps it open until removed
+	Cron     string   `json:"cron,omitempty"`     // Recurring: when each occurrence opens, e.g. "0 2 * * SUN"
+	Duration Duration `json:"duration,omitempty"` // Recurring: how long each occurrence lasts
+}
+
+// Validate reports windows that are neither a valid ad-hoc nor a valid recurring window.
+func (m MaintenanceWindow) Validate() error {
+	if m.Cron != "" {
+		if _, err := cron.ParseStandard(m.Cron); err != nil {
+			return fmt.Errorf("invalid cron expression: %w", err)
+		}
+		if m.Duration <= 0 {
+			return fmt.Errorf("recurring maintenance needs a positive duration")
+		}
+		return nil
+	}
+
+	start, err := time.Parse(time.RFC3339, m.Start)
+	if err != nil {
+		return fmt.Errorf("invalid start: %w", err)
+	}
+	if m.End == "" {
+		return nil
+	}
+	end, err := time.Parse(time.RFC3339, m.End)
+	if err != nil {
+		return fmt.Errorf("invalid end: %w", err)
+	}
+	if !end.After(start) {
+		return fmt.Errorf("end must be after start")
+	}
+	return nil
+}
+
+// ActiveAt reports whether the window is in effect at t, and when it closes.
+// The close time is zero for a window without an end.
+func (m MaintenanceWindow) ActiveAt(t time.Time) (time.Time, bool) {
+	if m.Cron != "" {
+		sched, err := cron.ParseStandard(m.Cron)
+		if err != nil {
+			return time.Time{}, false
+		}
+		// The latest occurrence that could still be open started after t-Duration
+		opened := sched.Next(t.Add(-time.Duration(m.Duration)))
+		if opened.After(t) {
+			return time.Time{}, false
+		}
+		return opened.Add(time.Duration(m.Duration)), true
+	}
+
+	start, err1 := time.Parse(time.RFC3339, m.Start)
+	if m.End == "" {
+		return time.Time{}, err1 == nil && !t.Before(start)
+	}
+	end, err2 := time.Parse(time.RFC3339, m.End)
+	if err1 != nil || err2 != nil || t.Before(start) || !t.Before(end) {
+		return time.Time{}, false
+	}
+	return end, true
+}
+
+// Expired reports whether an ad-hoc window has closed for good. Recurring windows never expire.
+func (m MaintenanceWindow) Expired(t time.Time) bool {
+	if m.Cron != "" {
+		return false
+	}
+	end, err := time.Parse(time.RFC3339, m.End)
+	return err == nil && !t.Before(end)
+}
+
+// AddMaintenanceWindow adds a window to the item, pruning ad-hoc windows that
+// have closed by now.
+func (i *WatchlistItem) AddMaintenanceWindow(window MaintenanceWindow, now time.Time) error {
+	windows := make([]MaintenanceWindow, 0, len(i.Maintenance)+1)
+	for _, existing := range i.Maintenance {
+		if existing.ID == window.ID {
+			return fmt.Errorf("maintenance window already exists: %s", window.ID)
+		}
+		if !existing.Expired(now) {
+			windows = append(windows, existing)
+		}
+	}
+	i.Maintenance = append(windows, window)
+	return nil
+}
+
+// RemoveMaintenanceWindow removes the item's window with the given ID.
+func (i *WatchlistItem) RemoveMaintenanceWindow(id string) error {
+	for n, window := range i.Maintenance {
+		if window.ID == id {
+			i.Maintenance = append(i.Maintenance[:n:n], i.Maintenance[n+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("maintenance window not found: %s", id)
+}
+
+// ActiveMaintenance describes the maintenance window an item is currently in.
+type ActiveMaintenance struct {
+	WindowID string    `json:"windowId"`
+	Reason   string    `json:"reason,omitempty"`
+	Until    time.Time `json:"until,omitzero"` // Zero while the window has no end
+}
+
+// Schedule runs a service action whenever Cron fires, on one service or on
+// every watchlist item of a group.
+type Schedule struct {
+	ID          string          `json:"id"`
+	Name        string          `json:"name,omitempty"`        // Label used in events
+	Cron        string          `json:"cron"`                  // Standard 5-field expression in local time, e.g. "0 3 * * *"
+	Action      string          `json:"action"`                // start|stop|restart
+	ServiceName string          `json:"serviceName,omitempty"` // Target service...
+	Tag         string          `json:"tag,omitempty"`         // ...or every watchlist item with this tag
+	Hold        Duration        `json:"hold,omitempty"`        // stop: keep watched targets from being auto-restarted this long
+	Status      *ScheduleStatus `json:"status,omitempty"`      // Scheduler's runtime view when fetched
+}
+
+// Validate reports schedules that can't be run.
+func (s Schedule) Validate() error {
+	if _, err := cron.ParseStandard(s.Cron); err != nil {
+		return fmt.Errorf("invalid cron expression: %w", err)
+	}
+	switch s.Action {
+	case "start", "stop", "restart":
+	default:
+		return fmt.Errorf("unknown schedule action: %q", s.Action)
+	}
+	if (s.ServiceName == "") == (s.Tag == "") {
+		return fmt.Errorf("schedule needs either a serviceName or a tag")
+	}
+	if s.Hold < 0 {
+		return fmt.Errorf("hold must not be negative")
+	}
+	if s.Hold > 0 && s.Action != "stop" {
+		return fmt.Errorf("hold only applies to stop schedules")
+	}
+	return nil
+}
+
+// Label identifies the schedule in events.
+func (s Schedule) Label() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	target := s.ServiceName
+	if s.Tag != "" {
+		target = "tag " + s.Tag
+	}
+	return fmt.Sprintf("%s %s at %s", s.Action, target, s.Cron)
+}
+
+// Next returns when the schedule fires next after t, or zero if its expression is invalid.
+func (s Schedule) Next(t time.Time) time.Time {
+	sched, err := cron.ParseStandard(s.Cron)
+	if err != nil {
+		return time.Time{}
+	}
+	return sched.Next(t)
+}
+
+// ScheduleStatus is the scheduler's runtime view of a schedule. It is not persisted.
+type ScheduleStatus struct {
+	NextRun    time.Time `json:"nextRun"`
+	LastRun    time.Time `json:"lastRun,omitempty"`
+	LastResult string    `json:"lastResult,omitempty"` // ok|failed
+	LastError  string    `json:"lastError,omitempty"`
+	Running    bool      `json:"running"`
+}
+
+// StateChange reports a service transitioning to a new state.
+type StateChange struct {
+	Name  string    `json:"name"`
+	State string    `json:"state"` // Same values as Service.State
+	Time  time.Time `json:"time"`
+	Error string    `json:"error,omitempty"` // Set instead of Name and State when the stream failed
 }
 
 // Represents an SSE event.
 type Event struct {
-	Type string      `json:"type"`
-	Data interface{} `json:"data"`
+	Type  string      `json:"type"`
+	Level string      `json:"level,omitempty"` // INFO|ERROR
+	Time  time.Time   `json:"time"`
+	Data  interface{} `json:"data"`
+}
+
+// Duration is a time.Duration that marshals to JSON as a string like "30s".
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		// Accept plain numbers as seconds
+		var secs float64
+		if err := json.Unmarshal(b, &secs); err != nil {
+			return fmt.Errorf("invalid duration: %s", b)
+		}
+		*d = Duration(secs * float64(time.Second))
+		return nil
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
 }
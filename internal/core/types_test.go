@@ -0,0 +1,127 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMaintenanceWindowActiveAt(t *testing.T) {
+	// 2025-06-01 is a Sunday
+	at := func(day, hour, min int) time.Time { return time.Date(2025, 6, day, hour, min, 0, 0, time.UTC) }
+	weekly := MaintenanceWindow{Cron: "0 2 * * SUN", Duration: Duration(2 * time.Hour)}
+	fixed := MaintenanceWindow{Start: "2025-06-01T10:00:00Z", End: "2025-06-01T12:00:00Z"}
+	open := MaintenanceWindow{Start: "2025-06-01T10:00:00Z"}
+
+	tests := []struct {
+		name       string
+		window     MaintenanceWindow
+		t          time.Time
+		wantActive bool
+		wantUntil  time.Time
+	}{
+		{name: "recurring before", window: weekly, t: at(1, 1, 59)},
+		{name: "recurring opens", window: weekly, t: at(1, 2, 0), wantActive: true, wantUntil: at(1, 4, 0)},
+		{name: "recurring during", window: weekly, t: at(1, 3, 30), wantActive: true, wantUntil: at(1, 4, 0)},
+		{name: "recurring closes", window: weekly, t: at(1, 4, 0)},
+		{name: "recurring other day", window: weekly, t: at(2, 3, 0)},
+		{name: "recurring next week", window: weekly, t: at(8, 2, 30), wantActive: true, wantUntil: at(8, 4, 0)},
+		{
+			// An occurrence running past midnight is still open the next day
+			name:       "recurring across midnight",
+			window:     MaintenanceWindow{Cron: "0 23 * * *", Duration: Duration(3 * time.Hour)},
+			t:          at(2, 1, 0),
+			wantActive: true,
+			wantUntil:  at(2, 2, 0),
+		},
+		{name: "recurring bad cron", window: MaintenanceWindow{Cron: "nope", Duration: Duration(time.Hour)}, t: at(1, 2, 0)},
+		{name: "fixed before", window: fixed, t: at(1, 9, 59)},
+		{name: "fixed opens", window: fixed, t: at(1, 10, 0), wantActive: true, wantUntil: at(1, 12, 0)},
+		{name: "fixed closes", window: fixed, t: at(1, 12, 0)},
+		{name: "open-ended before", window: open, t: at(1, 9, 0)},
+		{name: "open-ended", window: open, t: at(30, 0, 0), wantActive: true},
+		{name: "bad start", window: MaintenanceWindow{Start: "soon"}, t: at(1, 10, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			until, active := tt.window.ActiveAt(tt.t)
+			if active != tt.wantActive || !until.Equal(tt.wantUntil) {
+				t.Errorf("ActiveAt = %v, %v, want %v, %v", until, active, tt.wantUntil, tt.wantActive)
+			}
+		})
+	}
+}
+
+func TestMaintenanceWindowExpired(t *testing.T) {
+	end := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		window MaintenanceWindow
+		t      time.Time
+		want   bool
+	}{
+		{name: "fixed open", window: MaintenanceWindow{Start: "2025-06-01T10:00:00Z", End: "2025-06-01T12:00:00Z"}, t: end.Add(-time.Second)},
+		{name: "fixed closed", window: MaintenanceWindow{Start: "2025-06-01T10:00:00Z", End: "2025-06-01T12:00:00Z"}, t: end, want: true},
+		{name: "not started", window: MaintenanceWindow{Start: "2025-07-01T10:00:00Z", End: "2025-07-01T12:00:00Z"}, t: end},
+		{name: "no end", window: MaintenanceWindow{Start: "2025-06-01T10:00:00Z"}, t: end.AddDate(1, 0, 0)},
+		{name: "recurring", window: MaintenanceWindow{Cron: "0 2 * * SUN", Duration: Duration(time.Hour)}, t: end.AddDate(1, 0, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.window.Expired(tt.t); got != tt.want {
+				t.Errorf("Expired = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddMaintenanceWindowPrunesExpired(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	item := WatchlistItem{ServiceName: "app", Maintenance: []MaintenanceWindow{
+		{ID: "past", Start: "2025-05-01T10:00:00Z", End: "2025-05-01T12:00:00Z"},
+		{ID: "current", Start: "2025-06-01T10:00:00Z", End: "2025-06-01T14:00:00Z"},
+		{ID: "open", Start: "2025-05-01T10:00:00Z"},
+		{ID: "weekly", Cron: "0 2 * * SUN", Duration: Duration(time.Hour)},
+	}}
+
+	if err := item.AddMaintenanceWindow(MaintenanceWindow{ID: "current"}, now); err == nil {
+		t.Error("added a window with a taken ID")
+	}
+	if len(item.Maintenance) != 4 {
+		t.Errorf("failed add changed the windows: %+v", item.Maintenance)
+	}
+
+	if err := item.AddMaintenanceWindow(MaintenanceWindow{ID: "new", Start: "2025-06-02T10:00:00Z"}, now); err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, window := range item.Maintenance {
+		ids = append(ids, window.ID)
+	}
+	if want := []string{"current", "open", "weekly", "new"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("windows = %v, want %v", ids, want)
+	}
+}
+
+func TestMaintenanceWindowValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		window  MaintenanceWindow
+		wantErr bool
+	}{
+		{name: "recurring", window: MaintenanceWindow{Cron: "0 2 * * SUN", Duration: Duration(time.Hour)}},
+		{name: "recurring without duration", window: MaintenanceWindow{Cron: "0 2 * * SUN"}, wantErr: true},
+		{name: "bad cron", window: MaintenanceWindow{Cron: "sundays", Duration: Duration(time.Hour)}, wantErr: true},
+		{name: "fixed", window: MaintenanceWindow{Start: "2025-06-01T10:00:00Z", End: "2025-06-01T12:00:00Z"}},
+		{name: "no end", window: MaintenanceWindow{Start: "2025-06-01T10:00:00Z"}},
+		{name: "end before start", window: MaintenanceWindow{Start: "2025-06-01T10:00:00Z", End: "2025-06-01T09:00:00Z"}, wantErr: true},
+		{name: "no start", window: MaintenanceWindow{End: "2025-06-01T12:00:00Z"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.window.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
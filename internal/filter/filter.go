@@ -0,0 +1,331 @@
+// Package filter parses and evaluates filter expressions over log entries, e.g.
+//
+//	event in (restart_failed, service_failed) and level = ERROR and data.memoryMB > 500
+//
+// Fields are dotted paths into the entry (time, level, event, data.serviceName).
+// Comparisons are =, !=, <, <=, >, >=, "in (...)" and "contains" (a
+// case-insensitive substring match), combined with and, or, not and parentheses.
+// Numbers compare numerically, timestamps chronologically and anything else as
+// text. A field the entry doesn't have never matches a comparison.
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SyntaxError reports where an expression is malformed.
+type SyntaxError struct {
+	Pos int // 1-based position in the expression
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Msg, e.Pos)
+}
+
+// Expr is a parsed filter expression.
+type Expr interface {
+	// Match reports whether a log entry satisfies the expression.
+	Match(entry map[string]interface{}) bool
+}
+
+// Parse parses an expression. Errors are *SyntaxError.
+func Parse(src string) (Expr, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	expr, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.errorf(t, "unexpected %s", t)
+	}
+	return expr, nil
+}
+
+type parser struct {
+	tokens []token
+	next   int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) take() token {
+	t := p.tokens[p.next]
+	if t.kind != tokEOF {
+		p.next++
+	}
+	return t
+}
+
+func (p *parser) errorf(t token, format string, args ...any) error {
+	return &SyntaxError{Pos: t.pos + 1, Msg: fmt.Sprintf(format, args...)}
+}
+
+// or := and ("or" and)*
+func (p *parser) or() (Expr, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().is("or") {
+		p.take()
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = orExpr{left, right}
+	}
+	return left, nil
+}
+
+// and := unary ("and" unary)*
+func (p *parser) and() (Expr, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().is("and") {
+		p.take()
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = andExpr{left, right}
+	}
+	return left, nil
+}
+
+// unary := "not" unary | "(" or ")" | comparison
+func (p *parser) unary() (Expr, error) {
+	t := p.peek()
+	switch {
+	case t.is("not"):
+		p.take()
+		inner, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{inner}, nil
+	case t.kind == tokLParen:
+		p.take()
+		inner, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.take(); t.kind != tokRParen {
+			return nil, p.errorf(t, `expected ")" but found %s`, t)
+		}
+		return inner, nil
+	}
+	return p.comparison()
+}
+
+// comparison := field op value | field "in" "(" value ("," value)* ")" | field "contains" value
+func (p *parser) comparison() (Expr, error) {
+	t := p.take()
+	if t.kind != tokWord || isKeyword(t.text) {
+		return nil, p.errorf(t, "expected field name but found %s", t)
+	}
+	path := strings.Split(t.text, ".")
+	for _, part := range path {
+		if part == "" {
+			return nil, p.errorf(t, "invalid field name %s", t)
+		}
+	}
+
+	op := p.take()
+	switch {
+	case op.kind == tokOp:
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		return compareExpr{path: path, op: op.text, values: []literal{value}}, nil
+	case op.is("contains"):
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		return compareExpr{path: path, op: "contains", values: []literal{value}}, nil
+	case op.is("in"):
+		if t := p.take(); t.kind != tokLParen {
+			return nil, p.errorf(t, `expected "(" after in but found %s`, t)
+		}
+		var values []literal
+		for {
+			value, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+			t := p.take()
+			if t.kind == tokRParen {
+				break
+			}
+			if t.kind != tokComma {
+				return nil, p.errorf(t, `expected "," or ")" but found %s`, t)
+			}
+		}
+		return compareExpr{path: path, op: "in", values: values}, nil
+	}
+	return nil, p.errorf(op, "expected operator after %s but found %s", t, op)
+}
+
+func (p *parser) value() (literal, error) {
+	t := p.take()
+	if t.kind == tokString || (t.kind == tokWord && !isKeyword(t.text)) {
+		return newLiteral(t.text), nil
+	}
+	return literal{}, p.errorf(t, "expected value but found %s", t)
+}
+
+func isKeyword(word string) bool {
+	switch strings.ToLower(word) {
+	case "and", "or", "not", "in", "contains":
+		return true
+	}
+	return false
+}
+
+type andExpr struct{ left, right Expr }
+
+func (e andExpr) Match(entry map[string]interface{}) bool {
+	return e.left.Match(entry) && e.right.Match(entry)
+}
+
+type orExpr struct{ left, right Expr }
+
+func (e orExpr) Match(entry map[string]interface{}) bool {
+	return e.left.Match(entry) || e.right.Match(entry)
+}
+
+type notExpr struct{ inner Expr }
+
+func (e notExpr) Match(entry map[string]interface{}) bool {
+	return !e.inner.Match(entry)
+}
+
+// literal is a value in an expression, parsed ahead as a number and a time where it is one.
+type literal struct {
+	text     string
+	number   float64
+	isNumber bool
+	time     time.Time
+	isTime   bool
+}
+
+func newLiteral(text string) literal {
+	l := literal{text: text}
+	if n, err := strconv.ParseFloat(text, 64); err == nil {
+		l.number, l.isNumber = n, true
+	}
+	if t, err := time.Parse(time.RFC3339, text); err == nil {
+		l.time, l.isTime = t, true
+	}
+	return l
+}
+
+type compareExpr struct {
+	path   []string
+	op     string
+	values []literal
+}
+
+func (e compareExpr) Match(entry map[string]interface{}) bool {
+	value, ok := lookup(entry, e.path)
+	if !ok {
+		return false
+	}
+	switch e.op {
+	case "in":
+		for _, l := range e.values {
+			if c, ok := compare(value, l); ok && c == 0 {
+				return true
+			}
+		}
+		return false
+	case "contains":
+		return strings.Contains(strings.ToLower(fmt.Sprint(value)), strings.ToLower(e.values[0].text))
+	}
+
+	c, ok := compare(value, e.values[0])
+	if !ok {
+		return false
+	}
+	switch e.op {
+	case "=":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return false
+}
+
+// lookup follows a dotted path into an entry.
+func lookup(entry map[string]interface{}, path []string) (interface{}, bool) {
+	var value interface{} = entry
+	for _, key := range path {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = m[key]; !ok || value == nil {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// compare orders an entry value against a literal, reporting false when they
+// can't be compared (objects and arrays).
+func compare(value interface{}, l literal) (int, bool) {
+	switch v := value.(type) {
+	case float64:
+		if !l.isNumber {
+			return strings.Compare(strconv.FormatFloat(v, 'f', -1, 64), l.text), true
+		}
+		return compareNumbers(v, l.number), true
+	case string:
+		if l.isNumber {
+			if n, err := strconv.ParseFloat(v, 64); err == nil {
+				return compareNumbers(n, l.number), true
+			}
+		}
+		if l.isTime {
+			if t, err := time.Parse(time.RFC3339, v); err == nil {
+				return t.Compare(l.time), true
+			}
+		}
+		return strings.Compare(v, l.text), true
+	case bool:
+		return strings.Compare(strconv.FormatBool(v), strings.ToLower(l.text)), true
+	}
+	return 0, false
+}
+
+func compareNumbers(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,126 @@
+package filter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+var entry = map[string]interface{}{
+	"time":  "2025-11-04T12:00:00Z",
+	"level": "ERROR",
+	"event": "restart_failed",
+	"data": map[string]interface{}{
+		"serviceName": "Web API",
+		"memoryMB":    512.0,
+		"exitCode":    "3",
+		"healthy":     false,
+		"message":     `it's "quoted"`,
+		"tags":        []interface{}{"billing"},
+	},
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"level = ERROR", true},
+		{"level == ERROR", true},
+		{"level != ERROR", false},
+		{"LEVEL = ERROR", false},                           // Fields are case-sensitive...
+		{"level = ERROR AND event = restart_failed", true}, // ...keywords aren't
+
+		// and binds tighter than or, not tighter than both
+		{"event = restart_failed or level = INFO and event = nope", true},
+		{"(event = restart_failed or level = INFO) and event = nope", false},
+		{"not level = ERROR and event = nope", false},
+		{"not (level = ERROR and event = nope)", true},
+		{"not not level = ERROR", true},
+
+		{"event in (service_failed, restart_failed)", true},
+		{"event in (service_failed)", false},
+		{"not event in (service_failed)", true},
+		{"data.serviceName contains api", true},
+		{"data.serviceName contains 'b a'", true},
+		{"data.serviceName contains nope", false},
+
+		{"data.serviceName = 'Web API'", true},
+		{`data.serviceName = "Web API"`, true},
+		{"data.serviceName = Web", false},
+		{`data.message = 'it\'s "quoted"'`, true},
+		{`data.message = "it's \"quoted\""`, true},
+
+		// Numbers compare numerically, even when the entry holds them as text
+		{"data.memoryMB > 500", true},
+		{"data.memoryMB > 60", true},
+		{"data.memoryMB = 512.0", true},
+		{"data.memoryMB <= 511.9", false},
+		{"data.exitCode >= 3", true},
+		{"data.exitCode > 10", false},
+		{"data.serviceName > 10", true}, // Not a number, so compared as text
+
+		// Timestamps compare chronologically, whatever their zone
+		{"time > 2025-11-04T11:59:59Z", true},
+		{"time > 2025-11-04T12:30:00+01:00", true},
+		{"time >= 2025-11-04T12:00:00Z", true},
+		{"time < 2025-11-04T12:00:00Z", false},
+
+		{"data.healthy = FALSE", true},
+		{"data.healthy != true", true},
+
+		// A field the entry doesn't have never matches a comparison
+		{"data.pid = 1", false},
+		{"data.pid != 1", false},
+		{"not data.pid = 1", true},
+		{"data.serviceName.first = Web", false},
+		{"data.tags = billing", false},
+		{"data.tags contains billing", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			expr, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := expr.Match(entry); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		pos  int
+		msg  string
+	}{
+		{"", 1, "expected field name but found end of expression"},
+		{"and = 1", 1, `expected field name but found "and"`},
+		{"data..x = 1", 1, `invalid field name "data..x"`},
+		{"level ERROR", 7, `expected operator after "level" but found "ERROR"`},
+		{"level =", 8, "expected value but found end of expression"},
+		{"level = in", 9, `expected value but found "in"`},
+		{"level ! ERROR", 7, `expected "!="`},
+		{"level ~ ERROR", 7, "unexpected character '~'"},
+		{"level = 'ERROR", 9, "unterminated string"},
+		{"(level = ERROR", 15, `expected ")" but found end of expression`},
+		{"level = ERROR extra", 15, `unexpected "extra"`},
+		{"event in a", 10, `expected "(" after in but found "a"`},
+		{"event in (a b)", 13, `expected "," or ")" but found "b"`},
+		{"level = ERROR and", 18, "expected field name but found end of expression"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Parse(tt.expr)
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Parse() error = %v, want a SyntaxError", err)
+			}
+			if syntaxErr.Pos != tt.pos || !strings.Contains(syntaxErr.Msg, tt.msg) {
+				t.Errorf("Parse() error = %v, want %q at position %d", err, tt.msg, tt.pos)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/ethan-mdev/service-watch/internal/alerts"
+	"github.com/ethan-mdev/service-watch/internal/core"
+	"github.com/ethan-mdev/service-watch/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+type AlertsHTTP struct {
+	Engine *alerts.Engine
+}
+
+func NewAlertsHTTP(engine *alerts.Engine) *AlertsHTTP {
+	return &AlertsHTTP{Engine: engine}
+}
+
+// Routes sets up the HTTP routes for alerts.
+func (h *AlertsHTTP) Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Get("/", h.list)
+	r.Get("/rules", h.rules)
+	r.Route("/{id}", func(r chi.Router) {
+		r.Get("/", h.get)
+		r.Post("/ack", h.acknowledge)
+		r.Post("/silence", h.silence)
+		r.Delete("/silence", h.unsilence)
+	})
+	return r
+}
+
+// list returns alerts, newest first, filtered by ?state=, ?severity=, ?rule= and ?service=.
+func (h *AlertsHTTP) list(w http.ResponseWriter, r *http.Request) {
+	state := r.URL.Query().Get("state")
+	severity := r.URL.Query().Get("severity")
+	rule := r.URL.Query().Get("rule")
+	service := r.URL.Query().Get("service")
+
+	results := []alerts.Alert{}
+	for _, alert := range h.Engine.List() {
+		if state != "" && alert.State != state {
+			continue
+		}
+		if severity != "" && alert.Severity != severity {
+			continue
+		}
+		if rule != "" && alert.Rule != rule {
+			continue
+		}
+		if service != "" && alert.Labels["serviceName"] != service {
+			continue
+		}
+		results = append(results, alert)
+	}
+	utils.RespondWithJSON(w, 200, map[string]any{"count": len(results), "items": results})
+}
+
+func (h *AlertsHTTP) rules(w http.ResponseWriter, r *http.Request) {
+	utils.RespondWithJSON(w, 200, map[string]any{"items": h.Engine.Rules()})
+}
+
+func (h *AlertsHTTP) get(w http.ResponseWriter, r *http.Request) {
+	alert, err := h.Engine.Get(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondWithError(w, 404, "alert not found", err)
+		return
+	}
+	utils.RespondWithJSON(w, 200, alert)
+}
+
+func (h *AlertsHTTP) acknowledge(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := h.Engine.Get(id); err != nil {
+		utils.RespondWithError(w, 404, "alert not found", err)
+		return
+	}
+
+	var req struct {
+		By string `json:"by"`
+	}
+	// Body is optional
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondWithError(w, 400, "invalid request body", err)
+			return
+		}
+	}
+
+	alert, err := h.Engine.Acknowledge(id, req.By)
+	if err != nil {
+		utils.RespondWithError(w, 400, err.Error(), err)
+		return
+	}
+	utils.RespondWithJSON(w, 200, alert)
+}
+
+func (h *AlertsHTTP) silence(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := h.Engine.Get(id); err != nil {
+		utils.RespondWithError(w, 404, "alert not found", err)
+		return
+	}
+
+	var req struct {
+		Duration core.Duration `json:"duration"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, 400, "invalid request body", err)
+		return
+	}
+	if req.Duration <= 0 {
+		utils.RespondWithError(w, 400, "duration must be positive", nil)
+		return
+	}
+
+	alert, err := h.Engine.Silence(id, time.Duration(req.Duration))
+	if err != nil {
+		utils.RespondWithError(w, 400, err.Error(), err)
+		return
+	}
+	utils.RespondWithJSON(w, 200, alert)
+}
+
+func (h *AlertsHTTP) unsilence(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := h.Engine.Get(id); err != nil {
+		utils.RespondWithError(w, 404, "alert not found", err)
+		return
+	}
+
+	alert, err := h.Engine.Unsilence(id)
+	if err != nil {
+		utils.RespondWithError(w, 400, err.Error(), err)
+		return
+	}
+	utils.RespondWithJSON(w, 200, alert)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"sync/atomic"
 
 	"github.com/ethan-mdev/service-watch/internal/core"
 	"github.com/ethan-mdev/service-watch/internal/sse"
@@ -12,6 +13,7 @@ import (
 
 type EventsHTTP struct {
 	Broadcaster *sse.Broadcaster
+	clients     atomic.Int64 // Connected SSE clients
 }
 
 func NewEventsHTTP(broadcaster *sse.Broadcaster) *EventsHTTP {
@@ -34,6 +36,8 @@ func (h *EventsHTTP) Stream(w http.ResponseWriter, r *http.Request) {
 	// Register client
 	h.Broadcaster.RegisterClient(client)
 	defer h.Broadcaster.UnregisterClient(client)
+	h.clients.Add(1)
+	defer h.clients.Add(-1)
 
 	log.Printf("SSE: Client connected: %v", r.RemoteAddr)
 
@@ -51,3 +55,8 @@ func (h *EventsHTTP) Stream(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 }
+
+// Clients returns how many SSE clients are connected.
+func (h *EventsHTTP) Clients() int64 {
+	return h.clients.Load()
+}
@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethan-mdev/service-watch/internal/logger"
+	"github.com/ethan-mdev/service-watch/internal/utils"
+)
+
+// exportFlushEvery is how many entries are written between flushes to the client.
+const exportFlushEvery = 500
+
+// exportColumnSample is how many entries a CSV export without ?columns= reads
+// before settling its data columns.
+const exportColumnSample = 1000
+
+// exportFile is a log file to export and its entries in range.
+type exportFile struct {
+	file    logger.LogFile
+	id      int64
+	entries []logger.IndexEntry
+}
+
+// export streams the entries matching the /v1/metrics filters, oldest first, as
+// NDJSON or CSV, reading them straight from the log files. The response is
+// gzipped when the client accepts it.
+func (h *MetricsHTTP) export(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "ndjson"
+	}
+	if format != "ndjson" && format != "csv" {
+		utils.RespondWithError(w, 400, "format must be ndjson or csv", nil)
+		return
+	}
+
+	f, ok := h.parseFilter(w, r)
+	if !ok {
+		return
+	}
+
+	files, err := logger.Files(h.LogPath)
+	if err != nil {
+		utils.RespondWithError(w, 500, "failed to list log files", err)
+		return
+	}
+
+	// Settle which files are exported before writing anything, so errors can
+	// still be reported with a status code
+	var plan []exportFile
+	for _, logFile := range files {
+		if !logFile.HasEntriesSince(f.since) {
+			continue
+		}
+		index, err := logFile.Index()
+		// A damaged backup shouldn't hide everything else
+		if err != nil && !logFile.End.IsZero() {
+			log.Printf("Skipping unreadable log backup %s: %v", logFile.Path, err)
+			continue
+		} else if err != nil {
+			utils.RespondWithError(w, 500, "failed to read log file", err)
+			return
+		}
+		plan = append(plan, exportFile{file: logFile, id: index.ID, entries: entriesSince(index.Entries, f.since)})
+	}
+
+	// CSV takes its data columns from ?columns=, or else from the first
+	// exportColumnSample entries, so the entries are only read once
+	var columns []string
+	if format == "csv" {
+		for _, value := range r.URL.Query()["columns"] {
+			for _, column := range strings.Split(value, ",") {
+				if column = strings.TrimSpace(column); column != "" {
+					columns = append(columns, "data."+strings.TrimPrefix(column, "data."))
+				}
+			}
+		}
+	}
+
+	filename := "events." + format
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.Header().Set("Vary", "Accept-Encoding")
+
+	var out io.Writer = w
+	var gz *gzip.Writer
+	if acceptsGzip(r) {
+		w.Header().Set("Content-Encoding", "gzip")
+		gz = gzip.NewWriter(w)
+		out = gz
+	}
+	w.WriteHeader(200)
+
+	// No Content-Length, so the response goes out chunked as it is flushed
+	written := 0
+	flush := func() {
+		if gz != nil {
+			gz.Flush()
+		}
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+	}
+
+	var write func(entry map[string]interface{})
+	var writer *csv.Writer
+	var sampling bool                   // The CSV header waits for the sample
+	var sample []map[string]interface{} // Entries held back until then
+	writeRow := func(entry map[string]interface{}) {
+		data := flattenData(entry["data"])
+		row := []string{csvValue(entry["time"]), csvValue(entry["level"]), csvValue(entry["event"])}
+		for _, column := range columns {
+			row = append(row, data[column])
+		}
+		writer.Write(row)
+	}
+	writeHeader := func() {
+		writer.Write(append([]string{"time", "level", "event"}, columns...))
+	}
+	// settleColumns writes the header from the sampled entries' data fields,
+	// sorted by name, then the sampled entries themselves
+	settleColumns := func() {
+		seen := make(map[string]bool)
+		for _, entry := range sample {
+			for column := range flattenData(entry["data"]) {
+				if !seen[column] {
+					seen[column] = true
+					columns = append(columns, column)
+				}
+			}
+		}
+		sort.Strings(columns)
+		writeHeader()
+		for _, entry := range sample {
+			writeRow(entry)
+		}
+		sample, sampling = nil, false
+		write = writeRow
+	}
+
+	switch {
+	case format == "ndjson":
+		encoder := json.NewEncoder(out)
+		write = func(entry map[string]interface{}) { encoder.Encode(entry) }
+	case columns != nil:
+		writer = csv.NewWriter(out)
+		writeHeader()
+		write = writeRow
+	default:
+		writer = csv.NewWriter(out)
+		sampling = true
+		write = func(entry map[string]interface{}) {
+			if sample = append(sample, entry); len(sample) == exportColumnSample {
+				settleColumns()
+			}
+		}
+	}
+
+	for _, ef := range plan {
+		if r.Context().Err() != nil {
+			return
+		}
+		err := scanEntries(ef.file, ef.id, ef.entries, false, f.indexed, func(_ logger.Cursor, entry map[string]interface{}) bool {
+			if f.matches(entry) {
+				write(entry)
+				if written++; written%exportFlushEvery == 0 {
+					if writer != nil {
+						writer.Flush()
+					}
+					flush()
+				}
+			}
+			return r.Context().Err() == nil
+		})
+		if err != nil {
+			// The status is already sent; cutting the response short is the only way
+			// left to tell the client the export is incomplete
+			log.Printf("Aborting export at unreadable log file %s: %v", ef.file.Path, err)
+			panic(http.ErrAbortHandler)
+		}
+	}
+
+	if sampling {
+		settleColumns()
+	}
+	if writer != nil {
+		writer.Flush()
+	}
+	if gz != nil {
+		gz.Close()
+	}
+}
+
+// acceptsGzip reports whether the client takes gzip-encoded responses.
+func acceptsGzip(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(encoding), ";")
+		if strings.EqualFold(strings.TrimSpace(name), "gzip") {
+			return strings.ReplaceAll(params, " ", "") != "q=0"
+		}
+	}
+	return false
+}
+
+// flattenData turns an entry's data into CSV cells keyed by column, with nested
+// objects flattened into dotted names (data.check.status).
+func flattenData(data interface{}) map[string]string {
+	cells := make(map[string]string)
+	var walk func(prefix string, value interface{})
+	walk = func(prefix string, value interface{}) {
+		if m, ok := value.(map[string]interface{}); ok {
+			for key, v := range m {
+				walk(prefix+"."+key, v)
+			}
+			return
+		}
+		cells[prefix] = csvValue(value)
+	}
+	if m, ok := data.(map[string]interface{}); ok {
+		for key, v := range m {
+			walk("data."+key, v)
+		}
+	}
+	return cells
+}
+
+// csvValue formats a JSON value for a CSV cell. Arrays stay JSON.
+func csvValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	b, _ := json.Marshal(value)
+	return string(b)
+}
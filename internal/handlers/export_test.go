@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethan-mdev/service-watch/internal/logger"
+)
+
+// writeTestLog logs entries to a new log file and returns the handler reading it.
+func writeTestLog(t *testing.T, entries func(log *logger.Logger)) *MetricsHTTP {
+	t.Helper()
+	logPath := filepath.Join(t.TempDir(), "service-watch.jsonl")
+	log, err := logger.Start(logPath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries(log)
+	log.Close()
+	return NewMetricsHTTP(logPath, nil, nil)
+}
+
+func exportCSV(t *testing.T, h *MetricsHTTP, query string) [][]string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.export(w, httptest.NewRequest("GET", "/v1/metrics/export?format=csv"+query, nil))
+	if w.Code != 200 {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	rows, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+func TestExportCSV(t *testing.T) {
+	h := writeTestLog(t, func(log *logger.Logger) {
+		log.Info("restart_success", map[string]interface{}{"serviceName": "nginx", "restartCount": 3})
+		log.Error("service_unhealthy", map[string]interface{}{"serviceName": "db", "check": map[string]interface{}{"status": "down"}})
+	})
+
+	tests := []struct {
+		name   string
+		query  string
+		header []string
+		row    []string // Last row, after its time cell
+	}{
+		{
+			name:   "flattened",
+			header: []string{"time", "level", "event", "data.check.status", "data.restartCount", "data.serviceName"},
+			row:    []string{"ERROR", "service_unhealthy", "down", "", "db"},
+		},
+		{
+			name:   "columns",
+			query:  "&columns=serviceName,data.restartCount",
+			header: []string{"time", "level", "event", "data.serviceName", "data.restartCount"},
+			row:    []string{"ERROR", "service_unhealthy", "db", ""},
+		},
+		{
+			name:   "no entries",
+			query:  "&event=service_failed",
+			header: []string{"time", "level", "event"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := exportCSV(t, h, tt.query)
+			if !reflect.DeepEqual(rows[0], tt.header) {
+				t.Errorf("header = %v, want %v", rows[0], tt.header)
+			}
+			if tt.row == nil {
+				if len(rows) != 1 {
+					t.Errorf("got %d rows, want only the header", len(rows))
+				}
+				return
+			}
+			if last := rows[len(rows)-1]; !reflect.DeepEqual(last[1:], tt.row) {
+				t.Errorf("last row = %v, want %v", last[1:], tt.row)
+			}
+		})
+	}
+}
+
+func TestExportCSVSettlesColumnsOnSample(t *testing.T) {
+	h := writeTestLog(t, func(log *logger.Logger) {
+		for n := 0; n < exportColumnSample; n++ {
+			log.Info("tick", map[string]interface{}{"n": n})
+		}
+		log.Info("tick", map[string]interface{}{"n": exportColumnSample, "late": true})
+	})
+
+	rows := exportCSV(t, h, "")
+	if want := []string{"time", "level", "event", "data.n"}; !reflect.DeepEqual(rows[0], want) {
+		t.Errorf("header = %v, want %v", rows[0], want)
+	}
+	if len(rows) != exportColumnSample+2 {
+		t.Errorf("got %d rows, want %d", len(rows), exportColumnSample+2)
+	}
+
+	// Naming the late field includes it
+	rows = exportCSV(t, h, "&columns=n,late")
+	if last := rows[len(rows)-1]; last[4] != "true" {
+		t.Errorf("late = %q, want true", last[4])
+	}
+}
+
+func TestExportNDJSON(t *testing.T) {
+	h := writeTestLog(t, func(log *logger.Logger) {
+		log.Info("restart_success", map[string]interface{}{"serviceName": "nginx"})
+		log.Info("restart_success", map[string]interface{}{"serviceName": "db"})
+		log.Info("scheduler_started", nil)
+	})
+
+	w := httptest.NewRecorder()
+	h.export(w, httptest.NewRequest("GET", "/v1/metrics/export?event=restart_success", nil))
+	if w.Code != 200 {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var lines []string
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if len(lines) != 2 || !strings.Contains(lines[0], `"nginx"`) || !strings.Contains(lines[1], `"db"`) {
+		t.Errorf("lines = %q, want nginx then db", lines)
+	}
+}
@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+	"github.com/ethan-mdev/service-watch/internal/logger"
+	"github.com/ethan-mdev/service-watch/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// GroupsHTTP acts on every watchlist item carrying a tag.
+type GroupsHTTP struct {
+	Watchlist core.WatchlistManager
+	Services  core.ServiceManager
+	Log       *logger.Logger
+}
+
+func NewGroupsHTTP(watchlist core.WatchlistManager, services core.ServiceManager, log *logger.Logger) *GroupsHTTP {
+	return &GroupsHTTP{Watchlist: watchlist, Services: services, Log: log}
+}
+
+// Routes sets up the HTTP routes for group operations.
+func (h *GroupsHTTP) Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Get("/", h.list)
+	r.Route("/{group}", func(r chi.Router) {
+		r.Get("/", h.get)
+		r.Post("/start", h.action("start"))
+		r.Post("/stop", h.action("stop"))
+		r.Post("/restart", h.action("restart"))
+	})
+	return r
+}
+
+// groupRequest controls how a group operation is rolled out.
+type groupRequest struct {
+	Parallelism int           `json:"parallelism"` // Members of a level acted on at once (default all, or 1 when rolling)
+	Rolling     bool          `json:"rolling"`     // Go batch by batch within each level, stopping at the first failure
+	Pause       core.Duration `json:"pause"`       // Rolling only: wait between batches
+}
+
+// memberResult is the outcome of a group operation for one member.
+type memberResult struct {
+	ServiceName string `json:"serviceName"`
+	Status      string `json:"status"` // ok|failed|skipped
+	Error       string `json:"error,omitempty"`
+}
+
+func (h *GroupsHTTP) list(w http.ResponseWriter, r *http.Request) {
+	items, err := h.Watchlist.List(r.Context())
+	if err != nil {
+		utils.RespondWithError(w, 500, "failed to list watchlist", err)
+		return
+	}
+
+	members := make(map[string][]string)
+	for _, item := range items {
+		for _, tag := range item.Tags {
+			members[tag] = append(members[tag], item.ServiceName)
+		}
+	}
+
+	type group struct {
+		Name    string   `json:"name"`
+		Members []string `json:"members"`
+	}
+	groups := make([]group, 0, len(members))
+	for name, names := range members {
+		sort.Strings(names)
+		groups = append(groups, group{Name: name, Members: names})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+	utils.RespondWithJSON(w, 200, map[string]any{"items": groups})
+}
+
+func (h *GroupsHTTP) get(w http.ResponseWriter, r *http.Request) {
+	group := chi.URLParam(r, "group")
+	items, err := h.Watchlist.List(r.Context())
+	if err != nil {
+		utils.RespondWithError(w, 500, "failed to list watchlist", err)
+		return
+	}
+
+	var members []core.WatchlistItem
+	for _, item := range items {
+		if item.HasTag(group) {
+			members = append(members, item)
+		}
+	}
+	if len(members) == 0 {
+		utils.RespondWithError(w, 404, "group not found", nil)
+		return
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].ServiceName < members[j].ServiceName })
+	utils.RespondWithJSON(w, 200, map[string]any{"name": group, "items": members})
+}
+
+// action runs a service operation on every member of a group, one dependency
+// level at a time: dependencies are started and restarted before the members
+// that depend on them, and stopped after them.
+func (h *GroupsHTTP) action(action string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		group := chi.URLParam(r, "group")
+		var req groupRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			utils.RespondWithError(w, 400, "invalid request body", err)
+			return
+		}
+		if req.Parallelism < 0 {
+			utils.RespondWithError(w, 400, "parallelism must not be negative", nil)
+			return
+		}
+
+		levels, err := h.levels(r.Context(), group)
+		if err != nil {
+			utils.RespondWithError(w, 500, "failed to list watchlist", err)
+			return
+		}
+		if len(levels) == 0 {
+			utils.RespondWithError(w, 404, "group not found", nil)
+			return
+		}
+		if action == "stop" {
+			slices.Reverse(levels)
+		}
+
+		var members int
+		for _, level := range levels {
+			members += len(level)
+		}
+		if req.Parallelism == 0 {
+			req.Parallelism = members
+			if req.Rolling {
+				req.Parallelism = 1
+			}
+		}
+
+		results := h.run(r.Context(), action, levels, req)
+
+		var failed, skipped int
+		for _, res := range results {
+			switch res.Status {
+			case "failed":
+				failed++
+			case "skipped":
+				skipped++
+			}
+		}
+		event := map[string]interface{}{
+			"group":       group,
+			"action":      action,
+			"members":     members,
+			"failed":      failed,
+			"skipped":     skipped,
+			"parallelism": req.Parallelism,
+			"rolling":     req.Rolling,
+		}
+		code := 200
+		if failed > 0 {
+			h.Log.Error("group_action", event)
+			code = 424
+		} else {
+			h.Log.Info("group_action", event)
+		}
+
+		utils.RespondWithJSON(w, code, map[string]any{
+			"group":   group,
+			"action":  action,
+			"failed":  failed,
+			"skipped": skipped,
+			"results": results,
+		})
+	}
+}
+
+// levels returns the service names of a group by dependency level.
+func (h *GroupsHTTP) levels(ctx context.Context, group string) ([][]string, error) {
+	items, err := h.Watchlist.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return core.GroupLevels(items, group)
+}
+
+// run applies action to each level in turn, at most req.Parallelism members at a
+// time. A level only starts once the previous one has finished. A rolling run goes
+// in batches of req.Parallelism within each level and skips the remaining members
+// once one fails.
+func (h *GroupsHTTP) run(ctx context.Context, action string, levels [][]string, req groupRequest) []memberResult {
+	var members []string
+	var batches [][2]int // Index ranges into members
+	for _, level := range levels {
+		start := len(members)
+		members = append(members, level...)
+		size := len(level)
+		if req.Rolling {
+			size = req.Parallelism
+		}
+		for i := start; i < len(members); i += size {
+			batches = append(batches, [2]int{i, min(i+size, len(members))})
+		}
+	}
+
+	results := make([]memberResult, len(members))
+	apply := func(i int) {
+		var err error
+		switch action {
+		case "start":
+			err = h.Services.Start(ctx, members[i])
+		case "stop":
+			err = h.Services.Stop(ctx, members[i])
+		case "restart":
+			err = h.Services.Restart(ctx, members[i])
+		}
+		results[i] = memberResult{ServiceName: members[i], Status: "ok"}
+		if err != nil {
+			results[i].Status = "failed"
+			results[i].Error = err.Error()
+		}
+	}
+
+	for _, batch := range batches {
+		start, end := batch[0], batch[1]
+
+		var wg sync.WaitGroup
+		slots := make(chan struct{}, req.Parallelism)
+		for i := start; i < end; i++ {
+			wg.Add(1)
+			slots <- struct{}{}
+			go func(i int) {
+				defer wg.Done()
+				defer func() { <-slots }()
+				apply(i)
+			}(i)
+		}
+		wg.Wait()
+
+		if !req.Rolling {
+			continue
+		}
+		failed := slices.ContainsFunc(results[start:end], func(res memberResult) bool { return res.Status == "failed" })
+		if failed || ctx.Err() != nil {
+			for i := end; i < len(members); i++ {
+				results[i] = memberResult{ServiceName: members[i], Status: "skipped"}
+			}
+			break
+		}
+
+		if req.Pause > 0 && end < len(members) {
+			select {
+			case <-time.After(time.Duration(req.Pause)):
+			case <-ctx.Done():
+			}
+		}
+	}
+	return results
+}
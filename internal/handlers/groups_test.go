@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+	"github.com/ethan-mdev/service-watch/internal/logger"
+	"github.com/ethan-mdev/service-watch/internal/storage"
+)
+
+// recordingServices is a ServiceManager that logs when each action begins and
+// ends, and fails the services in fail.
+type recordingServices struct {
+	mu       sync.Mutex
+	events   []string
+	inFlight int
+	peak     int
+	fail     map[string]bool
+}
+
+func (f *recordingServices) act(name string) error {
+	f.mu.Lock()
+	f.events = append(f.events, "begin "+name)
+	f.inFlight++
+	f.peak = max(f.peak, f.inFlight)
+	f.mu.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = append(f.events, "end "+name)
+	f.inFlight--
+	if f.fail[name] {
+		return fmt.Errorf("unit %s failed to start", name)
+	}
+	return nil
+}
+
+func (f *recordingServices) List(ctx context.Context) ([]core.Service, error) { return nil, nil }
+func (f *recordingServices) Get(ctx context.Context, name string) (core.Service, error) {
+	return core.Service{Name: name, State: "running"}, nil
+}
+func (f *recordingServices) Start(ctx context.Context, name string) error   { return f.act(name) }
+func (f *recordingServices) Stop(ctx context.Context, name string) error    { return f.act(name) }
+func (f *recordingServices) Restart(ctx context.Context, name string) error { return f.act(name) }
+
+// newTestGroups watches a "stack" group where api and worker depend on db and
+// cache, and web depends on api.
+func newTestGroups(t *testing.T, svc *recordingServices) *GroupsHTTP {
+	t.Helper()
+	dir := t.TempDir()
+	log, err := logger.Start(filepath.Join(dir, "events.jsonl"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { log.Close() })
+
+	wl := storage.NewJSONWatchlist(filepath.Join(dir, "watchlist.json"), svc)
+	ctx := context.Background()
+	for _, name := range []string{"db", "cache", "api", "worker", "web"} {
+		if err := wl.Add(ctx, name, false); err != nil {
+			t.Fatal(err)
+		}
+		if err := wl.SetTags(ctx, name, []string{"stack"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, deps := range map[string][]string{"api": {"db", "cache"}, "worker": {"db"}, "web": {"api"}} {
+		if err := wl.SetDependencies(ctx, name, deps); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewGroupsHTTP(wl, svc, log)
+}
+
+type groupResponse struct {
+	Failed  int            `json:"failed"`
+	Skipped int            `json:"skipped"`
+	Results []memberResult `json:"results"`
+}
+
+func runGroup(t *testing.T, h *GroupsHTTP, path, body string) (int, groupResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.Routes().ServeHTTP(w, httptest.NewRequest("POST", path, strings.NewReader(body)))
+	var resp groupResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	return w.Code, resp
+}
+
+// levelsInOrder reports the first member that began before an earlier level finished.
+func levelsInOrder(events []string, levels [][]string) error {
+	at := make(map[string]int, len(events))
+	for i, event := range events {
+		at[event] = i
+	}
+	for n := 1; n < len(levels); n++ {
+		for _, before := range levels[n-1] {
+			for _, after := range levels[n] {
+				if at["begin "+after] < at["end "+before] {
+					return fmt.Errorf("%s began before %s finished", after, before)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func TestGroupActionOrder(t *testing.T) {
+	levels := [][]string{{"cache", "db"}, {"api", "worker"}, {"web"}}
+	tests := []struct {
+		name     string
+		path     string
+		body     string
+		levels   [][]string
+		wantPeak int
+	}{
+		{name: "restart", path: "/stack/restart", levels: levels, wantPeak: 2},
+		{name: "stop", path: "/stack/stop", levels: [][]string{{"web"}, {"api", "worker"}, {"cache", "db"}}, wantPeak: 2},
+		{name: "parallelism", path: "/stack/start", body: `{"parallelism":1}`, levels: levels, wantPeak: 1},
+		{name: "rolling", path: "/stack/restart", body: `{"rolling":true,"parallelism":2}`, levels: levels, wantPeak: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &recordingServices{}
+			h := newTestGroups(t, svc)
+
+			code, resp := runGroup(t, h, tt.path, tt.body)
+			if code != 200 || resp.Failed != 0 || resp.Skipped != 0 {
+				t.Fatalf("status %d, %+v", code, resp)
+			}
+			var got []string
+			for _, res := range resp.Results {
+				got = append(got, res.ServiceName)
+			}
+			var want []string
+			for _, level := range tt.levels {
+				want = append(want, level...)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("results = %v, want %v", got, want)
+			}
+			if err := levelsInOrder(svc.events, tt.levels); err != nil {
+				t.Error(err)
+			}
+			if svc.peak != tt.wantPeak {
+				t.Errorf("%d members at once, want %d", svc.peak, tt.wantPeak)
+			}
+		})
+	}
+}
+
+func TestGroupActionFailure(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    map[string]string // Status by member
+		skipped int
+	}{
+		{
+			// Without rolling, every member is still tried
+			name: "parallel",
+			want: map[string]string{"cache": "ok", "db": "ok", "api": "failed", "worker": "ok", "web": "ok"},
+		},
+		{
+			name:    "rolling",
+			body:    `{"rolling":true}`,
+			want:    map[string]string{"cache": "ok", "db": "ok", "api": "failed", "worker": "skipped", "web": "skipped"},
+			skipped: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &recordingServices{fail: map[string]bool{"api": true}}
+			h := newTestGroups(t, svc)
+
+			code, resp := runGroup(t, h, "/stack/restart", tt.body)
+			if code != 424 {
+				t.Errorf("status %d, want 424", code)
+			}
+			got := make(map[string]string)
+			for _, res := range resp.Results {
+				got[res.ServiceName] = res.Status
+				if res.Status == "failed" && res.Error != "unit api failed to start" {
+					t.Errorf("error = %q", res.Error)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) || resp.Failed != 1 || resp.Skipped != tt.skipped {
+				t.Errorf("results = %v (failed %d, skipped %d), want %v", got, resp.Failed, resp.Skipped, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupActionRequest(t *testing.T) {
+	h := newTestGroups(t, &recordingServices{})
+	for _, tt := range []struct {
+		path, body string
+		want       int
+	}{
+		{"/stack/restart", `{"parallelism":-1}`, 400},
+		{"/stack/restart", `{`, 400},
+		{"/none/restart", ``, 404},
+	} {
+		w := httptest.NewRecorder()
+		h.Routes().ServeHTTP(w, httptest.NewRequest("POST", tt.path, strings.NewReader(tt.body)))
+		if w.Code != tt.want {
+			t.Errorf("%s %s: status %d, want %d", tt.path, tt.body, w.Code, tt.want)
+		}
+	}
+}
@@ -1,38 +1,48 @@
 package handlers
 
 import (
-	"bufio"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
-	"os"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/ethan-mdev/service-watch/internal/core"
+	"github.com/ethan-mdev/service-watch/internal/filter"
+	"github.com/ethan-mdev/service-watch/internal/logger"
+	"github.com/ethan-mdev/service-watch/internal/tsdb"
 	"github.com/ethan-mdev/service-watch/internal/utils"
 	"github.com/go-chi/chi/v5"
 )
 
 type MetricsHTTP struct {
-	LogPath string
+	LogPath   string
+	Watchlist core.WatchlistManager // Resolves ?tag= to service names
+	Samples   *tsdb.Store
 }
 
-func NewMetricsHTTP(logPath string) *MetricsHTTP {
-	return &MetricsHTTP{LogPath: logPath}
+func NewMetricsHTTP(logPath string, watchlist core.WatchlistManager, samples *tsdb.Store) *MetricsHTTP {
+	return &MetricsHTTP{LogPath: logPath, Watchlist: watchlist, Samples: samples}
 }
 
 func (h *MetricsHTTP) Routes() chi.Router {
 	r := chi.NewRouter()
 	r.Get("/", h.query)
+	r.Get("/samples", h.samples)
+	r.Get("/series", h.series)
+	r.Get("/export", h.export)
 	return r
 }
 
-// query handles filtering and returning log entries
+// query handles filtering and returning log entries, newest first. Entries are
+// found through the logger's index, so only the lines returned are read.
 func (h *MetricsHTTP) query(w http.ResponseWriter, r *http.Request) {
-	// Parse query params
-	eventType := r.URL.Query().Get("event")     // e.g., "watcher_started, host_resources, service_status"
-	serviceName := r.URL.Query().Get("service") // e.g., "Steam Client Service"
-	limitStr := r.URL.Query().Get("limit")      // e.g., "100"
-	sinceStr := r.URL.Query().Get("since")      // e.g., "1h" or RFC3339 timestamp
+	limitStr := r.URL.Query().Get("limit")   // e.g., "100"
+	beforeStr := r.URL.Query().Get("before") // nextCursor of the previous page
 
 	limit := 100 // default
 	if limitStr != "" {
@@ -41,72 +51,401 @@ func (h *MetricsHTTP) query(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Parse "since" parameter
-	var sinceTime time.Time
-	if sinceStr != "" {
-		// Try parsing as duration (e.g., "1h", "30m")
-		if d, err := time.ParseDuration(sinceStr); err == nil {
-			sinceTime = time.Now().Add(-d)
-		} else if t, err := time.Parse(time.RFC3339, sinceStr); err == nil {
-			// Try parsing as timestamp
-			sinceTime = t
+	var before *logger.Cursor
+	if beforeStr != "" {
+		cursor, err := logger.ParseCursor(beforeStr)
+		if err != nil {
+			utils.RespondWithError(w, 400, "invalid before: "+err.Error(), err)
+			return
 		}
+		before = &cursor
 	}
 
-	// Read and filter logs
-	file, err := os.Open(h.LogPath)
+	f, ok := h.parseFilter(w, r)
+	if !ok {
+		return
+	}
+
+	files, err := logger.Files(h.LogPath)
 	if err != nil {
-		utils.RespondWithError(w, 500, "failed to open log file", err)
+		utils.RespondWithError(w, 500, "failed to list log files", err)
 		return
 	}
-	defer file.Close()
 
+	// Walk the live file first, then backups newest first. Backups rotated
+	// before since hold nothing newer, so the walk ends there.
 	var results []map[string]interface{}
-	scanner := bufio.NewScanner(file)
-
-	for scanner.Scan() {
-		var entry map[string]interface{}
-		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
-			continue
+	var next *logger.Cursor
+	for i := len(files) - 1; i >= 0 && len(results) < limit; i-- {
+		logFile := files[i]
+		if !logFile.HasEntriesSince(f.since) {
+			break
+		}
+		index, err := logFile.Index()
+		if err == nil {
+			entries := entriesSince(index.Entries, f.since)
+			// Files newer than the one the cursor points into were read by earlier pages
+			if before != nil {
+				if before.File != index.ID {
+					continue
+				}
+				offset := before.Offset
+				entries = entries[:sort.Search(len(entries), func(i int) bool { return entries[i].Offset >= offset })]
+				before = nil
+			}
+			err = scanEntries(logFile, index.ID, entries, true, f.indexed, func(cursor logger.Cursor, entry map[string]interface{}) bool {
+				if !f.matches(entry) {
+					return true
+				}
+				results = append(results, entry)
+				if len(results) < limit {
+					return true
+				}
+				next = &cursor
+				return false
+			})
+		}
+		// A damaged backup shouldn't hide everything else
+		if err != nil && !logFile.End.IsZero() {
+			log.Printf("Skipping unreadable log backup %s: %v", logFile.Path, err)
+		} else if err != nil {
+			utils.RespondWithError(w, 500, "failed to read log file", err)
+			return
 		}
+	}
 
-		// Filter by event type
-		if eventType != "" && entry["event"] != eventType {
-			continue
+	response := map[string]interface{}{
+		"count": len(results),
+		"items": results,
+	}
+	if next != nil {
+		response["nextCursor"] = next.String()
+	}
+	utils.RespondWithJSON(w, 200, response)
+}
+
+// entryFilter holds the filters shared by the log entry endpoints.
+type entryFilter struct {
+	events   map[string]bool // Each nil when not filtered on
+	services map[string]bool
+	levels   map[string]bool
+	tagged   map[string]bool // Services in any of the ?tag= groups
+	since    time.Time
+	until    time.Time
+	expr     filter.Expr // ?filter= expression
+	search   string      // Lowercased ?q= text
+}
+
+// parseFilter reads the entry filters of a request, writing an error response
+// and returning false when they are invalid. event, service, level and tag take
+// several values, repeated or comma-separated.
+func (h *MetricsHTTP) parseFilter(w http.ResponseWriter, r *http.Request) (*entryFilter, bool) {
+	params := r.URL.Query()
+	f := &entryFilter{
+		events:   paramSet(params["event"]),   // e.g., "watcher_started, host_resources, service_status"
+		services: paramSet(params["service"]), // e.g., "Steam Client Service"
+		levels:   paramSet(params["level"]),   // e.g., "ERROR"
+		search:   strings.ToLower(params.Get("q")),
+	}
+
+	now := time.Now()
+	var err error
+	if f.since, err = parseTimeParam(params.Get("since"), time.Time{}, now); err != nil { // e.g., "1h" or RFC3339 timestamp
+		utils.RespondWithError(w, 400, "invalid since: "+err.Error(), err)
+		return nil, false
+	}
+	if f.until, err = parseTimeParam(params.Get("until"), time.Time{}, now); err != nil {
+		utils.RespondWithError(w, 400, "invalid until: "+err.Error(), err)
+		return nil, false
+	}
+
+	if expr := params.Get("filter"); expr != "" {
+		if f.expr, err = filter.Parse(expr); err != nil {
+			response := map[string]interface{}{"error": "invalid filter: " + err.Error()}
+			var syntaxErr *filter.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				response["position"] = syntaxErr.Pos
+			}
+			utils.RespondWithJSON(w, 400, response)
+			return nil, false
 		}
+	}
 
-		// Filter by service name
-		if serviceName != "" {
-			if data, ok := entry["data"].(map[string]interface{}); ok {
-				if data["serviceName"] != serviceName {
-					continue
+	// Resolve tags to the services in those groups
+	if tags := paramSet(params["tag"]); tags != nil { // e.g., "billing"
+		items, err := h.Watchlist.List(r.Context())
+		if err != nil {
+			utils.RespondWithError(w, 500, "failed to list watchlist", err)
+			return nil, false
+		}
+		f.tagged = make(map[string]bool)
+		for _, item := range items {
+			for tag := range tags {
+				if item.HasTag(tag) {
+					f.tagged[item.ServiceName] = true
 				}
-			} else {
-				continue
 			}
 		}
+	}
+	return f, true
+}
 
-		// Filter by time
-		if !sinceTime.IsZero() {
-			if timeStr, ok := entry["time"].(string); ok {
-				if t, err := time.Parse(time.RFC3339, timeStr); err == nil {
-					if t.Before(sinceTime) {
-						continue
-					}
+// paramSet collects the values of a query parameter, splitting each on commas.
+// It returns nil when there are none.
+func paramSet(values []string) map[string]bool {
+	var set map[string]bool
+	for _, value := range values {
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				if set == nil {
+					set = make(map[string]bool)
 				}
+				set[v] = true
 			}
 		}
+	}
+	return set
+}
+
+// indexed applies the filters that can be checked against the index, before an
+// entry is read. since is left to the scan, which stops at it.
+func (f *entryFilter) indexed(e logger.IndexEntry) bool {
+	if !f.until.IsZero() && e.Time.After(f.until) {
+		return false
+	}
+	if f.events != nil && !f.events[e.Event] {
+		return false
+	}
+	if f.services != nil && !f.services[e.Service] {
+		return false
+	}
+	if f.levels != nil && !f.levels[e.Level] {
+		return false
+	}
+	return f.tagged == nil || f.tagged[e.Service]
+}
 
-		results = append(results, entry)
+// matches applies the filters that need the entry itself.
+func (f *entryFilter) matches(entry map[string]interface{}) bool {
+	if f.expr != nil && !f.expr.Match(entry) {
+		return false
+	}
+	return f.search == "" || containsText(entry["data"], f.search)
+}
 
-		// Limit results
-		if len(results) >= limit {
-			break
+// containsText reports whether any value in data, however deeply nested,
+// contains the lowercased text, ignoring case.
+func containsText(data interface{}, text string) bool {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for _, value := range v {
+			if containsText(value, text) {
+				return true
+			}
 		}
+		return false
+	case []interface{}:
+		for _, value := range v {
+			if containsText(value, text) {
+				return true
+			}
+		}
+		return false
+	case nil:
+		return false
 	}
+	return strings.Contains(strings.ToLower(fmt.Sprint(data)), text)
+}
 
-	utils.RespondWithJSON(w, 200, map[string]interface{}{
-		"count": len(results),
-		"items": results,
+// entriesSince drops the entries of a file older than since.
+func entriesSince(entries []logger.IndexEntry, since time.Time) []logger.IndexEntry {
+	if since.IsZero() {
+		return entries
+	}
+	return entries[sort.Search(len(entries), func(i int) bool { return !entries[i].Time.Before(since) }):]
+}
+
+// scanEntries calls fn with the entries of a log file that match the index, in
+// the order written or newest first, until fn returns false.
+func scanEntries(logFile logger.LogFile, fileID int64, entries []logger.IndexEntry, newestFirst bool, match func(logger.IndexEntry) bool, fn func(cursor logger.Cursor, entry map[string]interface{}) bool) error {
+	var err error
+	var reader *logger.EntryReader
+	defer func() {
+		if reader != nil {
+			reader.Close()
+		}
+	}()
+	for n := range entries {
+		e := entries[n]
+		if newestFirst {
+			e = entries[len(entries)-1-n]
+		}
+		if !match(e) {
+			continue
+		}
+		if reader == nil {
+			if reader, err = logFile.OpenEntries(); err != nil {
+				return err
+			}
+		}
+		line, err := reader.Read(e)
+		if err != nil {
+			return err
+		}
+		var entry map[string]interface{}
+		if err := json.Unmarshal(line, &entry); err != nil {
+			continue
+		}
+		if !fn(logger.Cursor{File: fileID, Offset: e.Offset}, entry) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// samples returns stored points of one field of a service (or the host when
+// ?service= is empty) from the time-series store.
+func (h *MetricsHTTP) samples(w http.ResponseWriter, r *http.Request) {
+	series := r.URL.Query().Get("service")
+	if series == "" {
+		series = tsdb.HostSeries
+	}
+	field := r.URL.Query().Get("field")
+	if field == "" {
+		utils.RespondWithError(w, 400, "field is required", nil)
+		return
+	}
+
+	now := time.Now()
+	from, err := parseTimeParam(r.URL.Query().Get("from"), now.Add(-time.Hour), now)
+	if err != nil {
+		utils.RespondWithError(w, 400, "invalid from: "+err.Error(), err)
+		return
+	}
+	to, err := parseTimeParam(r.URL.Query().Get("to"), now, now)
+	if err != nil {
+		utils.RespondWithError(w, 400, "invalid to: "+err.Error(), err)
+		return
+	}
+
+	var tier tsdb.Tier
+	if name := r.URL.Query().Get("tier"); name != "" {
+		var ok bool
+		if tier, ok = tsdb.TierByName(name); !ok {
+			utils.RespondWithError(w, 400, "tier must be one of raw, 1m, 1h", nil)
+			return
+		}
+	} else {
+		// Without a tier, aim for a few hundred points across the range
+		tier = tsdb.PickTier(from, to.Sub(from)/300, now)
+	}
+
+	points, err := h.Samples.QueryTier(tier, series, field, from, to)
+	if err != nil {
+		utils.RespondWithError(w, 500, "failed to read samples", err)
+		return
+	}
+	if points == nil {
+		points = []tsdb.Point{}
+	}
+	utils.RespondWithJSON(w, 200, map[string]any{
+		"series": series,
+		"field":  field,
+		"tier":   tier.Name,
+		"count":  len(points),
+		"items":  points,
 	})
 }
+
+// maxBuckets bounds how many buckets /series returns, so a tiny step over a long range is rejected.
+const maxBuckets = 5000
+
+// series returns one field of a service (or the host when ?service= is empty)
+// bucketed by ?step= and reduced with ?agg=, plus summary statistics over the range.
+func (h *MetricsHTTP) series(w http.ResponseWriter, r *http.Request) {
+	series := r.URL.Query().Get("service")
+	if series == "" {
+		series = tsdb.HostSeries
+	}
+	field := r.URL.Query().Get("field")
+	if field == "" {
+		utils.RespondWithError(w, 400, "field is required", nil)
+		return
+	}
+	agg := r.URL.Query().Get("agg")
+	if agg == "" {
+		agg = "avg"
+	}
+
+	now := time.Now()
+	from, err := parseTimeParam(r.URL.Query().Get("from"), now.Add(-time.Hour), now)
+	if err != nil {
+		utils.RespondWithError(w, 400, "invalid from: "+err.Error(), err)
+		return
+	}
+	to, err := parseTimeParam(r.URL.Query().Get("to"), now, now)
+	if err != nil {
+		utils.RespondWithError(w, 400, "invalid to: "+err.Error(), err)
+		return
+	}
+	if !to.After(from) {
+		utils.RespondWithError(w, 400, "to must be after from", nil)
+		return
+	}
+
+	// Without a step, aim for a few hundred buckets across the range
+	step := (to.Sub(from) / 300).Round(time.Second)
+	if stepStr := r.URL.Query().Get("step"); stepStr != "" {
+		if step, err = time.ParseDuration(stepStr); err != nil {
+			utils.RespondWithError(w, 400, "invalid step", err)
+			return
+		}
+	}
+	if step < time.Second {
+		step = time.Second
+	}
+	if to.Sub(from)/step > maxBuckets {
+		utils.RespondWithError(w, 400, fmt.Sprintf("step too small: over %d buckets", maxBuckets), nil)
+		return
+	}
+
+	// Percentiles of downsampled points are only approximate, so read the finest tier still holding from
+	tierStep := step
+	if agg == "p95" {
+		tierStep = 0
+	}
+	tier, points, err := h.Samples.Query(series, field, from, to, tierStep)
+	if err != nil {
+		utils.RespondWithError(w, 500, "failed to read samples", err)
+		return
+	}
+	buckets, err := tsdb.Aggregate(points, step, agg)
+	if err != nil {
+		utils.RespondWithError(w, 400, err.Error(), err)
+		return
+	}
+
+	utils.RespondWithJSON(w, 200, map[string]any{
+		"series":  series,
+		"field":   field,
+		"agg":     agg,
+		"step":    step.String(),
+		"tier":    tier.Name,
+		"from":    from,
+		"to":      to,
+		"items":   buckets,
+		"summary": tsdb.Summarize(points),
+	})
+}
+
+// parseTimeParam parses a query parameter given as a duration before now (e.g. "1h")
+// or an RFC3339 timestamp. An empty value gives def.
+func parseTimeParam(value string, def, now time.Time) (time.Time, error) {
+	if value == "" {
+		return def, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethan-mdev/service-watch/internal/tsdb"
+)
+
+func TestSeries(t *testing.T) {
+	store, err := tsdb.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	// Two samples a minute for ten minutes, ending before the current five minutes
+	start := time.Now().Truncate(5 * time.Minute).Add(-10 * time.Minute)
+	for n := 0; n < 20; n++ {
+		if err := store.Append("web", "cpuPercent", start.Add(time.Duration(n)*30*time.Second), float64(n)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := NewMetricsHTTP("", nil, store)
+
+	type response struct {
+		Tier    string        `json:"tier"`
+		Step    string        `json:"step"`
+		Items   []tsdb.Bucket `json:"items"`
+		Summary tsdb.Summary  `json:"summary"`
+	}
+	tests := []struct {
+		name     string
+		query    string
+		wantTier string
+		wantStep string
+		buckets  int
+		first    tsdb.Bucket // Ignoring Time, which is checked for alignment
+		count    int         // Summary count
+	}{
+		{
+			name:     "minute buckets",
+			query:    "service=web&field=cpuPercent&from=15m&step=1m&agg=max",
+			wantTier: "1m",
+			wantStep: "1m0s",
+			buckets:  10,
+			first:    tsdb.Bucket{Value: 1, Count: 2},
+			count:    20,
+		},
+		{
+			name:     "wider buckets",
+			query:    "service=web&field=cpuPercent&from=15m&step=5m",
+			wantTier: "1m",
+			wantStep: "5m0s",
+			buckets:  2,
+			first:    tsdb.Bucket{Value: 4.5, Count: 10},
+			count:    20,
+		},
+		{
+			// Percentiles read raw samples rather than averages
+			name:     "p95",
+			query:    "service=web&field=cpuPercent&from=15m&step=5m&agg=p95",
+			wantTier: "raw",
+			wantStep: "5m0s",
+			buckets:  2,
+			first:    tsdb.Bucket{Value: 9, Count: 10},
+			count:    20,
+		},
+		{
+			name:     "default step",
+			query:    "service=web&field=cpuPercent&from=15m",
+			wantTier: "raw",
+			wantStep: "3s",
+			buckets:  20,
+			first:    tsdb.Bucket{Value: 0, Count: 1},
+			count:    20,
+		},
+		{
+			name:     "empty range",
+			query:    "service=web&field=cpuPercent&from=2h&to=1h&step=1m",
+			wantTier: "1m",
+			wantStep: "1m0s",
+		},
+		{
+			name:     "unknown field",
+			query:    "service=web&field=memoryMB&step=1m",
+			wantTier: "1m",
+			wantStep: "1m0s",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.Routes().ServeHTTP(w, httptest.NewRequest("GET", "/series?"+tt.query, nil))
+			if w.Code != 200 {
+				t.Fatalf("status %d: %s", w.Code, w.Body)
+			}
+			var resp response
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Tier != tt.wantTier || resp.Step != tt.wantStep {
+				t.Errorf("tier %s, step %s, want %s, %s", resp.Tier, resp.Step, tt.wantTier, tt.wantStep)
+			}
+			if resp.Items == nil || len(resp.Items) != tt.buckets {
+				t.Fatalf("got %d buckets, want %d", len(resp.Items), tt.buckets)
+			}
+			if resp.Summary.Count != tt.count {
+				t.Errorf("summary count = %d, want %d", resp.Summary.Count, tt.count)
+			}
+			if tt.buckets == 0 {
+				return
+			}
+			step, _ := time.ParseDuration(resp.Step)
+			for _, bucket := range resp.Items {
+				if !bucket.Time.Equal(bucket.Time.Truncate(step)) {
+					t.Errorf("bucket at %v isn't aligned to %s", bucket.Time, step)
+				}
+			}
+			first := resp.Items[0]
+			first.Time = time.Time{}
+			if first != tt.first {
+				t.Errorf("first bucket = %+v, want %+v", first, tt.first)
+			}
+		})
+	}
+}
+
+func TestSeriesRejects(t *testing.T) {
+	store, err := tsdb.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	h := NewMetricsHTTP("", nil, store)
+
+	for _, query := range []string{
+		"service=web",                        // No field
+		"field=cpuPercent&from=1h&to=2h",     // to before from
+		"field=cpuPercent&from=720h&step=1s", // Too many buckets
+		"field=cpuPercent&step=soon",         // Bad step
+		"field=cpuPercent&agg=median",        // Bad agg
+		"field=cpuPercent&from=yesterday",    // Bad from
+	} {
+		w := httptest.NewRecorder()
+		h.Routes().ServeHTTP(w, httptest.NewRequest("GET", "/series?"+query, nil))
+		if w.Code != 400 {
+			t.Errorf("%s: status %d, want 400", query, w.Code)
+		}
+	}
+}
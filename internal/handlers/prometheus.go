@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+)
+
+type PrometheusHTTP struct {
+	Stats  core.StatsProvider
+	Events *EventsHTTP
+}
+
+func NewPrometheusHTTP(stats core.StatsProvider, events *EventsHTTP) *PrometheusHTTP {
+	return &PrometheusHTTP{Stats: stats, Events: events}
+}
+
+// Metrics serves the monitor's latest samples in the Prometheus text format.
+// Nothing is measured at scrape time; values are as of the last watcher check.
+func (h *PrometheusHTTP) Metrics(w http.ResponseWriter, r *http.Request) {
+	stats := h.Stats.Stats()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	out := bufio.NewWriter(w)
+	defer out.Flush()
+	p := promWriter{out}
+
+	items := stats.Items
+	p.family("service_watch_service_up", "gauge", "Whether the watched service is running (1) or not (0).")
+	for _, item := range items {
+		up := 0.0
+		if item.Service != nil && item.Service.State == "running" {
+			up = 1
+		}
+		p.sample("service_watch_service_up", up, "service", item.ServiceName)
+	}
+	p.family("service_watch_service_cpu_percent", "gauge", "CPU usage of the watched service.")
+	for _, item := range items {
+		if item.Service != nil {
+			p.sample("service_watch_service_cpu_percent", item.Service.CPUPercent, "service", item.ServiceName)
+		}
+	}
+	p.family("service_watch_service_memory_bytes", "gauge", "Memory used by the watched service.")
+	for _, item := range items {
+		if item.Service != nil {
+			p.sample("service_watch_service_memory_bytes", item.Service.MemoryMB*1024*1024, "service", item.ServiceName)
+		}
+	}
+	p.family("service_watch_service_uptime_seconds", "gauge", "How long the watched service has been running.")
+	for _, item := range items {
+		if item.Service != nil {
+			p.sample("service_watch_service_uptime_seconds", float64(item.Service.UptimeSeconds), "service", item.ServiceName)
+		}
+	}
+	p.family("service_watch_service_pid", "gauge", "Process ID of the watched service, 0 when it isn't running.")
+	for _, item := range items {
+		if item.Service != nil {
+			p.sample("service_watch_service_pid", float64(item.Service.PID), "service", item.ServiceName)
+		}
+	}
+	p.family("service_watch_service_restarts_total", "counter", "Restarts of the watched service by service-watch.")
+	for _, item := range items {
+		p.sample("service_watch_service_restarts_total", float64(item.RestartCount), "service", item.ServiceName)
+	}
+	// FailCount goes back to 0 once a service stays up, so it's a gauge
+	p.family("service_watch_service_failures", "gauge", "Restart attempts since the watched service was last healthy.")
+	for _, item := range items {
+		p.sample("service_watch_service_failures", float64(item.FailCount), "service", item.ServiceName)
+	}
+
+	host := stats.Host
+	p.family("service_watch_host_cpu_percent", "gauge", "Host CPU usage.")
+	p.sample("service_watch_host_cpu_percent", host.CPUPercent)
+	p.family("service_watch_host_memory_total_bytes", "gauge", "Host memory.")
+	p.sample("service_watch_host_memory_total_bytes", float64(host.TotalMB*1024*1024))
+	p.family("service_watch_host_memory_used_bytes", "gauge", "Host memory in use.")
+	p.sample("service_watch_host_memory_used_bytes", float64(host.UsedMB*1024*1024))
+	p.family("service_watch_host_memory_used_percent", "gauge", "Host memory in use as a percentage.")
+	p.sample("service_watch_host_memory_used_percent", host.UsedPercent)
+
+	p.family("service_watch_sse_clients", "gauge", "Connected /v1/events clients.")
+	p.sample("service_watch_sse_clients", float64(h.Events.Clients()))
+	p.family("service_watch_watcher_loop_duration_seconds", "summary", "Time taken by watcher checks of the whole watchlist.")
+	p.sample("service_watch_watcher_loop_duration_seconds_sum", stats.CheckTime.Seconds())
+	p.sample("service_watch_watcher_loop_duration_seconds_count", float64(stats.Checks))
+	p.family("service_watch_watcher_last_loop_duration_seconds", "gauge", "Time taken by the last watcher check.")
+	p.sample("service_watch_watcher_last_loop_duration_seconds", stats.LastDuration.Seconds())
+	if !stats.LastCheck.IsZero() {
+		p.family("service_watch_watcher_last_loop_timestamp_seconds", "gauge", "When the last watcher check started.")
+		p.sample("service_watch_watcher_last_loop_timestamp_seconds", float64(stats.LastCheck.UnixNano())/1e9)
+	}
+}
+
+// promWriter writes the Prometheus text exposition format.
+type promWriter struct {
+	w *bufio.Writer
+}
+
+func (p promWriter) family(name, kind, help string) {
+	fmt.Fprintf(p.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// sample writes one value; labels are name, value pairs.
+func (p promWriter) sample(name string, value float64, labels ...string) {
+	p.w.WriteString(name)
+	if len(labels) > 0 {
+		p.w.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				p.w.WriteByte(',')
+			}
+			fmt.Fprintf(p.w, "%s=\"%s\"", labels[i], labelEscaper.Replace(labels[i+1]))
+		}
+		p.w.WriteByte('}')
+	}
+	p.w.WriteByte(' ')
+	p.w.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	p.w.WriteByte('\n')
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
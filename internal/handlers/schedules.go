@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+	"github.com/ethan-mdev/service-watch/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+type SchedulesHTTP struct {
+	M        core.ScheduleManager
+	Services core.ServiceManager
+	Status   core.ScheduleStatusProvider
+}
+
+func NewSchedulesHTTP(m core.ScheduleManager, services core.ServiceManager, status core.ScheduleStatusProvider) *SchedulesHTTP {
+	return &SchedulesHTTP{M: m, Services: services, Status: status}
+}
+
+// Routes sets up the HTTP routes for scheduled actions.
+func (h *SchedulesHTTP) Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Get("/", h.list)
+	r.Post("/", h.add)
+	r.Route("/{id}", func(r chi.Router) {
+		r.Get("/", h.get)
+		r.Delete("/", h.remove)
+	})
+	return r
+}
+
+// list returns all schedules, optionally only those targeting ?service= or ?tag=.
+func (h *SchedulesHTTP) list(w http.ResponseWriter, r *http.Request) {
+	schedules, err := h.M.List(r.Context())
+	if err != nil {
+		utils.RespondWithError(w, 500, "failed to list schedules", err)
+		return
+	}
+	service := r.URL.Query().Get("service")
+	tag := r.URL.Query().Get("tag")
+
+	filtered := make([]core.Schedule, 0, len(schedules))
+	for _, schedule := range schedules {
+		if service != "" && schedule.ServiceName != service {
+			continue
+		}
+		if tag != "" && schedule.Tag != tag {
+			continue
+		}
+		h.withStatus(&schedule)
+		filtered = append(filtered, schedule)
+	}
+	utils.RespondWithJSON(w, 200, map[string]any{"items": filtered})
+}
+
+func (h *SchedulesHTTP) get(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	schedule, err := h.M.Get(r.Context(), id)
+	if err != nil {
+		utils.RespondWithError(w, 404, "schedule not found", err)
+		return
+	}
+	h.withStatus(&schedule)
+	utils.RespondWithJSON(w, 200, schedule)
+}
+
+func (h *SchedulesHTTP) add(w http.ResponseWriter, r *http.Request) {
+	var schedule core.Schedule
+	if err := json.NewDecoder(r.Body).Decode(&schedule); err != nil {
+		utils.RespondWithError(w, 400, "invalid request body", err)
+		return
+	}
+	schedule.ID = ""
+
+	if err := schedule.Validate(); err != nil {
+		utils.RespondWithError(w, 400, "invalid schedule: "+err.Error(), err)
+		return
+	}
+	if schedule.ServiceName != "" {
+		if _, err := h.Services.Get(r.Context(), schedule.ServiceName); err != nil {
+			utils.RespondWithError(w, 400, "service not found", err)
+			return
+		}
+	}
+
+	schedule, err := h.M.Add(r.Context(), schedule)
+	if err != nil {
+		utils.RespondWithError(w, 400, "failed to add schedule", err)
+		return
+	}
+	utils.RespondWithJSON(w, 201, schedule)
+}
+
+func (h *SchedulesHTTP) remove(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if err := h.M.Remove(r.Context(), id); err != nil {
+		utils.RespondWithError(w, 404, "failed to remove schedule", err)
+		return
+	}
+	utils.RespondWithJSON(w, 200, map[string]any{"removed": true})
+}
+
+// withStatus attaches the scheduler's runtime status to a schedule.
+func (h *SchedulesHTTP) withStatus(schedule *core.Schedule) {
+	if h.Status == nil {
+		return
+	}
+	if status, ok := h.Status.ScheduleStatus(schedule.ID); ok {
+		schedule.Status = &status
+	}
+}
@@ -2,7 +2,10 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/ethan-mdev/service-watch/internal/core"
 	"github.com/ethan-mdev/service-watch/internal/utils"
@@ -10,11 +13,15 @@ import (
 )
 
 type WatchlistHTTP struct {
-	M core.WatchlistManager
+	M      core.WatchlistManager
+	Status core.StatusProvider
+	// AllowExec accepts exec health checks and hooks, which run commands as the
+	// daemon's user. Off unless the daemon is started with -allow-exec.
+	AllowExec bool
 }
 
-func NewWatchlistHTTP(m core.WatchlistManager) *WatchlistHTTP {
-	return &WatchlistHTTP{M: m}
+func NewWatchlistHTTP(m core.WatchlistManager, status core.StatusProvider, allowExec bool) *WatchlistHTTP {
+	return &WatchlistHTTP{M: m, Status: status, AllowExec: allowExec}
 }
 
 // Routes sets up the HTTP routes for watchlist management.
@@ -22,21 +29,62 @@ func (h *WatchlistHTTP) Routes() chi.Router {
 	r := chi.NewRouter()
 	r.Get("/", h.list)
 	r.Post("/", h.add)
+	r.Get("/graph", h.graph)
 	r.Route("/{name}", func(r chi.Router) {
 		r.Get("/", h.get)
 		r.Put("/", h.update)
 		r.Delete("/", h.remove)
+		r.Post("/release", h.release)
+		r.Post("/maintenance", h.addMaintenance)
+		r.Delete("/maintenance/{id}", h.removeMaintenance)
+		r.Get("/restarts", h.restarts)
 	})
 	return r
 }
 
+// execRefused explains why an update can't be applied when it would have the
+// daemon run commands without -allow-exec, or returns "".
+func (h *WatchlistHTTP) execRefused(req map[string]json.RawMessage) string {
+	if h.AllowExec {
+		return ""
+	}
+	var checks []core.HealthCheck
+	json.Unmarshal(req["healthChecks"], &checks)
+	for _, check := range checks {
+		if check.Type == "exec" {
+			return "exec health checks are disabled; start service-watch with -allow-exec to enable them"
+		}
+	}
+	var hooks core.RestartHooks
+	json.Unmarshal(req["hooks"], &hooks)
+	for _, stage := range [][]core.Hook{hooks.PreRestart, hooks.PostSuccess, hooks.PostFailure} {
+		for _, hook := range stage {
+			if hook.Type == "exec" {
+				return "exec hooks are disabled; start service-watch with -allow-exec to enable them"
+			}
+		}
+	}
+	return ""
+}
+
+// list returns the watchlist, optionally only the items tagged ?tag=.
 func (h *WatchlistHTTP) list(w http.ResponseWriter, r *http.Request) {
 	items, err := h.M.List(r.Context())
 	if err != nil {
 		utils.RespondWithError(w, 500, "failed to list watchlist", err)
 		return
 	}
-	utils.RespondWithJSON(w, 200, map[string]any{"items": items})
+	tag := r.URL.Query().Get("tag")
+
+	filtered := make([]core.WatchlistItem, 0, len(items))
+	for _, item := range items {
+		if tag != "" && !item.HasTag(tag) {
+			continue
+		}
+		h.withStatus(&item)
+		filtered = append(filtered, item)
+	}
+	utils.RespondWithJSON(w, 200, map[string]any{"items": filtered})
 }
 
 func (h *WatchlistHTTP) get(w http.ResponseWriter, r *http.Request) {
@@ -46,13 +94,17 @@ func (h *WatchlistHTTP) get(w http.ResponseWriter, r *http.Request) {
 		utils.RespondWithError(w, 404, "watchlist item not found", err)
 		return
 	}
+	h.withStatus(&item)
 	utils.RespondWithJSON(w, 200, item)
 }
 
 func (h *WatchlistHTTP) add(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		ServiceName string `json:"serviceName"`
-		AutoRestart bool   `json:"autoRestart"`
+		ServiceName   string              `json:"serviceName"`
+		AutoRestart   bool                `json:"autoRestart"`
+		RestartPolicy *core.RestartPolicy `json:"restartPolicy"`
+		DependsOn     []string            `json:"dependsOn"`
+		Tags          []string            `json:"tags"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		utils.RespondWithError(w, 400, "invalid request body", err)
@@ -64,27 +116,104 @@ func (h *WatchlistHTTP) add(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.RestartPolicy != nil {
+		if err := req.RestartPolicy.Validate(); err != nil {
+			utils.RespondWithError(w, 400, "invalid restartPolicy: "+err.Error(), err)
+			return
+		}
+	}
+	if err := core.ValidateTags(req.Tags); err != nil {
+		utils.RespondWithError(w, 400, "invalid tags: "+err.Error(), err)
+		return
+	}
+
 	if err := h.M.Add(r.Context(), req.ServiceName, req.AutoRestart); err != nil {
 		utils.RespondWithError(w, 400, "failed to add to watchlist", err)
 		return
 	}
+	if req.RestartPolicy != nil {
+		if err := h.M.SetRestartPolicy(r.Context(), req.ServiceName, req.RestartPolicy); err != nil {
+			utils.RespondWithError(w, 500, "failed to set restart policy", err)
+			return
+		}
+	}
+	if len(req.Tags) > 0 {
+		if err := h.M.SetTags(r.Context(), req.ServiceName, req.Tags); err != nil {
+			utils.RespondWithError(w, 500, "failed to set tags", err)
+			return
+		}
+	}
+	if len(req.DependsOn) > 0 {
+		if err := h.M.SetDependencies(r.Context(), req.ServiceName, req.DependsOn); err != nil {
+			// Don't leave a half-configured item behind
+			h.M.Remove(r.Context(), req.ServiceName)
+			utils.RespondWithError(w, 400, "invalid dependsOn: "+err.Error(), err)
+			return
+		}
+	}
 	utils.RespondWithJSON(w, 201, map[string]any{"added": true})
 }
 
+// update applies only the fields present in the body, so clients can change
+// one setting without resending the rest. A null restartPolicy restores the default
+// and null hooks removes them.
 func (h *WatchlistHTTP) update(w http.ResponseWriter, r *http.Request) {
 	name := chi.URLParam(r, "name")
-	var req struct {
-		AutoRestart bool `json:"autoRestart"`
-	}
+	var req map[string]json.RawMessage
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		utils.RespondWithError(w, 400, "invalid request body", err)
 		return
 	}
+	if msg := h.execRefused(req); msg != "" {
+		utils.RespondWithError(w, 403, msg, nil)
+		return
+	}
 
-	if err := h.M.Update(r.Context(), name, req.AutoRestart); err != nil {
-		utils.RespondWithError(w, 400, "failed to update watchlist item", err)
+	// Every field is decoded before the store checks and applies them together,
+	// so a rejected request changes nothing
+	var update core.WatchlistUpdate
+	var autoRestart, restartDependents bool
+	var policy *core.RestartPolicy
+	var hooks *core.RestartHooks
+	var checks []core.HealthCheck
+	var rules []core.ThresholdRule
+	var tags, dependsOn []string
+	fields := []struct {
+		name string
+		dest any
+		set  func()
+	}{
+		{"autoRestart", &autoRestart, func() { update.AutoRestart = &autoRestart }},
+		{"restartPolicy", &policy, func() { update.RestartPolicy = &policy }},
+		{"hooks", &hooks, func() { update.Hooks = &hooks }},
+		{"healthChecks", &checks, func() { update.HealthChecks = &checks }},
+		{"thresholds", &rules, func() { update.Thresholds = &rules }},
+		{"tags", &tags, func() { update.Tags = &tags }},
+		{"dependsOn", &dependsOn, func() { update.DependsOn = &dependsOn }},
+		{"restartDependents", &restartDependents, func() { update.RestartDependents = &restartDependents }},
+	}
+	for _, field := range fields {
+		raw, ok := req[field.name]
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal(raw, field.dest); err != nil {
+			utils.RespondWithError(w, 400, "invalid "+field.name, err)
+			return
+		}
+		field.set()
+	}
+
+	err := h.M.UpdateItem(r.Context(), name, update)
+	if errors.Is(err, core.ErrNotInWatchlist) {
+		utils.RespondWithError(w, 404, "watchlist item not found", err)
 		return
 	}
+	if err != nil {
+		utils.RespondWithError(w, 400, err.Error(), err)
+		return
+	}
+
 	utils.RespondWithJSON(w, 200, map[string]any{"updated": true})
 }
 
@@ -96,3 +225,159 @@ func (h *WatchlistHTTP) remove(w http.ResponseWriter, r *http.Request) {
 	}
 	utils.RespondWithJSON(w, 200, map[string]any{"removed": true})
 }
+
+// graph describes the dependencies between watchlist items, along with
+// the order the monitor starts them in.
+func (h *WatchlistHTTP) graph(w http.ResponseWriter, r *http.Request) {
+	items, err := h.M.List(r.Context())
+	if err != nil {
+		utils.RespondWithError(w, 500, "failed to list watchlist", err)
+		return
+	}
+	order, err := core.DependencyOrder(items)
+	if err != nil {
+		utils.RespondWithError(w, 500, "invalid dependency graph", err)
+		return
+	}
+
+	type node struct {
+		Name              string   `json:"name"`
+		State             string   `json:"state,omitempty"`
+		DependsOn         []string `json:"dependsOn"`
+		Dependents        []string `json:"dependents"`
+		RestartDependents bool     `json:"restartDependents"`
+		WaitingOn         []string `json:"waitingOn,omitempty"`
+	}
+	type edge struct {
+		From string `json:"from"` // Dependent
+		To   string `json:"to"`   // Dependency
+	}
+
+	byName := make(map[string]core.WatchlistItem, len(items))
+	for _, item := range items {
+		h.withStatus(&item)
+		byName[item.ServiceName] = item
+	}
+
+	nodes := make([]node, 0, len(items))
+	edges := []edge{}
+	for _, name := range order {
+		item := byName[name]
+		n := node{
+			Name:              name,
+			DependsOn:         append([]string{}, item.DependsOn...),
+			Dependents:        append([]string{}, core.Dependents(items, name)...),
+			RestartDependents: item.RestartDependents,
+		}
+		if item.Service != nil {
+			n.State = item.Service.State
+		}
+		if item.Status != nil {
+			n.WaitingOn = item.Status.WaitingOn
+		}
+		nodes = append(nodes, n)
+		for _, dep := range item.DependsOn {
+			edges = append(edges, edge{From: name, To: dep})
+		}
+	}
+
+	utils.RespondWithJSON(w, 200, map[string]any{
+		"nodes": nodes,
+		"edges": edges,
+		"order": order,
+	})
+}
+
+// release lifts a flapping quarantine so auto-restart resumes.
+func (h *WatchlistHTTP) release(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	item, err := h.M.Get(r.Context(), name)
+	if err != nil {
+		utils.RespondWithError(w, 404, "watchlist item not found", err)
+		return
+	}
+	if item.Quarantine == nil {
+		utils.RespondWithError(w, 409, "watchlist item is not quarantined", nil)
+		return
+	}
+	if err := h.M.SetQuarantine(r.Context(), name, nil); err != nil {
+		utils.RespondWithError(w, 500, "failed to release watchlist item", err)
+		return
+	}
+	utils.RespondWithJSON(w, 200, map[string]any{"released": true})
+}
+
+// addMaintenance opens a maintenance window. A body with only a duration
+// starts an ad-hoc window now; cron plus duration makes it recurring.
+func (h *WatchlistHTTP) addMaintenance(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	var window core.MaintenanceWindow
+	if err := json.NewDecoder(r.Body).Decode(&window); err != nil {
+		utils.RespondWithError(w, 400, "invalid request body", err)
+		return
+	}
+	window.ID = ""
+
+	if window.Cron == "" && window.Start == "" {
+		if window.Duration <= 0 {
+			utils.RespondWithError(w, 400, "duration, start/end or cron is required", nil)
+			return
+		}
+		now := time.Now()
+		window.Start = now.Format(time.RFC3339)
+		window.End = now.Add(time.Duration(window.Duration)).Format(time.RFC3339)
+		window.Duration = 0
+	}
+
+	window, err := h.M.AddMaintenanceWindow(r.Context(), name, window)
+	if err != nil {
+		utils.RespondWithError(w, 400, "failed to add maintenance window", err)
+		return
+	}
+	utils.RespondWithJSON(w, 201, window)
+}
+
+func (h *WatchlistHTTP) removeMaintenance(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	id := chi.URLParam(r, "id")
+	if err := h.M.RemoveMaintenanceWindow(r.Context(), name, id); err != nil {
+		utils.RespondWithError(w, 404, "failed to remove maintenance window", err)
+		return
+	}
+	utils.RespondWithJSON(w, 200, map[string]any{"removed": true})
+}
+
+// restarts returns the item's restart history, newest first, when the store keeps one.
+func (h *WatchlistHTTP) restarts(w http.ResponseWriter, r *http.Request) {
+	history, ok := h.M.(core.RestartHistory)
+	if !ok {
+		utils.RespondWithError(w, 501, "restart history needs the sqlite watchlist store", nil)
+		return
+	}
+	limit := 100
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+		limit = l
+	}
+
+	name := chi.URLParam(r, "name")
+	records, err := history.Restarts(r.Context(), name, limit)
+	if errors.Is(err, core.ErrNotInWatchlist) {
+		utils.RespondWithError(w, 404, "watchlist item not found", err)
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, 500, "failed to read restart history", err)
+		return
+	}
+	utils.RespondWithJSON(w, 200, map[string]any{"count": len(records), "items": records})
+}
+
+// withStatus attaches the monitor's runtime status to an item.
+func (h *WatchlistHTTP) withStatus(item *core.WatchlistItem) {
+	if h.Status == nil {
+		return
+	}
+	if status, ok := h.Status.Status(item.ServiceName); ok {
+		item.Status = &status
+	}
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ethan-mdev/service-watch/internal/notify"
+	"github.com/ethan-mdev/service-watch/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+type WebhooksHTTP struct {
+	Notifier *notify.Notifier
+}
+
+func NewWebhooksHTTP(notifier *notify.Notifier) *WebhooksHTTP {
+	return &WebhooksHTTP{Notifier: notifier}
+}
+
+// Routes sets up the HTTP routes for webhook notifications.
+func (h *WebhooksHTTP) Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Get("/", h.list)
+	r.Get("/deliveries", h.deliveries)
+	r.Post("/{name}/test", h.test)
+	return r
+}
+
+func (h *WebhooksHTTP) list(w http.ResponseWriter, r *http.Request) {
+	utils.RespondWithJSON(w, 200, map[string]any{"items": h.Notifier.Webhooks()})
+}
+
+// deliveries returns recent deliveries, newest first, filtered by ?webhook=, ?event= and ?status=.
+func (h *WebhooksHTTP) deliveries(w http.ResponseWriter, r *http.Request) {
+	webhook := r.URL.Query().Get("webhook")
+	event := r.URL.Query().Get("event")
+	status := r.URL.Query().Get("status")
+	limit := 100
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+		limit = l
+	}
+
+	results := []notify.Delivery{}
+	for _, delivery := range h.Notifier.Deliveries() {
+		if webhook != "" && delivery.Webhook != webhook {
+			continue
+		}
+		if event != "" && delivery.Event != event {
+			continue
+		}
+		if status != "" && delivery.Status != status {
+			continue
+		}
+		results = append(results, delivery)
+		if len(results) >= limit {
+			break
+		}
+	}
+	utils.RespondWithJSON(w, 200, map[string]any{"count": len(results), "items": results})
+}
+
+// test sends a webhook_test event and reports how the delivery went.
+func (h *WebhooksHTTP) test(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	delivery, err := h.Notifier.Test(r.Context(), name)
+	if err != nil {
+		utils.RespondWithError(w, 404, "webhook not found", err)
+		return
+	}
+	code := 200
+	if delivery.Status != "delivered" {
+		code = 424
+	}
+	utils.RespondWithJSON(w, code, delivery)
+}
@@ -0,0 +1,97 @@
+package logger
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// backupTimeFormat is how lumberjack stamps rotated files, e.g. events-2025-11-04T12-34-56.789.jsonl.gz.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// LogFile is the live event log or one of its rotated backups.
+type LogFile struct {
+	Path       string
+	Compressed bool
+	End        time.Time // When the file was rotated; zero for the live file. Entries are no newer.
+}
+
+// HasEntriesSince reports whether the file can hold entries at or after since.
+func (f LogFile) HasEntriesSince(since time.Time) bool {
+	return since.IsZero() || f.End.IsZero() || !f.End.Before(since)
+}
+
+// Open opens the file for reading, decompressing backups.
+func (f LogFile) Open() (io.ReadCloser, error) {
+	file, err := os.Open(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	if !f.Compressed {
+		return file, nil
+	}
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return gzipFile{gz, file}, nil
+}
+
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (g gzipFile) Close() error {
+	g.Reader.Close()
+	return g.file.Close()
+}
+
+// Files lists the rotated backups of logPath oldest first, followed by logPath
+// itself when it exists.
+func Files(logPath string) ([]LogFile, error) {
+	dir := filepath.Dir(logPath)
+	ext := filepath.Ext(logPath)
+	prefix := strings.TrimSuffix(filepath.Base(logPath), ext) + "-"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	byTime := make(map[time.Time]LogFile)
+	for _, entry := range entries {
+		name := entry.Name()
+		compressed := strings.HasSuffix(name, ext+".gz")
+		stamp := strings.TrimPrefix(name, prefix)
+		if stamp == name || (!compressed && !strings.HasSuffix(name, ext)) {
+			continue
+		}
+		stamp = strings.TrimSuffix(strings.TrimSuffix(stamp, ".gz"), ext)
+		rotated, err := time.Parse(backupTimeFormat, stamp)
+		if err != nil {
+			continue
+		}
+		// While lumberjack compresses a backup both files exist, and only the plain one is complete
+		if existing, ok := byTime[rotated]; ok && !existing.Compressed {
+			continue
+		}
+		byTime[rotated] = LogFile{Path: filepath.Join(dir, name), Compressed: compressed, End: rotated}
+	}
+
+	files := make([]LogFile, 0, len(byTime)+1)
+	for _, file := range byTime {
+		files = append(files, file)
+	}
+	sort.Slice(files, func(a, b int) bool { return files[a].End.Before(files[b].End) })
+
+	if _, err := os.Stat(logPath); err == nil {
+		files = append(files, LogFile{Path: logPath})
+	}
+	return files, nil
+}
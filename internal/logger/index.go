@@ -0,0 +1,431 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Every log file has a sidecar index next to it (events.jsonl → events.idx,
+// events-<stamp>.jsonl.gz → events-<stamp>.idx): a header followed by one record
+// per entry with its time, line offset, level, event type and service, so queries
+// can pick entries newest first and read only their lines.
+//
+// Header: magic, int64 file ID. Record: int64 time (ns), int64 offset, uint32
+// length, then level, event and service, each prefixed with a uint16 length.
+const (
+	indexMagic      = "SWX1"
+	indexHeaderSize = len(indexMagic) + 8
+)
+
+// IndexEntry locates one entry of a log file.
+type IndexEntry struct {
+	Time    time.Time
+	Offset  int64 // Where the line starts in the uncompressed file
+	Length  int   // Including the trailing newline
+	Level   string
+	Event   string
+	Service string // data.serviceName, if the entry has one
+}
+
+// End is the offset just past the entry's line.
+func (e IndexEntry) End() int64 {
+	return e.Offset + int64(e.Length)
+}
+
+// Index lists the entries of one log file in the order they were written.
+type Index struct {
+	ID      int64 // Identifies the file across rotation, for cursors
+	Entries []IndexEntry
+}
+
+// Cursor marks an entry of the event log. Entries before it are older.
+type Cursor struct {
+	File   int64 // Index ID of the file holding the entry
+	Offset int64
+}
+
+func (c Cursor) String() string {
+	return strconv.FormatInt(c.File, 36) + "." + strconv.FormatInt(c.Offset, 36)
+}
+
+// ParseCursor reads a cursor produced by Cursor.String.
+func ParseCursor(s string) (Cursor, error) {
+	file, offset, ok := strings.Cut(s, ".")
+	if !ok {
+		return Cursor{}, fmt.Errorf("malformed cursor")
+	}
+	var c Cursor
+	var err error
+	if c.File, err = strconv.ParseInt(file, 36, 64); err != nil {
+		return Cursor{}, fmt.Errorf("malformed cursor")
+	}
+	if c.Offset, err = strconv.ParseInt(offset, 36, 64); err != nil || c.Offset < 0 {
+		return Cursor{}, fmt.Errorf("malformed cursor")
+	}
+	return c, nil
+}
+
+// indexPath returns the sidecar index path of a log file or backup.
+func indexPath(logPath string) string {
+	name := strings.TrimSuffix(logPath, ".gz")
+	return strings.TrimSuffix(name, filepath.Ext(name)) + ".idx"
+}
+
+func encodeIndexHeader(id int64) []byte {
+	return binary.LittleEndian.AppendUint64([]byte(indexMagic), uint64(id))
+}
+
+func encodeIndexEntry(buf []byte, e IndexEntry) []byte {
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(e.Time.UnixNano()))
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(e.Offset))
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(e.Length))
+	for _, s := range []string{e.Level, e.Event, e.Service} {
+		if len(s) > 0xffff {
+			s = s[:0xffff]
+		}
+		buf = binary.LittleEndian.AppendUint16(buf, uint16(len(s)))
+		buf = append(buf, s...)
+	}
+	return buf
+}
+
+// decodeIndexEntries appends the records in data to entries and returns how many
+// bytes were consumed. A record cut short by a write in progress is left for later.
+func decodeIndexEntries(data []byte, entries []IndexEntry, names map[string]string) ([]IndexEntry, int) {
+	read := 0
+	for {
+		rec := data[read:]
+		if len(rec) < 20 {
+			return entries, read
+		}
+		e := IndexEntry{
+			Time:   time.Unix(0, int64(binary.LittleEndian.Uint64(rec))),
+			Offset: int64(binary.LittleEndian.Uint64(rec[8:])),
+			Length: int(binary.LittleEndian.Uint32(rec[16:])),
+		}
+		pos := 20
+		var fields [3]string
+		for i := range fields {
+			if len(rec) < pos+2 {
+				return entries, read
+			}
+			n := int(binary.LittleEndian.Uint16(rec[pos:]))
+			pos += 2
+			if len(rec) < pos+n {
+				return entries, read
+			}
+			// Event types and service names repeat, so share their strings
+			s, ok := names[string(rec[pos:pos+n])]
+			if !ok {
+				s = string(rec[pos : pos+n])
+				names[s] = s
+			}
+			fields[i] = s
+			pos += n
+		}
+		e.Level, e.Event, e.Service = fields[0], fields[1], fields[2]
+		entries = append(entries, e)
+		read += pos
+	}
+}
+
+// readIndexFile loads an index file, ignoring a torn last record.
+func readIndexFile(path string) (*Index, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < indexHeaderSize || string(data[:len(indexMagic)]) != indexMagic {
+		return nil, fmt.Errorf("%s: not an index file", path)
+	}
+	entries, _ := decodeIndexEntries(data[indexHeaderSize:], nil, make(map[string]string))
+	return &Index{ID: int64(binary.LittleEndian.Uint64(data[len(indexMagic):])), Entries: entries}, nil
+}
+
+// writeIndexFile replaces the index file at path.
+func writeIndexFile(path string, index *Index) error {
+	buf := encodeIndexHeader(index.ID)
+	for _, e := range index.Entries {
+		buf = encodeIndexEntry(buf, e)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// buildIndex indexes a log file by reading it through. Lines that aren't valid
+// JSON, and a last line still being written, are left out.
+func buildIndex(f LogFile, id int64) (*Index, error) {
+	file, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	index := &Index{ID: id}
+	reader := bufio.NewReaderSize(file, 64*1024)
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			return index, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if e, ok := parseIndexEntry(line); ok {
+			e.Offset = offset
+			e.Length = len(line)
+			index.Entries = append(index.Entries, e)
+		}
+		offset += int64(len(line))
+	}
+}
+
+func parseIndexEntry(line []byte) (IndexEntry, bool) {
+	var entry struct {
+		Time  string                 `json:"time"`
+		Level string                 `json:"level"`
+		Event string                 `json:"event"`
+		Data  map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return IndexEntry{}, false
+	}
+	t, _ := time.Parse(time.RFC3339, entry.Time)
+	service, _ := entry.Data["serviceName"].(string)
+	return IndexEntry{Time: t, Level: entry.Level, Event: entry.Event, Service: service}, true
+}
+
+// indexWriter appends to the index of the live log file.
+type indexWriter struct {
+	path string
+	id   int64
+	file *os.File
+}
+
+// openIndexWriter opens the index of the live log file for appending. An index
+// that is missing or doesn't end where the log does (after a crash, or from
+// before indexing existed) is rebuilt from the log first.
+func openIndexWriter(logPath string, size int64) (*indexWriter, error) {
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		return nil, err
+	}
+	path := indexPath(logPath)
+	index, err := readIndexFile(path)
+	consistent := err == nil &&
+		((len(index.Entries) == 0 && size == 0) ||
+			(len(index.Entries) > 0 && index.Entries[len(index.Entries)-1].End() == size))
+	if !consistent {
+		id := time.Now().UnixNano()
+		if index != nil {
+			id = index.ID
+		}
+		if size > 0 {
+			if index, err = buildIndex(LogFile{Path: logPath}, id); err != nil {
+				return nil, fmt.Errorf("failed to index %s: %w", logPath, err)
+			}
+		} else {
+			index = &Index{ID: id}
+		}
+		if err := writeIndexFile(path, index); err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &indexWriter{path: path, id: index.ID, file: file}, nil
+}
+
+// newIndexWriter starts an empty index for a freshly rotated log file.
+func newIndexWriter(logPath string) (*indexWriter, error) {
+	path := indexPath(logPath)
+	id := time.Now().UnixNano()
+	if err := writeIndexFile(path, &Index{ID: id}); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &indexWriter{path: path, id: id, file: file}, nil
+}
+
+func (w *indexWriter) append(e IndexEntry) error {
+	_, err := w.file.Write(encodeIndexEntry(nil, e))
+	return err
+}
+
+func (w *indexWriter) close() error {
+	return w.file.Close()
+}
+
+// cachedIndex is an index already read, and how much of its file that covered.
+type cachedIndex struct {
+	index *Index
+	read  int64
+	names map[string]string
+}
+
+var (
+	indexCacheMutex sync.Mutex
+	indexCache      = make(map[string]*cachedIndex)
+)
+
+// Index returns the entries of the file, oldest first. Indexes are cached, and
+// the live file's index is read incrementally as it grows. A backup without an
+// index (rotated before indexing existed) is indexed once and the result saved.
+func (f LogFile) Index() (*Index, error) {
+	path := indexPath(f.Path)
+
+	indexCacheMutex.Lock()
+	defer indexCacheMutex.Unlock()
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f.indexMissing(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	header := make([]byte, indexHeaderSize)
+	if _, err := io.ReadFull(file, header); err != nil || string(header[:len(indexMagic)]) != indexMagic {
+		return nil, fmt.Errorf("%s: not an index file", path)
+	}
+	id := int64(binary.LittleEndian.Uint64(header[len(indexMagic):]))
+
+	cached := indexCache[path]
+	// Rotation replaces the live index with a new one
+	if cached == nil || cached.index.ID != id {
+		pruneIndexCache()
+		cached = &cachedIndex{index: &Index{ID: id}, read: int64(indexHeaderSize), names: make(map[string]string)}
+		indexCache[path] = cached
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > cached.read {
+		data := make([]byte, info.Size()-cached.read)
+		n, err := file.ReadAt(data, cached.read)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		entries, read := decodeIndexEntries(data[:n], cached.index.Entries, cached.names)
+		// Callers hold on to earlier results, so never change an Index once returned
+		cached.index = &Index{ID: id, Entries: entries}
+		cached.read += int64(read)
+	}
+	return cached.index, nil
+}
+
+// indexMissing indexes a file that has no sidecar index. Called with indexCacheMutex held.
+func (f LogFile) indexMissing(path string) (*Index, error) {
+	if f.End.IsZero() {
+		// The logger keeps the live file's index; without one, index it just for this read
+		return buildIndex(f, 0)
+	}
+	index, err := buildIndex(f, f.End.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	if err := writeIndexFile(path, index); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// pruneIndexCache forgets indexes whose files are gone. Called with indexCacheMutex held.
+func pruneIndexCache() {
+	for path := range indexCache {
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			delete(indexCache, path)
+		}
+	}
+}
+
+// removeOrphanIndexes deletes the indexes of backups lumberjack has removed.
+func removeOrphanIndexes(logPath string) {
+	ext := filepath.Ext(logPath)
+	prefix := strings.TrimSuffix(logPath, ext) + "-"
+	paths, _ := filepath.Glob(prefix + "*.idx")
+	for _, path := range paths {
+		base := strings.TrimSuffix(path, ".idx") + ext
+		_, plainErr := os.Stat(base)
+		_, gzErr := os.Stat(base + ".gz")
+		if errors.Is(plainErr, os.ErrNotExist) && errors.Is(gzErr, os.ErrNotExist) {
+			os.Remove(path)
+		}
+	}
+}
+
+// EntryReader reads the lines of indexed entries from one log file.
+type EntryReader struct {
+	file *os.File
+	data []byte // Whole contents of a compressed backup
+}
+
+// OpenEntries opens the file for reading entries by their index. Compressed
+// backups can't be read at an offset, so they are decompressed up front.
+func (f LogFile) OpenEntries() (*EntryReader, error) {
+	if !f.Compressed {
+		file, err := os.Open(f.Path)
+		if err != nil {
+			return nil, err
+		}
+		return &EntryReader{file: file}, nil
+	}
+	file, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	return &EntryReader{data: data}, nil
+}
+
+// Read returns the line of an entry, without its newline.
+func (r *EntryReader) Read(e IndexEntry) ([]byte, error) {
+	var line []byte
+	if r.file != nil {
+		line = make([]byte, e.Length)
+		if _, err := r.file.ReadAt(line, e.Offset); err != nil {
+			return nil, err
+		}
+	} else {
+		if e.End() > int64(len(r.data)) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		line = r.data[e.Offset:e.End()]
+	}
+	return bytes.TrimSuffix(line, []byte("\n")), nil
+}
+
+func (r *EntryReader) Close() error {
+	if r.file != nil {
+		return r.file.Close()
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func startTestLogger(t *testing.T, logPath string) *Logger {
+	t.Helper()
+	l, err := Start(logPath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+// waitForCompression waits until lumberjack has finished gzipping backups in the
+// background, so the test doesn't race it for the files.
+func waitForCompression(t *testing.T, logPath string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		plain, _ := filepath.Glob(strings.TrimSuffix(logPath, ".jsonl") + "-*.jsonl")
+		if len(plain) == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("backups never compressed: %v", plain)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// readEntries returns the event data "n" of the entries of f, oldest first.
+func readEntries(t *testing.T, f LogFile, entries []IndexEntry) []int {
+	t.Helper()
+	reader, err := f.OpenEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	var ns []int
+	for _, e := range entries {
+		line, err := reader.Read(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var entry struct {
+			Data struct{ N int } `json:"data"`
+		}
+		if err := json.Unmarshal(line, &entry); err != nil {
+			t.Fatalf("entry at %d: %v", e.Offset, err)
+		}
+		ns = append(ns, entry.Data.N)
+	}
+	return ns
+}
+
+func TestRotationMovesIndex(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "events.jsonl")
+	l := startTestLogger(t, logPath)
+	for n := 0; n < 3; n++ {
+		l.Info("tick", map[string]interface{}{"n": n})
+	}
+	before, err := LogFile{Path: logPath}.Index()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l.mutex.Lock()
+	err = l.rotate()
+	l.mutex.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n := 3; n < 5; n++ {
+		l.Info("tick", map[string]interface{}{"n": n})
+	}
+	l.Close()
+	waitForCompression(t, logPath)
+
+	files, err := Files(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files[0].End.IsZero() || !files[0].Compressed {
+		t.Fatalf("files after rotation = %+v, want a compressed backup and the live file", files)
+	}
+	backup, live := files[0], files[1]
+
+	// The live file's index went with it, so the backup keeps its entries and ID
+	if _, err := os.Stat(indexPath(backup.Path)); err != nil {
+		t.Fatalf("backup index: %v", err)
+	}
+	backupIndex, err := backup.Index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backupIndex.ID != before.ID {
+		t.Errorf("backup index ID = %d, want %d from before the rotation", backupIndex.ID, before.ID)
+	}
+	if got := readEntries(t, backup, backupIndex.Entries); fmt.Sprint(got) != "[0 1 2]" {
+		t.Errorf("backup entries = %v, want [0 1 2]", got)
+	}
+
+	liveIndex, err := live.Index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if liveIndex.ID == before.ID {
+		t.Error("live index kept the ID of the rotated file")
+	}
+	if got := readEntries(t, live, liveIndex.Entries); fmt.Sprint(got) != "[3 4]" {
+		t.Errorf("live entries = %v, want [3 4]", got)
+	}
+}
+
+func TestCursorCrossesRotation(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "events.jsonl")
+	l := startTestLogger(t, logPath)
+	for n := 0; n < 4; n++ {
+		l.Info("tick", map[string]interface{}{"n": n})
+	}
+
+	// A page taken before the rotation ends at entry 2 of the live file
+	index, err := LogFile{Path: logPath}.Index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cursor, err := ParseCursor(Cursor{File: index.ID, Offset: index.Entries[2].Offset}.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l.mutex.Lock()
+	err = l.rotate()
+	l.mutex.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("tick", map[string]interface{}{"n": 4})
+	l.Close()
+	waitForCompression(t, logPath)
+
+	// The next page finds the file the cursor points into among the backups and
+	// continues with the entries older than it
+	files, err := Files(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var next []int
+	found := false
+	for i := len(files) - 1; i >= 0; i-- {
+		index, err := files[i].Index()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if index.ID != cursor.File {
+			continue
+		}
+		found = true
+		entries := index.Entries[:sort.Search(len(index.Entries), func(i int) bool { return index.Entries[i].Offset >= cursor.Offset })]
+		next = readEntries(t, files[i], entries)
+	}
+	if !found {
+		t.Fatal("cursor's file not found after rotation")
+	}
+	if fmt.Sprint(next) != "[0 1]" {
+		t.Errorf("next page = %v, want [0 1]", next)
+	}
+}
+
+func TestTornIndexIsRebuilt(t *testing.T) {
+	tests := []struct {
+		name   string
+		damage func(data []byte) []byte
+	}{
+		{"torn last record", func(data []byte) []byte { return data[:len(data)-3] }},
+		{"missing records", func(data []byte) []byte { return data[:indexHeaderSize] }},
+		{"not an index", func(data []byte) []byte { return []byte("garbage") }},
+		{"missing", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logPath := filepath.Join(t.TempDir(), "events.jsonl")
+			l := startTestLogger(t, logPath)
+			for n := 0; n < 3; n++ {
+				l.Info("tick", map[string]interface{}{"n": n, "serviceName": "web"})
+			}
+			l.Close()
+
+			path := indexPath(logPath)
+			if tt.damage == nil {
+				os.Remove(path)
+			} else {
+				data, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, tt.damage(data), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// Restarting rebuilds the index from the log before appending to it
+			l = startTestLogger(t, logPath)
+			l.Info("tick", map[string]interface{}{"n": 3})
+			l.Close()
+
+			index, err := readIndexFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f := LogFile{Path: logPath}
+			if got := readEntries(t, f, index.Entries); fmt.Sprint(got) != "[0 1 2 3]" {
+				t.Errorf("entries = %v, want [0 1 2 3]", got)
+			}
+			if e := index.Entries[0]; e.Level != "INFO" || e.Event != "tick" || e.Service != "web" {
+				t.Errorf("rebuilt entry = %+v", e)
+			}
+		})
+	}
+}
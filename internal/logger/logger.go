@@ -3,7 +3,8 @@ package logger
 import (
 	"encoding/json"
 	"fmt"
-	"io"
+	"log"
+	"os"
 	"sync"
 	"time"
 
@@ -13,24 +14,42 @@ import (
 	"github.com/ethan-mdev/service-watch/internal/sse"
 )
 
+// maxFileSize is the size, in MB, the live log file is rotated at.
+const maxFileSize = 10
+
 type Logger struct {
-	eventFile   io.WriteCloser
+	logPath     string
+	eventFile   *lumberjack.Logger
+	index       *indexWriter
+	size        int64 // Bytes in the live log file
 	broadcaster *sse.Broadcaster
 	mutex       sync.Mutex
 }
 
 func Start(logPath string, broadcaster *sse.Broadcaster) (*Logger, error) {
-	// Lumberjack handles rotation automatically
+	// Lumberjack handles compression and cleanup of backups
 	logFile := &lumberjack.Logger{
 		Filename:   logPath,
-		MaxSize:    10,   // MB - rotate after 10MB
-		MaxBackups: 5,    // Keep 5 old files
-		MaxAge:     7,    // Days - delete files older than 7 days
-		Compress:   true, // Compress old files with gzip
+		MaxSize:    maxFileSize, // MB - rotate after 10MB
+		MaxBackups: 5,           // Keep 5 old files
+		MaxAge:     7,           // Days - delete files older than 7 days
+		Compress:   true,        // Compress old files with gzip
+	}
+
+	var size int64
+	if info, err := os.Stat(logPath); err == nil {
+		size = info.Size()
+	}
+	index, err := openIndexWriter(logPath, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log index: %w", err)
 	}
 
 	return &Logger{
+		logPath:     logPath,
 		eventFile:   logFile,
+		index:       index,
+		size:        size,
 		broadcaster: broadcaster,
 	}, nil
 }
@@ -47,15 +66,18 @@ func (l *Logger) log(level, eventType string, data map[string]interface{}) {
 	l.mutex.Lock()
 	defer l.mutex.Unlock()
 
+	now := time.Now()
 	event := map[string]interface{}{
-		"time":  time.Now().Format(time.RFC3339),
+		"time":  now.Format(time.RFC3339),
 		"level": level,
 		"event": eventType,
 		"data":  data,
 	}
 
-	// Write to JSONL file (lumberjack handles rotation)
-	json.NewEncoder(l.eventFile).Encode(event)
+	// Write to JSONL file and record where the line went in the index
+	if line, err := json.Marshal(event); err == nil {
+		l.write(append(line, '\n'), IndexEntry{Time: now, Level: level, Event: eventType, Service: serviceName(data)})
+	}
 
 	// Print to console for development visibility
 	fmt.Printf("[%s] %s: %v\n", level, eventType, data)
@@ -63,12 +85,76 @@ func (l *Logger) log(level, eventType string, data map[string]interface{}) {
 	// Broadcast to SSE clients
 	if l.broadcaster != nil {
 		l.broadcaster.Broadcast(core.Event{
-			Type: eventType,
-			Data: data,
+			Type:  eventType,
+			Level: level,
+			Time:  now,
+			Data:  data,
 		})
 	}
 }
 
+// write appends a line to the live log file and its index. Called with mutex held.
+func (l *Logger) write(line []byte, entry IndexEntry) {
+	// Rotate here rather than leaving it to lumberjack, so the index can follow the file
+	if l.size > 0 && l.size+int64(len(line)) >= maxFileSize*1024*1024 {
+		if err := l.rotate(); err != nil {
+			log.Printf("log rotation failed: %v", err)
+		}
+	}
+
+	n, err := l.eventFile.Write(line)
+	if n < len(line) || err != nil {
+		l.size += int64(n)
+		return
+	}
+	entry.Offset = l.size
+	entry.Length = n
+	l.size += int64(n)
+	if err := l.index.append(entry); err != nil {
+		log.Printf("log index write failed: %v", err)
+	}
+}
+
+// rotate moves the live log file aside with its index and starts new ones. Called with mutex held.
+func (l *Logger) rotate() error {
+	if err := l.eventFile.Rotate(); err != nil {
+		return err
+	}
+	l.size = 0
+
+	// Lumberjack names the backup after the current time, so find the newest one
+	files, findErr := Files(l.logPath)
+	var backup *LogFile
+	for i := range files {
+		if !files[i].End.IsZero() {
+			backup = &files[i]
+		}
+	}
+
+	// Without its index a backup is indexed again when first queried, so keep going
+	l.index.close()
+	if backup != nil {
+		findErr = os.Rename(l.index.path, indexPath(backup.Path))
+	}
+	index, err := newIndexWriter(l.logPath)
+	if err != nil {
+		return err
+	}
+	l.index = index
+	removeOrphanIndexes(l.logPath)
+	return findErr
+}
+
+// serviceName returns the service an entry is about, if any.
+func serviceName(data map[string]interface{}) string {
+	name, _ := data["serviceName"].(string)
+	return name
+}
+
 func (l *Logger) Close() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.index.close()
 	return l.eventFile.Close()
 }
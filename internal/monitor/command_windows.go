@@ -0,0 +1,10 @@
+//go:build windows
+
+package monitor
+
+import "os/exec"
+
+// killGroupOnCancel leaves cmd's default cancellation in place. Windows has no
+// process groups to signal; WaitDelay still stops children holding its output
+// from blocking the caller.
+func killGroupOnCancel(cmd *exec.Cmd) {}
@@ -0,0 +1,103 @@
+package monitor
+
+import (
+	"context"
+	"slices"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+)
+
+// orderItems sorts items so dependencies are checked, and started, before their dependents.
+// The storage layer rejects cycles, but if one slips through the list order is kept.
+func orderItems(items []core.WatchlistItem) []core.WatchlistItem {
+	order, err := core.DependencyOrder(items)
+	if err != nil {
+		return items
+	}
+	byName := make(map[string]core.WatchlistItem, len(items))
+	for _, item := range items {
+		byName[item.ServiceName] = item
+	}
+	sorted := make([]core.WatchlistItem, 0, len(items))
+	for _, name := range order {
+		sorted = append(sorted, byName[name])
+	}
+	return sorted
+}
+
+// trackUpstream records whether an item is up (running with all health checks
+// passing) and, when it comes back after being down, marks its dependents to be
+// restarted as they are checked.
+func (w *Watcher) trackUpstream(item core.WatchlistItem) {
+	if item.Service == nil {
+		return
+	}
+	state := w.stateFor(item.ServiceName)
+	state.up = item.Service.State == "running" && state.healthy()
+
+	if !state.up {
+		state.wasDown = true
+		return
+	}
+	if state.wasDown {
+		state.wasDown = false
+		if item.RestartDependents {
+			for _, name := range w.dependents[item.ServiceName] {
+				w.stateFor(name).upstreamRestarted = item.ServiceName
+			}
+		}
+	}
+}
+
+// waitingOn returns the dependencies of an item that aren't up yet.
+// Dependencies the monitor hasn't checked yet count as not up.
+func (w *Watcher) waitingOn(item core.WatchlistItem) []string {
+	var waiting []string
+	for _, dep := range item.DependsOn {
+		if state, exists := w.states[dep]; !exists || !state.up {
+			waiting = append(waiting, dep)
+		}
+	}
+	return waiting
+}
+
+// holdForDependencies reports whether a restart of item must wait for its dependencies,
+// logging restart_held whenever the set it is waiting on changes.
+func (w *Watcher) holdForDependencies(item core.WatchlistItem) bool {
+	state := w.stateFor(item.ServiceName)
+	waiting := w.waitingOn(item)
+	if len(waiting) > 0 && !slices.Equal(waiting, state.waitingOn) {
+		w.log.Info("restart_held", map[string]interface{}{
+			"serviceName": item.ServiceName,
+			"waitingOn":   waiting,
+		})
+	}
+	state.waitingOn = waiting
+	return len(waiting) > 0
+}
+
+// restartForUpstream restarts a running dependent whose upstream came back up,
+// so it reconnects. It goes through restartItem like any other restart, so
+// dependents with auto-restart off, in quarantine or backing off are left alone.
+func (w *Watcher) restartForUpstream(ctx context.Context, item core.WatchlistItem) {
+	state := w.stateFor(item.ServiceName)
+	upstream := state.upstreamRestarted
+	state.upstreamRestarted = ""
+	if w.restartItem(ctx, item, "upstream restarted") {
+		w.log.Info("dependent_restart", map[string]interface{}{
+			"serviceName": item.ServiceName,
+			"upstream":    upstream,
+		})
+	}
+}
+
+// dependentsOf maps each item to the names of the items that depend on it.
+func dependentsOf(items []core.WatchlistItem) map[string][]string {
+	dependents := make(map[string][]string)
+	for _, item := range items {
+		for _, dep := range item.DependsOn {
+			dependents[dep] = append(dependents[dep], item.ServiceName)
+		}
+	}
+	return dependents
+}
@@ -0,0 +1,167 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+)
+
+// fakeFleet is a ServiceManager with several services whose state the test controls.
+// Restarts of a service in blocked wait until the test closes its channel.
+type fakeFleet struct {
+	mu       sync.Mutex
+	states   map[string]string
+	restarts map[string]int
+	blocked  map[string]chan struct{}
+}
+
+func newFakeFleet(names ...string) *fakeFleet {
+	f := &fakeFleet{states: make(map[string]string), restarts: make(map[string]int), blocked: make(map[string]chan struct{})}
+	for _, name := range names {
+		f.states[name] = "running"
+	}
+	return f
+}
+
+func (f *fakeFleet) setState(name, state string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.states[name] = state
+}
+
+func (f *fakeFleet) restartCount(name string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.restarts[name]
+}
+
+func (f *fakeFleet) List(ctx context.Context) ([]core.Service, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var services []core.Service
+	for name, state := range f.states {
+		services = append(services, core.Service{Name: name, State: state})
+	}
+	return services, nil
+}
+
+func (f *fakeFleet) Get(ctx context.Context, name string) (core.Service, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	state, exists := f.states[name]
+	if !exists {
+		return core.Service{}, fmt.Errorf("service not found: %s", name)
+	}
+	return core.Service{Name: name, State: state}, nil
+}
+
+func (f *fakeFleet) Start(ctx context.Context, name string) error {
+	f.mu.Lock()
+	block := f.blocked[name]
+	f.mu.Unlock()
+	if block != nil {
+		<-block
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.restarts[name]++
+	f.states[name] = "running"
+	return nil
+}
+
+func (f *fakeFleet) Stop(ctx context.Context, name string) error {
+	f.setState(name, "stopped")
+	return nil
+}
+
+func (f *fakeFleet) Restart(ctx context.Context, name string) error {
+	return f.Start(ctx, name)
+}
+
+// awaitRestart hands the next finished restart back to the watcher.
+func awaitRestart(t *testing.T, w *Watcher) restartResult {
+	t.Helper()
+	select {
+	case res := <-w.restartResults:
+		w.handleRestartResult(res)
+		return res
+	case <-time.After(5 * time.Second):
+		t.Fatal("restart didn't finish")
+		return restartResult{}
+	}
+}
+
+func TestDependentsRestartThroughRestartItem(t *testing.T) {
+	fleet := newFakeFleet("db", "app")
+	w, wl := newTestWatcher(t, fleet)
+	ctx := context.Background()
+	for _, name := range []string{"db", "app"} {
+		if err := wl.Add(ctx, name, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := wl.SetDependencies(ctx, "app", []string{"db"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := wl.SetRestartDependents(ctx, "db", true); err != nil {
+		t.Fatal(err)
+	}
+	w.checkServices(ctx)
+
+	// db crashes and is brought back by its own restart
+	fleet.setState("db", "stopped")
+	w.checkServices(ctx)
+	if res := awaitRestart(t, w); res.serviceName != "db" || !res.restarted {
+		t.Fatalf("restart result = %+v, want db restarted", res)
+	}
+
+	// The pass that sees db up again restarts app without waiting for it
+	release := make(chan struct{})
+	fleet.mu.Lock()
+	fleet.blocked["app"] = release
+	fleet.mu.Unlock()
+	done := make(chan struct{})
+	go func() {
+		w.checkServices(ctx)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("check pass blocked on the dependent's restart")
+	}
+	if !w.stateFor("app").restarting {
+		t.Fatal("dependent restart not in flight")
+	}
+
+	// In flight, so another pass doesn't start a second one
+	w.checkServices(ctx)
+	close(release)
+	if res := awaitRestart(t, w); res.serviceName != "app" || !res.restarted {
+		t.Fatalf("restart result = %+v, want app restarted", res)
+	}
+	if n := fleet.restartCount("app"); n != 1 {
+		t.Errorf("app restarted %d times, want 1", n)
+	}
+	item, _ := wl.Get(ctx, "app")
+	if item.RestartCount != 1 || item.FailCount != 1 {
+		t.Errorf("restartCount %d, failCount %d, want both counted through the restart policy", item.RestartCount, item.FailCount)
+	}
+
+	// Auto-restart off leaves the dependent alone
+	if err := wl.Update(ctx, "app", false); err != nil {
+		t.Fatal(err)
+	}
+	fleet.setState("db", "stopped")
+	w.stateFor("db").lastAttempt = time.Time{} // Skip the backoff
+	w.checkServices(ctx)
+	awaitRestart(t, w)
+	w.checkServices(ctx)
+	if n := fleet.restartCount("app"); n != 1 {
+		t.Errorf("app restarted %d times with auto-restart off, want 1", n)
+	}
+}
@@ -0,0 +1,118 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+)
+
+const (
+	// flapWindow is how far back state changes are scored.
+	flapWindow = 10 * time.Minute
+	// flapNewestWeight and flapOldestWeight weight a state change by its age, as
+	// in Nagios: one just seen counts more than one about to leave flapWindow.
+	flapNewestWeight = 1.2
+	flapOldestWeight = 0.8
+	// flapFullScore is the weighted number of state changes within flapWindow
+	// that scores 100%.
+	flapFullScore = 10.0
+	// flapHighThreshold is the score at which an item starts flapping and is
+	// quarantined: about five recent changes, reached on a crash loop's third
+	// crash, before the default restart policy gives up on the service.
+	flapHighThreshold = 50.0
+	// flapLowThreshold is the score below which an item stops flapping. The gap
+	// keeps a score hovering around one threshold from toggling the state.
+	flapLowThreshold = 25.0
+)
+
+// observe records the item's current state and quarantines it when it starts flapping.
+// item.Quarantine is updated in place so later checks in the same pass see it.
+func (w *Watcher) observe(ctx context.Context, item *core.WatchlistItem) {
+	if item.Service == nil {
+		return
+	}
+	state := w.stateFor(item.ServiceName)
+
+	// Released through the API; start scoring from scratch
+	if state.quarantined && item.Quarantine == nil {
+		state.resetFlapping()
+		w.log.Info("quarantine_released", map[string]interface{}{
+			"serviceName": item.ServiceName,
+		})
+	}
+	state.quarantined = item.Quarantine != nil
+
+	state.recordRunning(item.Service.State == "running", time.Now())
+
+	switch {
+	case !state.flapping && state.flapScore >= flapHighThreshold:
+		state.flapping = true
+	case state.flapping && state.flapScore < flapLowThreshold:
+		state.flapping = false
+		w.log.Info("flapping_stopped", map[string]interface{}{
+			"serviceName": item.ServiceName,
+			"flapScore":   state.flapScore,
+		})
+	}
+	if !state.flapping || item.Quarantine != nil {
+		return
+	}
+
+	quarantine := &core.Quarantine{
+		Since:     time.Now().Format(time.RFC3339),
+		Reason:    fmt.Sprintf("flap score %.1f%% reached %.0f%%", state.flapScore, flapHighThreshold),
+		FlapScore: state.flapScore,
+	}
+	if err := w.watchlist.SetQuarantine(ctx, item.ServiceName, quarantine); err != nil {
+		return
+	}
+	item.Quarantine = quarantine
+	state.quarantined = true
+
+	w.log.Error("service_flapping", map[string]interface{}{
+		"serviceName": item.ServiceName,
+		"flapScore":   state.flapScore,
+		"message":     "Auto-restart suspended until released",
+	})
+}
+
+// recordRunning notes whether the service is running, remembering when it changed,
+// and rescores the changes still within flapWindow. Seeing the same state again,
+// from a poll and a push alike, changes nothing.
+func (s *itemState) recordRunning(running bool, now time.Time) {
+	if s.observed && running != s.lastRunning {
+		s.transitions = append(s.transitions, now)
+	}
+	s.observed = true
+	s.lastRunning = running
+
+	cutoff := now.Add(-flapWindow)
+	expired := 0
+	for expired < len(s.transitions) && s.transitions[expired].Before(cutoff) {
+		expired++
+	}
+	s.transitions = s.transitions[expired:]
+	s.flapScore = flapScore(s.transitions, now)
+}
+
+// flapScore computes the weighted percent state change of the transitions
+// within flapWindow of now, weighting each from flapOldestWeight at the edge of
+// the window up to flapNewestWeight for one seen now.
+func flapScore(transitions []time.Time, now time.Time) float64 {
+	var score float64
+	for _, t := range transitions {
+		age := min(max(now.Sub(t), 0), flapWindow)
+		score += flapNewestWeight - (flapNewestWeight-flapOldestWeight)*float64(age)/float64(flapWindow)
+	}
+	return min(score/flapFullScore*100, 100)
+}
+
+// resetFlapping forgets the state changes seen so far.
+func (s *itemState) resetFlapping() {
+	s.transitions = nil
+	s.observed = false
+	s.flapScore = 0
+	s.flapping = false
+}
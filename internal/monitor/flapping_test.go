@@ -0,0 +1,261 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+	"github.com/ethan-mdev/service-watch/internal/logger"
+	"github.com/ethan-mdev/service-watch/internal/sse"
+	"github.com/ethan-mdev/service-watch/internal/storage"
+)
+
+// fakeServices is a ServiceManager with one service whose state the test controls.
+type fakeServices struct {
+	mu     sync.Mutex
+	state  string
+	starts int
+}
+
+func (f *fakeServices) setState(state string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.state = state
+}
+
+func (f *fakeServices) List(ctx context.Context) ([]core.Service, error) {
+	svc, _ := f.Get(ctx, "app")
+	return []core.Service{svc}, nil
+}
+
+func (f *fakeServices) Get(ctx context.Context, name string) (core.Service, error) {
+	if name != "app" {
+		return core.Service{}, fmt.Errorf("service not found: %s", name)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return core.Service{Name: name, State: f.state}, nil
+}
+
+func (f *fakeServices) Start(ctx context.Context, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.starts++
+	f.state = "running"
+	return nil
+}
+
+func (f *fakeServices) Stop(ctx context.Context, name string) error {
+	f.setState("stopped")
+	return nil
+}
+
+func (f *fakeServices) Restart(ctx context.Context, name string) error {
+	return f.Start(ctx, name)
+}
+
+func newTestWatcher(t *testing.T, svc core.ServiceManager) (*Watcher, core.WatchlistManager) {
+	t.Helper()
+	dir := t.TempDir()
+	log, err := logger.Start(filepath.Join(dir, "events.jsonl"), sse.NewBroadcaster())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { log.Close() })
+	wl := storage.NewJSONWatchlist(filepath.Join(dir, "watchlist.json"), svc)
+	return New(wl, svc, log), wl
+}
+
+// checkOnce runs the per-item part of a polling pass for name, waiting for any
+// restart it starts to finish.
+func checkOnce(t *testing.T, w *Watcher, wl core.WatchlistManager, name string) core.WatchlistItem {
+	t.Helper()
+	ctx := context.Background()
+	item, err := wl.Get(ctx, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.observe(ctx, &item)
+	w.checkItem(ctx, item)
+	if w.stateFor(name).restarting {
+		select {
+		case res := <-w.restartResults:
+			w.handleRestartResult(res)
+		case <-time.After(5 * time.Second):
+			t.Fatal("restart didn't finish")
+		}
+	}
+	return item
+}
+
+func TestCrashLoopIsQuarantinedBeforeMaxAttempts(t *testing.T) {
+	svc := &fakeServices{state: "running"}
+	w, wl := newTestWatcher(t, svc)
+	ctx := context.Background()
+	if err := wl.Add(ctx, "app", true); err != nil {
+		t.Fatal(err)
+	}
+	checkOnce(t, w, wl, "app")
+
+	maxAttempts := core.DefaultRestartPolicy().MaxAttempts
+	for crash := 1; crash <= maxAttempts+1; crash++ {
+		// Dies before the next poll sees it running
+		svc.setState("stopped")
+		item := checkOnce(t, w, wl, "app")
+		if item.Quarantine != nil {
+			if !item.AutoRestart {
+				t.Fatal("auto-restart was given up before the item was quarantined")
+			}
+			if svc.starts > maxAttempts {
+				t.Errorf("%d restarts before quarantine, want at most %d", svc.starts, maxAttempts)
+			}
+			return
+		}
+		// Skip the backoff rather than waiting it out
+		w.stateFor("app").lastAttempt = time.Time{}
+	}
+
+	item, _ := wl.Get(ctx, "app")
+	t.Fatalf("not quarantined after %d crashes (autoRestart %v, failCount %d, flapScore %.0f)",
+		maxAttempts+1, item.AutoRestart, item.FailCount, w.stateFor("app").flapScore)
+}
+
+func TestRecordRunning(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	type observation struct {
+		after   time.Duration // Since start
+		running bool
+	}
+	tests := []struct {
+		name         string
+		observations []observation
+		want         int // Transitions still within the window
+	}{
+		{
+			name:         "steady",
+			observations: []observation{{0, true}, {2 * time.Second, true}, {4 * time.Second, true}},
+			want:         0,
+		},
+		{
+			// A push and a poll reporting the same stop
+			name:         "repeated state counts once",
+			observations: []observation{{0, true}, {time.Second, false}, {time.Second, false}, {2 * time.Second, false}},
+			want:         1,
+		},
+		{
+			name: "crash loop",
+			observations: []observation{
+				{0, true}, {10 * time.Second, false}, {11 * time.Second, true}, {20 * time.Second, false},
+				{25 * time.Second, true}, {40 * time.Second, false}, {50 * time.Second, true},
+			},
+			want: 6,
+		},
+		{
+			name: "old changes expire",
+			observations: []observation{
+				{0, true}, {time.Minute, false}, {2 * time.Minute, true}, {13 * time.Minute, false},
+			},
+			want: 1,
+		},
+		{
+			// The first observation is only a baseline
+			name:         "starts stopped",
+			observations: []observation{{0, false}, {time.Second, true}},
+			want:         1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := &itemState{}
+			for _, o := range tt.observations {
+				state.recordRunning(o.running, start.Add(o.after))
+			}
+			if len(state.transitions) != tt.want {
+				t.Errorf("%d transitions, want %d", len(state.transitions), tt.want)
+			}
+		})
+	}
+}
+
+func TestFlapScore(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	ago := func(ds ...time.Duration) []time.Time {
+		var ts []time.Time
+		for _, d := range ds {
+			ts = append(ts, now.Add(-d))
+		}
+		return ts
+	}
+	tests := []struct {
+		name        string
+		transitions []time.Time
+		want        float64
+	}{
+		{"none", nil, 0},
+		{"one now", ago(0), 12},
+		{"one at the edge of the window", ago(flapWindow), 8},
+		{"one halfway", ago(flapWindow / 2), 10},
+		{"newer count more", ago(time.Minute, 2*time.Minute), 22.8},
+		{"capped", ago(0, 0, 0, 0, 0, 0, 0, 0, 0, 0), 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := flapScore(tt.transitions, now); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("flapScore = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlappingThresholds(t *testing.T) {
+	svc := &fakeServices{state: "running"}
+	w, wl := newTestWatcher(t, svc)
+	ctx := context.Background()
+	if err := wl.Add(ctx, "app", true); err != nil {
+		t.Fatal(err)
+	}
+
+	// observeWith scores n state changes seen just now, without adding another
+	observeWith := func(n int) core.WatchlistItem {
+		t.Helper()
+		state := w.stateFor("app")
+		state.transitions = nil
+		for i := 0; i < n; i++ {
+			state.transitions = append(state.transitions, time.Now())
+		}
+		state.observed, state.lastRunning = true, true
+		item, err := wl.Get(ctx, "app")
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.observe(ctx, &item)
+		return item
+	}
+
+	steps := []struct {
+		changes     int
+		flapping    bool
+		quarantined bool
+	}{
+		{4, false, false}, // 48%, below the high threshold
+		{5, true, true},   // 60%, starts flapping
+		{3, true, true},   // 36%, between the thresholds
+		{2, false, true},  // 24%, below the low threshold; the quarantine stays until released
+		{4, false, true},  // Needs the high threshold again
+	}
+	for i, step := range steps {
+		item := observeWith(step.changes)
+		state := w.stateFor("app")
+		if state.flapping != step.flapping {
+			t.Errorf("step %d (%d changes, score %.1f): flapping = %v, want %v", i, step.changes, state.flapScore, state.flapping, step.flapping)
+		}
+		if (item.Quarantine != nil) != step.quarantined {
+			t.Errorf("step %d: quarantine = %+v, want quarantined %v", i, item.Quarantine, step.quarantined)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+)
+
+// maxCapturedOutput bounds how much probe output is kept for error messages and events.
+const maxCapturedOutput = 4096
+
+// commandWaitDelay is how long a command's output is still read after it exits or
+// is killed, so a background child left holding the pipe can't block the caller.
+const commandWaitDelay = 2 * time.Second
+
+// runHealthCheck runs a single probe. ctx carries the check's timeout.
+func runHealthCheck(ctx context.Context, check core.HealthCheck) error {
+	switch check.Type {
+	case "http":
+		return probeHTTP(ctx, check)
+	case "tcp":
+		return probeTCP(ctx, check.Address)
+	case "exec":
+		return probeExec(ctx, check)
+	default:
+		return fmt.Errorf("unknown health check type: %q", check.Type)
+	}
+}
+
+func probeHTTP(ctx context.Context, check core.HealthCheck) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, check.URL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if check.ExpectStatus != 0 {
+		if resp.StatusCode != check.ExpectStatus {
+			return fmt.Errorf("status %d, expected %d", resp.StatusCode, check.ExpectStatus)
+		}
+	} else if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	if check.BodyRegex != "" {
+		re, err := regexp.Compile(check.BodyRegex)
+		if err != nil {
+			return err
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		if err != nil {
+			return err
+		}
+		if !re.Match(body) {
+			return fmt.Errorf("body does not match %q", check.BodyRegex)
+		}
+	}
+	return nil
+}
+
+func probeTCP(ctx context.Context, address string) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func probeExec(ctx context.Context, check core.HealthCheck) error {
+	output, exitCode, err := runCommand(ctx, check.Command, nil)
+	if err != nil {
+		return err
+	}
+	if exitCode != check.ExpectExitCode {
+		if output != "" {
+			return fmt.Errorf("exit code %d, expected %d: %s", exitCode, check.ExpectExitCode, output)
+		}
+		return fmt.Errorf("exit code %d, expected %d", exitCode, check.ExpectExitCode)
+	}
+	return nil
+}
+
+// runCommand runs argv with env added to the inherited environment, and returns its
+// combined, truncated output and exit code. err is only set when the command
+// couldn't be run or was cut off by ctx.
+func runCommand(ctx context.Context, argv []string, env []string) (string, int, error) {
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	killGroupOnCancel(cmd)
+	cmd.WaitDelay = commandWaitDelay
+	out := &cappedBuffer{limit: maxCapturedOutput}
+	cmd.Stdout = out
+	cmd.Stderr = out
+
+	err := cmd.Run()
+	output := strings.TrimSpace(out.buf.String())
+	if out.truncated {
+		output += "..."
+	}
+
+	if ctx.Err() != nil {
+		return output, -1, ctx.Err()
+	}
+	// The command exited, but something it left running kept the output open
+	if errors.Is(err, exec.ErrWaitDelay) && cmd.ProcessState != nil {
+		return output, cmd.ProcessState.ExitCode(), nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return output, exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return output, -1, err
+	}
+	return output, 0, nil
+}
+
+// cappedBuffer keeps the first limit bytes written to it and discards the rest,
+// so a chatty command can't grow memory without bound.
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); len(p) > room {
+		b.buf.Write(p[:max(room, 0)])
+		b.truncated = true
+	} else {
+		b.buf.Write(p)
+	}
+	return len(p), nil
+}
@@ -0,0 +1,82 @@
+//go:build !windows
+
+package monitor
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		argv     []string
+		timeout  time.Duration
+		wantCode int
+		wantErr  bool
+		check    func(t *testing.T, output string)
+	}{
+		{
+			name:     "exit code and env",
+			argv:     []string{"sh", "-c", `echo "$SERVICE_WATCH_SERVICE"; exit 3`},
+			timeout:  5 * time.Second,
+			wantCode: 3,
+			check: func(t *testing.T, output string) {
+				if output != "nginx" {
+					t.Errorf("output = %q, want nginx", output)
+				}
+			},
+		},
+		{
+			// The background child inherits the output pipe and would keep Wait
+			// blocked for its whole life without WaitDelay
+			name:    "background child holding output",
+			argv:    []string{"sh", "-c", "sleep 30 & echo started"},
+			timeout: 10 * time.Second,
+			check: func(t *testing.T, output string) {
+				if output != "started" {
+					t.Errorf("output = %q, want started", output)
+				}
+			},
+		},
+		{
+			name:    "timeout kills the process group",
+			argv:    []string{"sh", "-c", "sleep 30 & sleep 30"},
+			timeout: 200 * time.Millisecond,
+			wantErr: true,
+		},
+		{
+			name:    "output is capped",
+			argv:    []string{"sh", "-c", "head -c 1000000 /dev/zero | tr '\\0' x"},
+			timeout: 5 * time.Second,
+			check: func(t *testing.T, output string) {
+				if len(output) != maxCapturedOutput+len("...") || !strings.HasSuffix(output, "...") {
+					t.Errorf("output is %d bytes, want %d and truncated", len(output), maxCapturedOutput+3)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+
+			started := time.Now()
+			output, code, err := runCommand(ctx, tt.argv, []string{"SERVICE_WATCH_SERVICE=nginx"})
+			if elapsed := time.Since(started); elapsed > tt.timeout/2+commandWaitDelay+time.Second {
+				t.Errorf("runCommand took %v", elapsed)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d", code, tt.wantCode)
+			}
+			if tt.check != nil {
+				tt.check(t, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+)
+
+// Hook stages, as named in core.RestartHooks.
+const (
+	stagePreRestart  = "preRestart"
+	stagePostSuccess = "postSuccess"
+	stagePostFailure = "postFailure"
+)
+
+// hookContext describes the restart a hook runs around. Exec hooks get it as
+// SERVICE_WATCH_* environment variables; HTTP hooks without a body get it as JSON.
+type hookContext struct {
+	ServiceName string `json:"serviceName"`
+	Stage       string `json:"stage"`
+	Reason      string `json:"reason"`
+	Attempt     int    `json:"attempt"`
+	Error       string `json:"error,omitempty"` // postFailure: why the restart failed
+}
+
+func (hc hookContext) env() []string {
+	return []string{
+		"SERVICE_WATCH_SERVICE=" + hc.ServiceName,
+		"SERVICE_WATCH_STAGE=" + hc.Stage,
+		"SERVICE_WATCH_REASON=" + hc.Reason,
+		"SERVICE_WATCH_ATTEMPT=" + strconv.Itoa(hc.Attempt),
+		"SERVICE_WATCH_ERROR=" + hc.Error,
+	}
+}
+
+// runHooks runs the hooks of an item for one stage in order, logging each outcome.
+// It stops and returns the error of the first failing hook marked abortOnFailure.
+func (w *Watcher) runHooks(ctx context.Context, item core.WatchlistItem, hc hookContext) error {
+	if item.Hooks == nil {
+		return nil
+	}
+	var hooks []core.Hook
+	switch hc.Stage {
+	case stagePreRestart:
+		hooks = item.Hooks.PreRestart
+	case stagePostSuccess:
+		hooks = item.Hooks.PostSuccess
+	case stagePostFailure:
+		hooks = item.Hooks.PostFailure
+	}
+
+	for _, hook := range hooks {
+		hook = hook.WithDefaults()
+		started := time.Now()
+		hookCtx, cancel := context.WithTimeout(ctx, time.Duration(hook.Timeout))
+		output, err := runHook(hookCtx, hook, hc)
+		cancel()
+
+		event := map[string]interface{}{
+			"serviceName": item.ServiceName,
+			"stage":       hc.Stage,
+			"hook":        hook.Label(),
+			"success":     err == nil,
+			"durationMs":  time.Since(started).Milliseconds(),
+			"output":      output,
+		}
+		if err == nil {
+			w.log.Info("hook_run", event)
+			continue
+		}
+		event["error"] = err.Error()
+		w.log.Error("hook_run", event)
+
+		if hook.AbortOnFailure {
+			return fmt.Errorf("hook %s failed: %w", hook.Label(), err)
+		}
+	}
+	return nil
+}
+
+// runHook runs a single hook and returns its captured output. ctx carries the hook's timeout.
+func runHook(ctx context.Context, hook core.Hook, hc hookContext) (string, error) {
+	switch hook.Type {
+	case "exec":
+		output, exitCode, err := runCommand(ctx, hook.Command, hc.env())
+		if err != nil {
+			return output, err
+		}
+		if exitCode != 0 {
+			return output, fmt.Errorf("exit code %d", exitCode)
+		}
+		return output, nil
+	case "http":
+		return callHook(ctx, hook, hc)
+	default:
+		return "", fmt.Errorf("unknown hook type: %q", hook.Type)
+	}
+}
+
+func callHook(ctx context.Context, hook core.Hook, hc hookContext) (string, error) {
+	body := []byte(hook.Body)
+	if hook.Body == "" {
+		body, _ = json.Marshal(hc)
+	}
+	req, err := http.NewRequestWithContext(ctx, hook.Method, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	if hook.Body == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range hook.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxCapturedOutput))
+	output := strings.TrimSpace(string(respBody))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return output, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return output, nil
+}
@@ -0,0 +1,62 @@
+package monitor
+
+import (
+	"time"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+)
+
+// activeMaintenance returns the window of an item in effect at t, if any.
+// When windows overlap, the one closing last wins, and one without an end beats all.
+func activeMaintenance(item core.WatchlistItem, t time.Time) *core.ActiveMaintenance {
+	var active *core.ActiveMaintenance
+	for _, window := range item.Maintenance {
+		until, ok := window.ActiveAt(t)
+		if !ok {
+			continue
+		}
+		if active == nil || until.IsZero() || (!active.Until.IsZero() && until.After(active.Until)) {
+			active = &core.ActiveMaintenance{
+				WindowID: window.ID,
+				Reason:   window.Reason,
+				Until:    until,
+			}
+		}
+	}
+	return active
+}
+
+// checkMaintenance logs maintenance windows opening and closing, and reports
+// whether the item is currently in one.
+func (w *Watcher) checkMaintenance(item core.WatchlistItem) bool {
+	state := w.stateFor(item.ServiceName)
+	active := activeMaintenance(item, time.Now())
+
+	switch {
+	case active != nil && state.maintenance == nil:
+		event := map[string]interface{}{
+			"serviceName": item.ServiceName,
+			"windowId":    active.WindowID,
+			"reason":      active.Reason,
+		}
+		if !active.Until.IsZero() {
+			event["until"] = active.Until.Format(time.RFC3339)
+		}
+		w.log.Info("maintenance_started", event)
+	case active == nil && state.maintenance != nil:
+		w.log.Info("maintenance_ended", map[string]interface{}{
+			"serviceName": item.ServiceName,
+			"windowId":    state.maintenance.WindowID,
+		})
+		// Planned stops and restarts shouldn't count against the service
+		state.resetFlapping()
+		state.samples = nil
+		state.resetHealth()
+	}
+
+	if active != nil {
+		state.upstreamRestarted = "" // Not restarted for its upstream once the window ends
+	}
+	state.maintenance = active
+	return active != nil
+}
@@ -0,0 +1,189 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+)
+
+// maxSamples caps the sliding window kept per item. ThresholdRule.Validate keeps
+// rules within what it holds.
+const maxSamples = core.MaxThresholdChecks
+
+// sample is one resource reading of a running service.
+type sample struct {
+	at         time.Time
+	cpuPercent float64
+	memoryMB   float64
+}
+
+func (s sample) metric(name string) float64 {
+	if name == "cpuPercent" {
+		return s.cpuPercent
+	}
+	return s.memoryMB
+}
+
+// thresholdState tracks one threshold rule of an item.
+type thresholdState struct {
+	status core.ThresholdStatus
+}
+
+// evaluateThresholds records a resource sample and applies the item's threshold rules.
+func (w *Watcher) evaluateThresholds(ctx context.Context, item core.WatchlistItem) {
+	state := w.stateFor(item.ServiceName)
+
+	// Only running services produce meaningful samples
+	if item.Service == nil || item.Service.State != "running" {
+		state.samples = nil
+		return
+	}
+
+	now := time.Now()
+	state.samples = append(state.samples, sample{
+		at:         now,
+		cpuPercent: item.Service.CPUPercent,
+		memoryMB:   item.Service.MemoryMB,
+	})
+	state.samples = trimSamples(state.samples, item.Thresholds, now)
+
+	labels := make(map[string]bool, len(item.Thresholds))
+	for _, rule := range item.Thresholds {
+		label := rule.Label()
+		labels[label] = true
+
+		ts, exists := state.thresholds[label]
+		if !exists {
+			ts = &thresholdState{status: core.ThresholdStatus{Rule: label}}
+			state.thresholds[label] = ts
+		}
+		ts.status.Value = item.Service.MetricValue(rule.Metric)
+
+		breached := windowBreached(state.samples, rule, now)
+		switch {
+		case breached && !ts.status.Firing:
+			ts.status.Firing = true
+			ts.status.Since = now
+			w.fireThreshold(ctx, item, rule, ts.status.Value)
+		case breached && rule.Action == "restart":
+			// The restart may have been held back by the restart policy's backoff
+			w.restartItem(ctx, item, "threshold: "+rule.Label())
+		case !breached && ts.status.Firing:
+			ts.status.Firing = false
+			ts.status.Since = time.Time{}
+			w.log.Info("threshold_cleared", map[string]interface{}{
+				"serviceName": item.ServiceName,
+				"rule":        label,
+				"metric":      rule.Metric,
+				"value":       ts.status.Value,
+			})
+		}
+	}
+
+	// Forget rules that were removed from the item
+	for label := range state.thresholds {
+		if !labels[label] {
+			delete(state.thresholds, label)
+		}
+	}
+}
+
+// fireThreshold logs a breached rule and carries out its action. A restart the
+// item doesn't allow is reported as restartSkipped rather than left out silently.
+func (w *Watcher) fireThreshold(ctx context.Context, item core.WatchlistItem, rule core.ThresholdRule, value float64) {
+	reason := fmt.Sprintf("%s is %.1f (%s)", rule.Metric, value, rule.Label())
+	data := map[string]interface{}{
+		"serviceName": item.ServiceName,
+		"rule":        rule.Label(),
+		"metric":      rule.Metric,
+		"value":       value,
+		"threshold":   rule.Value,
+		"action":      rule.Action,
+		"reason":      reason,
+	}
+	if rule.Action == "restart" {
+		switch {
+		case !item.AutoRestart:
+			data["restartSkipped"] = "autoRestart is off"
+		case item.Quarantine != nil:
+			data["restartSkipped"] = "quarantined"
+		}
+	}
+	w.log.Error("threshold_exceeded", data)
+
+	if rule.Action == "restart" {
+		w.restartItem(ctx, item, "threshold: "+reason)
+	}
+}
+
+// windowBreached reports whether the rule's condition held for its whole window:
+// the last Checks samples, or every sample within the last For.
+func windowBreached(samples []sample, rule core.ThresholdRule, now time.Time) bool {
+	if len(samples) == 0 {
+		return false
+	}
+
+	if rule.For > 0 {
+		windowStart := now.Add(-time.Duration(rule.For))
+		// Not enough history yet to cover the window
+		if samples[0].at.After(windowStart) {
+			return false
+		}
+		for i := len(samples) - 1; i >= 0 && !samples[i].at.Before(windowStart); i-- {
+			if !rule.Breached(samples[i].metric(rule.Metric)) {
+				return false
+			}
+		}
+		return true
+	}
+
+	checks := rule.Checks
+	if checks <= 0 {
+		checks = 1
+	}
+	if len(samples) < checks {
+		return false
+	}
+	for _, s := range samples[len(samples)-checks:] {
+		if !rule.Breached(s.metric(rule.Metric)) {
+			return false
+		}
+	}
+	return true
+}
+
+// trimSamples drops samples older than any rule needs. One sample before the
+// longest For window is kept so the window can be shown to be fully covered.
+func trimSamples(samples []sample, rules []core.ThresholdRule, now time.Time) []sample {
+	var longest time.Duration
+	checks := 1
+	for _, rule := range rules {
+		if time.Duration(rule.For) > longest {
+			longest = time.Duration(rule.For)
+		}
+		if rule.Checks > checks {
+			checks = rule.Checks
+		}
+	}
+
+	keep := len(samples)
+	cutoff := now.Add(-longest)
+	for i, s := range samples {
+		if !s.at.Before(cutoff) {
+			keep = len(samples) - i + 1
+			break
+		}
+	}
+	if keep < checks {
+		keep = checks
+	}
+	if keep > maxSamples {
+		keep = maxSamples
+	}
+	if keep >= len(samples) {
+		return samples
+	}
+	return append([]sample(nil), samples[len(samples)-keep:]...)
+}
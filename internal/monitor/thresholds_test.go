@@ -0,0 +1,108 @@
+package monitor
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+	"github.com/ethan-mdev/service-watch/internal/logger"
+	"github.com/ethan-mdev/service-watch/internal/sse"
+	"github.com/ethan-mdev/service-watch/internal/storage"
+)
+
+func TestLongestWindowsCanBreach(t *testing.T) {
+	tests := []struct {
+		name string
+		rule core.ThresholdRule
+	}{
+		{"for", core.ThresholdRule{Metric: "memoryMB", Operator: ">", Value: 100, For: core.MaxThresholdFor, Action: "alert"}},
+		{"checks", core.ThresholdRule{Metric: "memoryMB", Operator: ">", Value: 100, Checks: core.MaxThresholdChecks, Action: "alert"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.rule.Validate(); err != nil {
+				t.Fatalf("Validate() = %v", err)
+			}
+
+			// Breaching on every 2s poll for longer than either window
+			start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+			var samples []sample
+			now := start
+			for ; now.Sub(start) < 70*time.Minute; now = now.Add(2 * time.Second) {
+				samples = append(samples, sample{at: now, memoryMB: 500})
+				samples = trimSamples(samples, []core.ThresholdRule{tt.rule}, now)
+			}
+			if !windowBreached(samples, tt.rule, now) {
+				t.Errorf("rule never breached with %d samples kept", len(samples))
+			}
+
+			tooLong := tt.rule
+			tooLong.For *= 2
+			tooLong.Checks *= 2
+			if err := tooLong.Validate(); err == nil {
+				t.Error("Validate() accepted a window longer than the monitor keeps")
+			}
+		})
+	}
+}
+
+func TestRestartThresholdReportsSkippedRestart(t *testing.T) {
+	rule := core.ThresholdRule{Metric: "memoryMB", Operator: ">", Value: 100, Action: "restart"}
+	tests := []struct {
+		name        string
+		autoRestart bool
+		quarantined bool
+		wantSkipped string
+	}{
+		{name: "auto-restart", autoRestart: true},
+		{name: "auto-restart off", wantSkipped: "autoRestart is off"},
+		{name: "quarantined", autoRestart: true, quarantined: true, wantSkipped: "quarantined"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &fakeServices{state: "running"}
+			broadcaster := sse.NewBroadcaster()
+			client := &sse.Client{Channel: make(chan core.Event, 16)}
+			broadcaster.RegisterClient(client)
+			log, err := logger.Start(filepath.Join(t.TempDir(), "events.jsonl"), broadcaster)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer log.Close()
+			wl := storage.NewJSONWatchlist(filepath.Join(t.TempDir(), "watchlist.json"), svc)
+			w := New(wl, svc, log)
+			ctx := context.Background()
+			if err := wl.Add(ctx, "app", tt.autoRestart); err != nil {
+				t.Fatal(err)
+			}
+			item, _ := wl.Get(ctx, "app")
+			if tt.quarantined {
+				item.Quarantine = &core.Quarantine{Reason: "flapping"}
+			}
+
+			w.fireThreshold(ctx, item, rule, 500)
+			restarted := w.stateFor("app").restarting
+			if restarted {
+				awaitRestart(t, w)
+			}
+
+			var exceeded map[string]interface{}
+			for len(client.Channel) > 0 {
+				if event := <-client.Channel; event.Type == "threshold_exceeded" {
+					exceeded = event.Data.(map[string]interface{})
+				}
+			}
+			if exceeded == nil {
+				t.Fatal("threshold_exceeded not logged")
+			}
+			if skipped, _ := exceeded["restartSkipped"].(string); skipped != tt.wantSkipped {
+				t.Errorf("restartSkipped = %q, want %q", skipped, tt.wantSkipped)
+			}
+			if restarted != (tt.wantSkipped == "") {
+				t.Errorf("restarted = %v, want a restart only when none was skipped", restarted)
+			}
+		})
+	}
+}
@@ -2,6 +2,8 @@ package monitor
 
 import (
 	"context"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/ethan-mdev/service-watch/internal/core"
@@ -10,44 +12,169 @@ import (
 	"github.com/shirou/gopsutil/v4/mem"
 )
 
-// Start begins monitoring watchlist items and auto-restarting services.
-func Start(ctx context.Context, watchlistMgr core.WatchlistManager, svcMgr core.ServiceManager, log *logger.Logger) {
-	log.Info("watcher_started", map[string]interface{}{
+// Watcher monitors watchlist items, auto-restarting services and running their health checks.
+type Watcher struct {
+	watchlist core.WatchlistManager
+	services  core.ServiceManager
+	log       *logger.Logger
+
+	// Only touched by the Run goroutine
+	states         map[string]*itemState
+	names          map[string]string   // Watched item names keyed by their normalized spelling
+	dependents     map[string][]string // Names of the items depending on each item, as of the last poll
+	healthResults  chan healthResult
+	restartResults chan restartResult
+
+	// Snapshot of item status published for Status and Stats callers
+	statusMutex sync.RWMutex
+	status      map[string]core.ItemStatus
+	stats       core.MonitorStats
+}
+
+// itemState is runtime bookkeeping kept for each watched service.
+type itemState struct {
+	lastAttempt       time.Time               // When a restart was last attempted
+	runningSince      time.Time               // When the service was first seen running, zero while it isn't
+	health            map[string]*healthState // Keyed by HealthCheck.Label
+	samples           []sample                // Recent resource usage, oldest first
+	thresholds        map[string]*thresholdState
+	transitions       []time.Time             // When the service started or stopped within flapWindow, oldest first
+	lastRunning       bool                    // Whether the service was running when last observed
+	observed          bool                    // lastRunning is known
+	flapScore         float64                 // Weighted percent state change of transitions
+	flapping          bool                    // Score reached flapHighThreshold and hasn't fallen below flapLowThreshold since
+	quarantined       bool                    // Quarantine state seen on the last observation
+	maintenance       *core.ActiveMaintenance // Window in effect on the last check
+	up                bool                    // Running with all health checks passing on the last check
+	wasDown           bool                    // Seen not up since dependents were last restarted
+	waitingOn         []string                // Dependencies holding back a restart
+	restarting        bool                    // A restart and its hooks are in flight
+	upstreamRestarted string                  // Dependency that came back up, to restart this item for on its next check
+}
+
+// healthState tracks one health check of an item.
+type healthState struct {
+	check   core.HealthCheck
+	status  core.HealthStatus
+	running bool // A probe is in flight
+}
+
+// healthResult is sent back to the Run goroutine when a probe finishes.
+type healthResult struct {
+	serviceName string
+	label       string
+	err         error
+	started     time.Time
+	duration    time.Duration
+}
+
+// restartResult is sent back to the Run goroutine when a restart and its hooks finish.
+type restartResult struct {
+	serviceName string
+	restarted   bool // The service was started or restarted
+}
+
+// New creates a watcher for the items of watchlistMgr.
+func New(watchlistMgr core.WatchlistManager, svcMgr core.ServiceManager, log *logger.Logger) *Watcher {
+	return &Watcher{
+		watchlist:      watchlistMgr,
+		services:       svcMgr,
+		log:            log,
+		states:         make(map[string]*itemState),
+		names:          make(map[string]string),
+		healthResults:  make(chan healthResult, 16),
+		restartResults: make(chan restartResult, 16),
+		status:         make(map[string]core.ItemStatus),
+	}
+}
+
+// Run monitors until ctx is cancelled.
+// Service managers that implement core.StateWatcher are also subscribed to, so
+// state transitions are handled as soon as they happen rather than on the next poll.
+func (w *Watcher) Run(ctx context.Context) {
+	var changes <-chan core.StateChange
+	if sw, ok := w.services.(core.StateWatcher); ok {
+		changes = sw.Watch(ctx)
+	}
+
+	w.log.Info("watcher_started", map[string]interface{}{
 		"interval": "2s",
+		"push":     changes != nil,
 	})
 
 	ticker := time.NewTicker(2 * time.Second)
 	defer ticker.Stop()
 
 	// Check immediately on startup
-	checkServices(ctx, watchlistMgr, svcMgr, log)
+	w.checkServices(ctx)
 
 	for {
 		select {
 		case <-ctx.Done():
-			log.Info("watcher_stopped", nil)
+			w.log.Info("watcher_stopped", nil)
 			return
 		case <-ticker.C:
-			checkServices(ctx, watchlistMgr, svcMgr, log)
+			w.checkServices(ctx)
+		case change, ok := <-changes:
+			if !ok {
+				// Stream ended; the ticker keeps everything covered
+				w.log.Info("watcher_push_stopped", map[string]interface{}{
+					"fallback": "polling",
+				})
+				changes = nil
+				continue
+			}
+			if change.Error != "" {
+				w.log.Error("watcher_push_error", map[string]interface{}{
+					"error": change.Error,
+				})
+				continue
+			}
+			w.handleStateChange(ctx, change)
+		case res := <-w.healthResults:
+			w.handleHealthResult(ctx, res)
+		case res := <-w.restartResults:
+			w.handleRestartResult(res)
 		}
 	}
 }
 
-func checkServices(ctx context.Context, watchlistMgr core.WatchlistManager, svcMgr core.ServiceManager, log *logger.Logger) {
-	items, err := watchlistMgr.List(ctx)
+// Status implements core.StatusProvider.
+func (w *Watcher) Status(name string) (core.ItemStatus, bool) {
+	w.statusMutex.RLock()
+	defer w.statusMutex.RUnlock()
+	status, exists := w.status[name]
+	return status, exists
+}
+
+// Stats implements core.StatsProvider.
+func (w *Watcher) Stats() core.MonitorStats {
+	w.statusMutex.RLock()
+	defer w.statusMutex.RUnlock()
+	return w.stats
+}
+
+func (w *Watcher) checkServices(ctx context.Context) {
+	started := time.Now()
+	items, err := w.watchlist.List(ctx)
 	if err != nil {
-		log.Error("watcher_list_failed", map[string]interface{}{
+		w.log.Error("watcher_list_failed", map[string]interface{}{
 			"error": err.Error(),
 		})
 		return
 	}
 
-	checkHostResources(log)
+	host := checkHostResources(w.log)
 
-	for _, item := range items {
+	watched := make(map[string]bool, len(items))
+	names := make(map[string]string, len(items))
+	w.dependents = dependentsOf(items)
+	for _, item := range orderItems(items) {
+		watched[item.ServiceName] = true
+		names[w.normalizeName(item.ServiceName)] = item.ServiceName
 
 		if item.Service != nil {
-			log.Info("service_status", map[string]interface{}{
+			w.log.Info("service_status", map[string]interface{}{
 				"serviceName": item.ServiceName,
 				"state":       item.Service.State,
 				"cpuPercent":  item.Service.CPUPercent,
@@ -57,50 +184,435 @@ func checkServices(ctx context.Context, watchlistMgr core.WatchlistManager, svcM
 			})
 		}
 
-		if !item.AutoRestart || item.Service == nil {
+		w.trackUpstream(item)
+
+		// Status is still logged during maintenance, but nothing acts on it
+		if w.checkMaintenance(item) {
+			w.publish(item.ServiceName)
 			continue
 		}
 
-		if item.Service.State != "running" {
-			if item.FailCount >= 3 {
-				log.Error("service_failed", map[string]interface{}{
+		w.observe(ctx, &item)
+		w.checkItem(ctx, item)
+		w.evaluateThresholds(ctx, item)
+		w.scheduleHealthChecks(ctx, item)
+		w.publish(item.ServiceName)
+	}
+
+	w.names = names
+
+	// Drop bookkeeping for items removed from the watchlist
+	for name := range w.states {
+		if !watched[name] {
+			delete(w.states, name)
+			w.statusMutex.Lock()
+			delete(w.status, name)
+			w.statusMutex.Unlock()
+		}
+	}
+
+	duration := time.Since(started)
+	w.statusMutex.Lock()
+	w.stats.Items = items
+	w.stats.Host = host
+	w.stats.LastCheck = started
+	w.stats.Checks++
+	w.stats.CheckTime += duration
+	w.stats.LastDuration = duration
+	w.statusMutex.Unlock()
+}
+
+// handleStateChange reacts to a pushed transition of a watched service.
+// The pushed name may be spelled differently from the item's (nginx.service for
+// an item added as nginx), so it is matched on the backend's normalized name.
+func (w *Watcher) handleStateChange(ctx context.Context, change core.StateChange) {
+	name, watched := w.names[w.normalizeName(change.Name)]
+	if !watched {
+		name = change.Name
+	}
+	item, err := w.watchlist.Get(ctx, name)
+	if err != nil {
+		return // not on the watchlist
+	}
+
+	w.log.Info("service_state_changed", map[string]interface{}{
+		"serviceName": item.ServiceName,
+		"state":       change.State,
+	})
+
+	w.trackUpstream(item)
+	if w.checkMaintenance(item) {
+		w.publish(item.ServiceName)
+		return
+	}
+
+	w.observe(ctx, &item)
+	w.checkItem(ctx, item)
+	w.publish(item.ServiceName)
+}
+
+// normalizeName returns the service manager's canonical spelling of name.
+func (w *Watcher) normalizeName(name string) string {
+	if n, ok := w.services.(core.NameNormalizer); ok {
+		return n.NormalizeName(name)
+	}
+	return name
+}
+
+// checkItem restarts a watched service that is no longer running.
+func (w *Watcher) checkItem(ctx context.Context, item core.WatchlistItem) {
+	if item.Service == nil {
+		return
+	}
+
+	state := w.stateFor(item.ServiceName)
+
+	if item.Service.State == "running" {
+		if state.runningSince.IsZero() {
+			state.runningSince = time.Now()
+		}
+		state.waitingOn = nil
+		if state.upstreamRestarted != "" {
+			w.restartForUpstream(ctx, item)
+			return
+		}
+		// A long enough healthy period wipes out earlier attempts
+		policy := item.Policy()
+		if item.FailCount > 0 && state.healthy() && time.Since(state.runningSince) >= time.Duration(policy.ResetAfter) {
+			if err := w.watchlist.ResetFailCount(ctx, item.ServiceName); err == nil {
+				w.log.Info("fail_count_reset", map[string]interface{}{
 					"serviceName": item.ServiceName,
 					"failCount":   item.FailCount,
-					"message":     "Exceeded max restart attempts",
 				})
-				watchlistMgr.Update(ctx, item.ServiceName, false)
-				continue
 			}
+		}
+		return
+	}
+	state.runningSince = time.Time{}
+	state.upstreamRestarted = "" // Its own restart will pick up the new upstream
 
-			log.Info("restart_attempt", map[string]interface{}{
-				"serviceName": item.ServiceName,
-				"state":       item.Service.State,
-			})
+	w.restartItem(ctx, item, "stopped")
+}
 
-			if err := svcMgr.Start(ctx, item.ServiceName); err != nil {
-				log.Error("restart_failed", map[string]interface{}{
-					"serviceName": item.ServiceName,
-					"error":       err.Error(),
-					"failCount":   item.FailCount + 1,
-				})
-			} else {
-				watchlistMgr.IncrementRestartCount(ctx, item.ServiceName)
-				log.Info("restart_success", map[string]interface{}{
-					"serviceName":  item.ServiceName,
-					"restartCount": item.RestartCount + 1,
-				})
+// restartItem brings a service back up, following its restart policy, and
+// reports whether an attempt was started. reason is recorded in the
+// restart_attempt event. The restart and its hooks run off the Run goroutine,
+// reporting back through restartResults.
+func (w *Watcher) restartItem(ctx context.Context, item core.WatchlistItem, reason string) bool {
+	if !item.AutoRestart || item.Quarantine != nil || activeMaintenance(item, time.Now()) != nil {
+		return false
+	}
+	state := w.stateFor(item.ServiceName)
+	if state.restarting {
+		return false
+	}
+	// Starting before upstream is up would only fail or leave it misconfigured
+	if w.holdForDependencies(item) {
+		return false
+	}
+
+	policy := item.Policy()
+
+	if policy.MaxAttempts > 0 && item.FailCount >= policy.MaxAttempts {
+		w.log.Error("service_failed", map[string]interface{}{
+			"serviceName": item.ServiceName,
+			"failCount":   item.FailCount,
+			"message":     "Exceeded max restart attempts",
+		})
+		w.watchlist.Update(ctx, item.ServiceName, false)
+		return false
+	}
+
+	// Back off between consecutive attempts
+	if time.Since(state.lastAttempt) < policy.Delay(item.FailCount) {
+		return false
+	}
+
+	svcState := "unknown"
+	if item.Service != nil {
+		svcState = item.Service.State
+	}
+	w.log.Info("restart_attempt", map[string]interface{}{
+		"serviceName": item.ServiceName,
+		"state":       svcState,
+		"attempt":     item.FailCount + 1,
+		"reason":      reason,
+	})
+
+	state.lastAttempt = time.Now()
+	state.restarting = true
+	w.watchlist.IncrementFailCount(ctx, item.ServiceName)
+
+	go func() {
+		hc := hookContext{ServiceName: item.ServiceName, Stage: stagePreRestart, Reason: reason, Attempt: item.FailCount + 1}
+		restarted := w.restart(ctx, item, svcState, hc)
+		select {
+		case w.restartResults <- restartResult{serviceName: item.ServiceName, restarted: restarted}:
+		case <-ctx.Done():
+		}
+	}()
+	return true
+}
+
+// restart runs one restart attempt of item with its hooks, and reports whether
+// the service was started.
+func (w *Watcher) restart(ctx context.Context, item core.WatchlistItem, svcState string, hc hookContext) bool {
+	policy := item.Policy()
+	if err := w.runHooks(ctx, item, hc); err != nil {
+		// Counted as an attempt so backoff and maxAttempts still apply
+		w.log.Error("restart_aborted", map[string]interface{}{
+			"serviceName":   item.ServiceName,
+			"error":         err.Error(),
+			"failCount":     item.FailCount + 1,
+			"nextAttemptIn": policy.Delay(item.FailCount + 1).String(),
+		})
+		return false
+	}
+
+	// A wedged service is still running and needs a full restart
+	var err error
+	if svcState == "running" {
+		err = w.services.Restart(ctx, item.ServiceName)
+	} else {
+		err = w.services.Start(ctx, item.ServiceName)
+	}
+
+	if err != nil {
+		w.log.Error("restart_failed", map[string]interface{}{
+			"serviceName":   item.ServiceName,
+			"error":         err.Error(),
+			"failCount":     item.FailCount + 1,
+			"nextAttemptIn": policy.Delay(item.FailCount + 1).String(),
+		})
+		hc.Stage = stagePostFailure
+		hc.Error = err.Error()
+		w.runHooks(ctx, item, hc)
+		return false
+	}
+
+	w.watchlist.IncrementRestartCount(ctx, item.ServiceName)
+	w.log.Info("restart_success", map[string]interface{}{
+		"serviceName":  item.ServiceName,
+		"restartCount": item.RestartCount + 1,
+	})
+	hc.Stage = stagePostSuccess
+	w.runHooks(ctx, item, hc)
+	return true
+}
+
+// handleRestartResult records the end of a restart started by restartItem.
+func (w *Watcher) handleRestartResult(res restartResult) {
+	state, exists := w.states[res.serviceName]
+	if !exists {
+		return
+	}
+	state.restarting = false
+	if res.restarted {
+		state.markRestarted()
+		// A short-lived run can fall between polls, but still counts as a change
+		state.recordRunning(true, time.Now())
+	}
+}
+
+// scheduleHealthChecks starts the probes of an item that are due.
+// Results come back to Run through healthResults.
+func (w *Watcher) scheduleHealthChecks(ctx context.Context, item core.WatchlistItem) {
+	state := w.stateFor(item.ServiceName)
+	labels := make(map[string]bool, len(item.HealthChecks))
+
+	for _, check := range item.HealthChecks {
+		check = check.WithDefaults()
+		label := check.Label()
+		labels[label] = true
+
+		hs, exists := state.health[label]
+		if !exists {
+			hs = &healthState{status: core.HealthStatus{Check: label, Healthy: true}}
+			state.health[label] = hs
+		}
+		hs.check = check
+
+		// Probing a stopped service tells us nothing the state doesn't
+		if item.Service == nil || item.Service.State != "running" {
+			continue
+		}
+		if hs.running || time.Since(hs.status.LastCheck) < time.Duration(check.Interval) {
+			continue
+		}
+
+		hs.running = true
+		go func(name string, check core.HealthCheck) {
+			started := time.Now()
+			checkCtx, cancel := context.WithTimeout(ctx, time.Duration(check.Timeout))
+			err := runHealthCheck(checkCtx, check)
+			cancel()
+
+			select {
+			case w.healthResults <- healthResult{
+				serviceName: name,
+				label:       check.Label(),
+				err:         err,
+				started:     started,
+				duration:    time.Since(started),
+			}:
+			case <-ctx.Done():
 			}
+		}(item.ServiceName, check)
+	}
+
+	// Forget checks that were removed from the item
+	for label := range state.health {
+		if !labels[label] {
+			delete(state.health, label)
+		}
+	}
+}
+
+// handleHealthResult records a probe outcome and restarts the service once
+// a check has failed FailureThreshold times in a row.
+func (w *Watcher) handleHealthResult(ctx context.Context, res healthResult) {
+	state, exists := w.states[res.serviceName]
+	if !exists {
+		return
+	}
+	hs, exists := state.health[res.label]
+	if !exists {
+		return
+	}
+	hs.running = false
+	hs.status.LastCheck = res.started
+	hs.status.LastDuration = core.Duration(res.duration)
+	defer w.publish(res.serviceName)
+
+	if res.err == nil {
+		hs.status.Healthy = true
+		hs.status.ConsecutiveFailures = 0
+		hs.status.LastError = ""
+		w.log.Info("health_check", map[string]interface{}{
+			"serviceName": res.serviceName,
+			"check":       res.label,
+			"healthy":     true,
+			"durationMs":  res.duration.Milliseconds(),
+		})
+		return
+	}
+
+	hs.status.ConsecutiveFailures++
+	hs.status.LastError = res.err.Error()
+	w.log.Error("health_check", map[string]interface{}{
+		"serviceName":         res.serviceName,
+		"check":               res.label,
+		"healthy":             false,
+		"durationMs":          res.duration.Milliseconds(),
+		"error":               res.err.Error(),
+		"consecutiveFailures": hs.status.ConsecutiveFailures,
+	})
+
+	if hs.status.ConsecutiveFailures < hs.check.FailureThreshold {
+		return
+	}
+	if hs.status.Healthy {
+		hs.status.Healthy = false
+		w.log.Error("service_unhealthy", map[string]interface{}{
+			"serviceName":         res.serviceName,
+			"check":               res.label,
+			"consecutiveFailures": hs.status.ConsecutiveFailures,
+		})
+	}
+
+	item, err := w.watchlist.Get(ctx, res.serviceName)
+	if err != nil {
+		return
+	}
+	w.restartItem(ctx, item, "health_check")
+}
+
+// publish snapshots an item's runtime state for Status.
+func (w *Watcher) publish(name string) {
+	state, exists := w.states[name]
+	if !exists {
+		return
+	}
+
+	status := core.ItemStatus{
+		FlapScore:   state.flapScore,
+		Flapping:    state.flapping,
+		Maintenance: state.maintenance,
+		WaitingOn:   state.waitingOn,
+	}
+	for _, hs := range state.health {
+		status.Health = append(status.Health, hs.status)
+	}
+	sort.Slice(status.Health, func(i, j int) bool { return status.Health[i].Check < status.Health[j].Check })
+	for _, ts := range state.thresholds {
+		status.Thresholds = append(status.Thresholds, ts.status)
+	}
+	sort.Slice(status.Thresholds, func(i, j int) bool { return status.Thresholds[i].Rule < status.Thresholds[j].Rule })
+
+	w.statusMutex.Lock()
+	w.status[name] = status
+	w.statusMutex.Unlock()
+}
+
+// stateFor returns the bookkeeping for a service, creating it on first use.
+func (w *Watcher) stateFor(name string) *itemState {
+	state, exists := w.states[name]
+	if !exists {
+		state = &itemState{
+			health:     make(map[string]*healthState),
+			thresholds: make(map[string]*thresholdState),
 		}
+		w.states[name] = state
 	}
+	return state
 }
 
-func checkHostResources(log *logger.Logger) {
+// healthy reports whether every health check of the item is passing.
+func (s *itemState) healthy() bool {
+	for _, hs := range s.health {
+		if !hs.status.Healthy {
+			return false
+		}
+	}
+	return true
+}
+
+// resetHealth gives a freshly restarted service a clean slate and a full
+// interval to come up before it is probed again.
+func (s *itemState) resetHealth() {
+	now := time.Now()
+	for _, hs := range s.health {
+		hs.status.Healthy = true
+		hs.status.ConsecutiveFailures = 0
+		hs.status.LastError = ""
+		hs.status.LastCheck = now
+	}
+}
+
+// markRestarted resets what is known about a service the monitor just restarted.
+// It counts as down until it is next seen running, so dependents wait for it.
+func (s *itemState) markRestarted() {
+	s.resetHealth()
+	// Usage of the old process says nothing about the new one
+	s.samples = nil
+	s.up = false
+	s.wasDown = true
+}
+
+func checkHostResources(log *logger.Logger) core.HostResources {
 	mem, _ := mem.VirtualMemory()
 	cpuPercents, _ := cpu.Percent(time.Second, false)
+	host := core.HostResources{
+		CPUPercent:  cpuPercents[0],
+		TotalMB:     mem.Total / 1024 / 1024,
+		UsedMB:      mem.Used / 1024 / 1024,
+		UsedPercent: mem.UsedPercent,
+	}
 	log.Info("host_resources", map[string]interface{}{
-		"cpuPercent":  cpuPercents[0],
-		"totalMB":     mem.Total / 1024 / 1024,
-		"usedMB":      mem.Used / 1024 / 1024,
-		"usedPercent": mem.UsedPercent,
+		"cpuPercent":  host.CPUPercent,
+		"totalMB":     host.TotalMB,
+		"usedMB":      host.UsedMB,
+		"usedPercent": host.UsedPercent,
 	})
+	return host
 }
@@ -0,0 +1,285 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+	"github.com/ethan-mdev/service-watch/internal/logger"
+	"github.com/ethan-mdev/service-watch/internal/sse"
+)
+
+// defaultEmailEvents are mailed when the config doesn't list any.
+var defaultEmailEvents = []string{"service_failed", "restart_failed", "threshold_*"}
+
+const defaultSubjectTemplate = `[service-watch] {{if eq (len .Events) 1}}{{with index .Events 0}}{{.Event}}{{with .Data.serviceName}} on {{.}}{{end}}{{end}}{{else}}{{len .Events}} events{{end}} ({{.Hostname}})`
+
+const defaultBodyTemplate = `{{len .Events}} event(s) on {{.Hostname}}:
+{{range .Events}}
+{{.Time.Format "2006-01-02 15:04:05"}} [{{.Level}}] {{.Event}}{{with .Data.serviceName}} - {{.}}{{end}}
+{{- range $key, $value := .Data}}
+    {{$key}}: {{$value}}
+{{- end}}
+{{end}}`
+
+// EmailConfig configures email alerts.
+type EmailConfig struct {
+	Filter
+	Host        string        `json:"host"`
+	Port        int           `json:"port,omitempty"`     // Default 587
+	Username    string        `json:"username,omitempty"` // PLAIN auth when set
+	Password    string        `json:"password,omitempty"`
+	TLS         bool          `json:"tls,omitempty"` // Implicit TLS (usually port 465); otherwise STARTTLS is used when offered
+	From        string        `json:"from"`
+	To          []string      `json:"to"`
+	BatchWindow core.Duration `json:"batchWindow,omitempty"` // Events within this long of the first are sent as one digest (default 1m)
+	MaxBatch    int           `json:"maxBatch,omitempty"`    // Send early once a digest has this many events (default 50)
+	Subject     string        `json:"subject,omitempty"`     // Go template; see defaultSubjectTemplate
+	Body        string        `json:"body,omitempty"`        // Go template; see defaultBodyTemplate
+}
+
+// EmailData is what the subject and body templates are executed with.
+type EmailData struct {
+	Hostname string
+	Events   []Payload // Oldest first
+}
+
+// LoadEmailConfig reads the email config from a JSON file. A missing file means email is off.
+func LoadEmailConfig(path string) (*EmailConfig, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg EmailConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid email config: %w", err)
+	}
+	if cfg.Host == "" || cfg.From == "" || len(cfg.To) == 0 {
+		return nil, fmt.Errorf("invalid email config: host, from and to are required")
+	}
+	return &cfg, nil
+}
+
+// Emailer mails matching events through an SMTP server, batching bursts into digests.
+type Emailer struct {
+	cfg      EmailConfig
+	subject  *template.Template
+	body     *template.Template
+	hostname string
+	log      *logger.Logger
+}
+
+// NewEmailer creates an emailer, filling in defaults and parsing the templates.
+func NewEmailer(cfg EmailConfig, log *logger.Logger) (*Emailer, error) {
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+	if len(cfg.Events) == 0 {
+		cfg.Events = defaultEmailEvents
+	}
+	if cfg.BatchWindow <= 0 {
+		cfg.BatchWindow = core.Duration(time.Minute)
+	}
+	if cfg.MaxBatch <= 0 {
+		cfg.MaxBatch = 50
+	}
+	if cfg.Subject == "" {
+		cfg.Subject = defaultSubjectTemplate
+	}
+	if cfg.Body == "" {
+		cfg.Body = defaultBodyTemplate
+	}
+
+	subject, err := template.New("subject").Parse(cfg.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subject template: %w", err)
+	}
+	body, err := template.New("body").Parse(cfg.Body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid body template: %w", err)
+	}
+
+	hostname, _ := os.Hostname()
+	return &Emailer{cfg: cfg, subject: subject, body: body, hostname: hostname, log: log}, nil
+}
+
+// Run subscribes to the broadcaster and mails matching events until ctx is cancelled.
+// The first event of a burst opens a batch window; everything matching within it
+// is sent together.
+func (e *Emailer) Run(ctx context.Context, broadcaster *sse.Broadcaster) {
+	sub := broadcaster.Subscribe(ctx, "email", e.log)
+	defer sub.Close()
+
+	e.batch(ctx, sub.Events)
+}
+
+// batch sends the matching events read from events in digests until ctx is cancelled.
+func (e *Emailer) batch(ctx context.Context, events <-chan core.Event) {
+	var batch []Payload
+	var window <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-events:
+			// Never mail about mailing, or a broken server would feed itself
+			if strings.HasPrefix(event.Type, "email_") || !e.cfg.Matches(event) {
+				continue
+			}
+			batch = append(batch, Payload{Event: event.Type, Level: event.Level, Time: event.Time, Data: event.Data})
+			if len(batch) == 1 {
+				window = time.After(time.Duration(e.cfg.BatchWindow))
+			}
+			if len(batch) >= e.cfg.MaxBatch {
+				go e.Send(ctx, batch)
+				batch, window = nil, nil
+			}
+		case <-window:
+			go e.Send(ctx, batch)
+			batch, window = nil, nil
+		}
+	}
+}
+
+// Send mails events as one message, retrying twice, and logs the outcome.
+func (e *Emailer) Send(ctx context.Context, events []Payload) error {
+	subject, body, err := e.render(events)
+	if err != nil {
+		e.log.Error("email_failed", map[string]interface{}{
+			"events": len(events),
+			"error":  err.Error(),
+		})
+		return err
+	}
+	msg := e.message(subject, body)
+
+	delay := 5 * time.Second
+	for attempt := 1; ; attempt++ {
+		err = e.deliver(msg)
+		if err == nil || attempt == 3 {
+			break
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		delay *= 2
+	}
+
+	if err != nil {
+		e.log.Error("email_failed", map[string]interface{}{
+			"subject": subject,
+			"events":  len(events),
+			"error":   err.Error(),
+		})
+		return err
+	}
+	e.log.Info("email_sent", map[string]interface{}{
+		"subject":    subject,
+		"events":     len(events),
+		"recipients": e.cfg.To,
+	})
+	return nil
+}
+
+func (e *Emailer) render(events []Payload) (string, string, error) {
+	data := EmailData{Hostname: e.hostname, Events: events}
+	var subject, body bytes.Buffer
+	if err := e.subject.Execute(&subject, data); err != nil {
+		return "", "", fmt.Errorf("subject template: %w", err)
+	}
+	if err := e.body.Execute(&body, data); err != nil {
+		return "", "", fmt.Errorf("body template: %w", err)
+	}
+	// Headers can't span lines
+	return strings.Join(strings.Fields(subject.String()), " "), body.String(), nil
+}
+
+// message builds a plain text RFC 5322 message.
+func (e *Emailer) message(subject, body string) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", e.cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(e.cfg.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	return msg.Bytes()
+}
+
+// deliver sends msg over one SMTP session, like smtp.SendMail but with a
+// timeout and support for implicit TLS.
+func (e *Emailer) deliver(msg []byte) error {
+	addr := net.JoinHostPort(e.cfg.Host, strconv.Itoa(e.cfg.Port))
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+
+	var conn net.Conn
+	var err error
+	if e.cfg.TLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: e.cfg.Host})
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(time.Minute))
+
+	c, err := smtp.NewClient(conn, e.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if !e.cfg.TLS {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(&tls.Config{ServerName: e.cfg.Host}); err != nil {
+				return err
+			}
+		}
+	}
+	if e.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", e.cfg.Username, e.cfg.Password, e.cfg.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(e.cfg.From); err != nil {
+		return err
+	}
+	for _, to := range e.cfg.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
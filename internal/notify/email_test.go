@@ -0,0 +1,291 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+	"github.com/ethan-mdev/service-watch/internal/logger"
+	"github.com/ethan-mdev/service-watch/internal/sse"
+)
+
+type smtpMessage struct {
+	from string
+	to   []string
+	data []byte
+}
+
+// fakeSMTP runs a minimal SMTP server that hands every message it accepts to the returned channel.
+func fakeSMTP(t *testing.T) (host string, port int, messages <-chan smtpMessage) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	received := make(chan smtpMessage, 10)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSMTP(conn, received)
+		}
+	}()
+	addr := ln.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, received
+}
+
+func serveSMTP(conn net.Conn, received chan<- smtpMessage) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 fake ESMTP")
+
+	var msg smtpMessage
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch {
+		case verb == "EHLO" || verb == "HELO":
+			tp.PrintfLine("250 fake")
+		case strings.HasPrefix(strings.ToUpper(line), "MAIL FROM:"):
+			msg = smtpMessage{from: strings.Trim(line[len("MAIL FROM:"):], "<>")}
+			tp.PrintfLine("250 OK")
+		case strings.HasPrefix(strings.ToUpper(line), "RCPT TO:"):
+			msg.to = append(msg.to, strings.Trim(line[len("RCPT TO:"):], "<>"))
+			tp.PrintfLine("250 OK")
+		case verb == "DATA":
+			tp.PrintfLine("354 Go ahead")
+			if msg.data, err = tp.ReadDotBytes(); err != nil {
+				return
+			}
+			received <- msg
+			tp.PrintfLine("250 Queued")
+		case verb == "QUIT":
+			tp.PrintfLine("221 Bye")
+			return
+		default:
+			tp.PrintfLine("250 OK")
+		}
+	}
+}
+
+// newTestEmailer returns an emailer and the events it logs.
+func newTestEmailer(t *testing.T, cfg EmailConfig) (*Emailer, <-chan core.Event) {
+	t.Helper()
+	broadcaster := sse.NewBroadcaster()
+	logged := &sse.Client{Channel: make(chan core.Event, 10)}
+	broadcaster.RegisterClient(logged)
+	log, err := logger.Start(filepath.Join(t.TempDir(), "events.jsonl"), broadcaster)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { log.Close() })
+	e, err := NewEmailer(cfg, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.hostname = "testhost"
+	return e, logged.Channel
+}
+
+// waitForMessage waits for the server to receive a message and the emailer to log sending it.
+func waitForMessage(t *testing.T, messages <-chan smtpMessage, logged <-chan core.Event) (*mail.Message, string) {
+	t.Helper()
+	select {
+	case event := <-logged:
+		if event.Type != "email_sent" {
+			t.Fatalf("logged %s: %v", event.Type, event.Data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("sending was never logged")
+	}
+	select {
+	case m := <-messages:
+		msg, err := mail.ReadMessage(bufio.NewReader(strings.NewReader(string(m.data))))
+		if err != nil {
+			t.Fatal(err)
+		}
+		subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return msg, subject
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message arrived")
+		return nil, ""
+	}
+}
+
+func TestEmailDigest(t *testing.T) {
+	host, port, messages := fakeSMTP(t)
+	e, logged := newTestEmailer(t, EmailConfig{
+		Filter:      Filter{Events: []string{"service_failed", "restart_failed", "email_*"}},
+		Host:        host,
+		Port:        port,
+		From:        "watch@example.com",
+		To:          []string{"ops@example.com", "oncall@example.com"},
+		BatchWindow: core.Duration(200 * time.Millisecond),
+	})
+
+	events := make(chan core.Event, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go e.batch(ctx, events)
+
+	at := time.Date(2025, 11, 4, 12, 0, 0, 0, time.Local)
+	events <- core.Event{Type: "service_failed", Level: "ERROR", Time: at, Data: map[string]interface{}{"serviceName": "nginx"}}
+	events <- core.Event{Type: "service_status", Level: "INFO", Time: at, Data: map[string]interface{}{"serviceName": "nginx"}}
+	events <- core.Event{Type: "email_failed", Level: "ERROR", Time: at, Data: map[string]interface{}{"error": "refused"}}
+	events <- core.Event{Type: "restart_failed", Level: "ERROR", Time: at.Add(time.Second), Data: map[string]interface{}{"serviceName": "mysql"}}
+
+	msg, subject := waitForMessage(t, messages, logged)
+	if subject != "[service-watch] 2 events (testhost)" {
+		t.Errorf("subject = %q", subject)
+	}
+	if got := msg.Header.Get("To"); got != "ops@example.com, oncall@example.com" {
+		t.Errorf("To = %q", got)
+	}
+	body, _ := io.ReadAll(msg.Body)
+	for _, want := range []string{
+		"2 event(s) on testhost:",
+		"2025-11-04 12:00:00 [ERROR] service_failed - nginx",
+		"2025-11-04 12:00:01 [ERROR] restart_failed - mysql",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("body doesn't contain %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(string(body), "service_status") || strings.Contains(string(body), "email_failed") {
+		t.Errorf("body has events that shouldn't be mailed:\n%s", body)
+	}
+
+	select {
+	case <-messages:
+		t.Error("a second message for the same burst")
+	case <-time.After(500 * time.Millisecond):
+	}
+}
+
+func TestEmailSentEarlyAtMaxBatch(t *testing.T) {
+	host, port, messages := fakeSMTP(t)
+	e, logged := newTestEmailer(t, EmailConfig{
+		Host:        host,
+		Port:        port,
+		From:        "watch@example.com",
+		To:          []string{"ops@example.com"},
+		BatchWindow: core.Duration(time.Hour),
+		MaxBatch:    2,
+	})
+
+	events := make(chan core.Event, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go e.batch(ctx, events)
+
+	for _, name := range []string{"web", "api"} {
+		events <- core.Event{Type: "service_failed", Level: "ERROR", Time: time.Now(), Data: map[string]interface{}{"serviceName": name}}
+	}
+	if _, subject := waitForMessage(t, messages, logged); subject != "[service-watch] 2 events (testhost)" {
+		t.Errorf("subject = %q", subject)
+	}
+}
+
+func TestEmailTemplates(t *testing.T) {
+	at := time.Date(2025, 11, 4, 12, 0, 0, 0, time.Local)
+	failed := Payload{Event: "service_failed", Level: "ERROR", Time: at, Data: map[string]interface{}{"serviceName": "nginx", "exitCode": 3}}
+	host := Payload{Event: "host_resources", Level: "INFO", Time: at, Data: map[string]interface{}{"cpuPercent": 97.5}}
+
+	tests := []struct {
+		name        string
+		subject     string
+		body        string
+		events      []Payload
+		wantSubject string
+		wantBody    []string
+	}{
+		{
+			name:        "one event",
+			events:      []Payload{failed},
+			wantSubject: "[service-watch] service_failed on nginx (testhost)",
+			wantBody:    []string{"1 event(s) on testhost:", "2025-11-04 12:00:00 [ERROR] service_failed - nginx", "    exitCode: 3"},
+		},
+		{
+			name:        "one event without a service",
+			events:      []Payload{host},
+			wantSubject: "[service-watch] host_resources (testhost)",
+			wantBody:    []string{"[INFO] host_resources\n", "    cpuPercent: 97.5"},
+		},
+		{
+			name:        "digest",
+			events:      []Payload{failed, host, failed},
+			wantSubject: "[service-watch] 3 events (testhost)",
+			wantBody:    []string{"3 event(s) on testhost:"},
+		},
+		{
+			name:        "custom",
+			subject:     "{{len .Events}}\n  alert(s) on {{.Hostname}}",
+			body:        "{{range .Events}}{{.Event}};{{end}}",
+			events:      []Payload{failed, host},
+			wantSubject: "2 alert(s) on testhost",
+			wantBody:    []string{"service_failed;host_resources;"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, _ := newTestEmailer(t, EmailConfig{Host: "localhost", From: "a@b", To: []string{"c@d"}, Subject: tt.subject, Body: tt.body})
+			subject, body, err := e.render(tt.events)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if subject != tt.wantSubject {
+				t.Errorf("subject = %q, want %q", subject, tt.wantSubject)
+			}
+			for _, want := range tt.wantBody {
+				if !strings.Contains(body, want) {
+					t.Errorf("body doesn't contain %q:\n%s", want, body)
+				}
+			}
+		})
+	}
+
+	if _, err := NewEmailer(EmailConfig{Subject: "{{.Events"}, nil); err == nil {
+		t.Error("NewEmailer accepted a malformed subject template")
+	}
+	e, _ := newTestEmailer(t, EmailConfig{Body: "{{.Missing}}"})
+	if _, _, err := e.render([]Payload{failed}); err == nil || !strings.HasPrefix(err.Error(), "body template") {
+		t.Errorf("render with a failing body template: %v", err)
+	}
+}
+
+func TestEmailMessage(t *testing.T) {
+	e, _ := newTestEmailer(t, EmailConfig{From: "watch@example.com", To: []string{"ops@example.com"}})
+	raw := e.message("Dienst gestoppt: Überwachung", "line one\nline two\n")
+	msg, err := mail.ReadMessage(bufio.NewReader(strings.NewReader(string(raw))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "Dienst gestoppt: Überwachung" {
+		t.Errorf("subject = %q (%v)", subject, err)
+	}
+	body, _ := io.ReadAll(msg.Body)
+	if string(body) != "line one\r\nline two\r\n" {
+		t.Errorf("body = %s", strconv.Quote(string(body)))
+	}
+}
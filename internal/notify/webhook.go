@@ -0,0 +1,401 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+	"github.com/ethan-mdev/service-watch/internal/logger"
+	"github.com/ethan-mdev/service-watch/internal/sse"
+)
+
+const (
+	// maxDeliveries is how many recent deliveries are kept for Deliveries.
+	maxDeliveries = 500
+	// queueSize bounds the events waiting per webhook; further events are dropped.
+	queueSize = 100
+	// maxRetryDelay caps the backoff between delivery attempts.
+	maxRetryDelay = 30 * time.Second
+)
+
+// Filter selects the events a notification channel sends. Empty lists match everything.
+type Filter struct {
+	Events   []string `json:"events,omitempty"`   // Event types, "restart_*" style prefixes allowed
+	Services []string `json:"services,omitempty"` // Only events about these services
+	Levels   []string `json:"levels,omitempty"`   // Only events of these levels (INFO|ERROR)
+}
+
+// Matches reports whether an event passes the filter.
+func (f Filter) Matches(event core.Event) bool {
+	if len(f.Events) > 0 && !matchAny(f.Events, event.Type) {
+		return false
+	}
+	if len(f.Levels) > 0 {
+		matched := false
+		for _, level := range f.Levels {
+			if strings.EqualFold(level, event.Level) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(f.Services) > 0 {
+		data, _ := event.Data.(map[string]interface{})
+		name, _ := data["serviceName"].(string)
+		if name == "" || !matchAny(f.Services, name) {
+			return false
+		}
+	}
+	return true
+}
+
+// Webhook is an endpoint that receives events as JSON POSTs.
+type Webhook struct {
+	Filter
+	Name       string            `json:"name"`
+	URL        string            `json:"url"`
+	Secret     string            `json:"secret,omitempty"`     // Key for the X-ServiceWatch-Signature HMAC
+	Headers    map[string]string `json:"headers,omitempty"`    // Extra request headers
+	MaxRetries int               `json:"maxRetries,omitempty"` // Retries after a failed attempt (default 3, -1 for none)
+	Timeout    core.Duration     `json:"timeout,omitempty"`    // Per-attempt timeout (default 10s)
+}
+
+// Validate reports webhooks that can't be delivered to.
+func (w Webhook) Validate() error {
+	if w.Name == "" {
+		return fmt.Errorf("webhook needs a name")
+	}
+	if !strings.HasPrefix(w.URL, "http://") && !strings.HasPrefix(w.URL, "https://") {
+		return fmt.Errorf("webhook %s needs an http(s) url", w.Name)
+	}
+	if w.MaxRetries < -1 || w.Timeout < 0 {
+		return fmt.Errorf("webhook %s: maxRetries and timeout must not be negative", w.Name)
+	}
+	return nil
+}
+
+// LoadWebhooks reads webhook definitions from a JSON file. A missing file means none.
+func LoadWebhooks(path string) ([]Webhook, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var webhooks []Webhook
+	if err := json.Unmarshal(data, &webhooks); err != nil {
+		return nil, fmt.Errorf("invalid webhook config: %w", err)
+	}
+	seen := make(map[string]bool, len(webhooks))
+	for _, webhook := range webhooks {
+		if err := webhook.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid webhook config: %w", err)
+		}
+		if seen[webhook.Name] {
+			return nil, fmt.Errorf("invalid webhook config: duplicate name %s", webhook.Name)
+		}
+		seen[webhook.Name] = true
+	}
+	return webhooks, nil
+}
+
+// Payload is the JSON body POSTed to webhooks.
+type Payload struct {
+	ID    string      `json:"id"` // Same for every attempt, so receivers can drop duplicates
+	Event string      `json:"event"`
+	Level string      `json:"level"`
+	Time  time.Time   `json:"time"`
+	Data  interface{} `json:"data"`
+}
+
+// Delivery records the outcome of sending one event to one webhook.
+type Delivery struct {
+	ID         string    `json:"id"`
+	Webhook    string    `json:"webhook"`
+	Event      string    `json:"event"`
+	Time       time.Time `json:"time"`   // When the last attempt finished
+	Status     string    `json:"status"` // delivered|failed|dropped
+	Attempts   int       `json:"attempts"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"durationMs"`
+}
+
+// Notifier forwards logged events to webhooks. Each webhook has its own queue,
+// so a slow endpoint doesn't hold up the others.
+type Notifier struct {
+	webhooks    []Webhook
+	client      *http.Client
+	retryDelay  time.Duration // First backoff; doubles with each retry
+	queues      map[string]chan Payload
+	deliveryLog io.Writer
+
+	mutex      sync.Mutex
+	deliveries []Delivery
+}
+
+// New creates a notifier for webhooks. Deliveries are also appended to
+// deliveryLog as JSON lines when it isn't nil.
+func New(webhooks []Webhook, client *http.Client, deliveryLog io.Writer) *Notifier {
+	n := &Notifier{
+		webhooks:    webhooks,
+		client:      client,
+		retryDelay:  time.Second,
+		queues:      make(map[string]chan Payload, len(webhooks)),
+		deliveryLog: deliveryLog,
+	}
+	for _, webhook := range webhooks {
+		n.queues[webhook.Name] = make(chan Payload, queueSize)
+	}
+	return n
+}
+
+// Run subscribes to the broadcaster and delivers events until ctx is cancelled.
+// Events dropped because delivery fell behind are reported to log.
+func (n *Notifier) Run(ctx context.Context, broadcaster *sse.Broadcaster, log *logger.Logger) {
+	for _, webhook := range n.webhooks {
+		go n.deliverLoop(ctx, webhook)
+	}
+
+	sub := broadcaster.Subscribe(ctx, "webhooks", log)
+	defer sub.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-sub.Events:
+			n.Notify(event)
+		}
+	}
+}
+
+// Notify queues an event for every webhook whose filters it passes.
+func (n *Notifier) Notify(event core.Event) {
+	payload := Payload{
+		ID:    newDeliveryID(),
+		Event: event.Type,
+		Level: event.Level,
+		Time:  event.Time,
+		Data:  event.Data,
+	}
+	for _, webhook := range n.webhooks {
+		if !webhook.Matches(event) {
+			continue
+		}
+		select {
+		case n.queues[webhook.Name] <- payload:
+		default:
+			n.record(Delivery{
+				ID:      payload.ID,
+				Webhook: webhook.Name,
+				Event:   payload.Event,
+				Time:    time.Now(),
+				Status:  "dropped",
+				Error:   "queue full",
+			})
+		}
+	}
+}
+
+// Test sends a webhook_test event to one webhook, bypassing its filters, and waits for the outcome.
+func (n *Notifier) Test(ctx context.Context, name string) (Delivery, error) {
+	for _, webhook := range n.webhooks {
+		if webhook.Name == name {
+			return n.deliver(ctx, webhook, Payload{
+				ID:    newDeliveryID(),
+				Event: "webhook_test",
+				Level: "INFO",
+				Time:  time.Now(),
+				Data:  map[string]interface{}{"webhook": name},
+			}), nil
+		}
+	}
+	return Delivery{}, fmt.Errorf("webhook not found: %s", name)
+}
+
+// Webhooks returns the configured webhooks with secrets and headers redacted.
+func (n *Notifier) Webhooks() []Webhook {
+	out := make([]Webhook, 0, len(n.webhooks))
+	for _, webhook := range n.webhooks {
+		if webhook.Secret != "" {
+			webhook.Secret = "********"
+		}
+		if len(webhook.Headers) > 0 {
+			redacted := make(map[string]string, len(webhook.Headers))
+			for key := range webhook.Headers {
+				redacted[key] = "********"
+			}
+			webhook.Headers = redacted
+		}
+		out = append(out, webhook)
+	}
+	return out
+}
+
+// Deliveries returns recent deliveries, newest first.
+func (n *Notifier) Deliveries() []Delivery {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	out := make([]Delivery, len(n.deliveries))
+	for i, delivery := range n.deliveries {
+		out[len(out)-1-i] = delivery
+	}
+	return out
+}
+
+func (n *Notifier) deliverLoop(ctx context.Context, webhook Webhook) {
+	queue := n.queues[webhook.Name]
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case payload := <-queue:
+			n.deliver(ctx, webhook, payload)
+		}
+	}
+}
+
+// deliver POSTs a payload, retrying network errors, 429s and 5xx replies with
+// exponential backoff. Other replies are final.
+func (n *Notifier) deliver(ctx context.Context, webhook Webhook, payload Payload) Delivery {
+	body, _ := json.Marshal(payload)
+	maxRetries := webhook.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = 3
+	}
+	timeout := time.Duration(webhook.Timeout)
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+
+	started := time.Now()
+	delivery := Delivery{ID: payload.ID, Webhook: webhook.Name, Event: payload.Event}
+	delay := n.retryDelay
+	for {
+		delivery.Attempts++
+		code, err := n.post(ctx, webhook, payload, body, timeout)
+		delivery.StatusCode = code
+		delivery.Error = ""
+		if err != nil {
+			delivery.Error = err.Error()
+		}
+
+		retryable := err != nil && (code == 0 || code == http.StatusTooManyRequests || code >= 500)
+		if err == nil || !retryable || delivery.Attempts > maxRetries || ctx.Err() != nil {
+			break
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+		}
+		delay = min(delay*2, maxRetryDelay)
+	}
+
+	delivery.Status = "delivered"
+	if delivery.Error != "" {
+		delivery.Status = "failed"
+	}
+	delivery.Time = time.Now()
+	delivery.DurationMs = delivery.Time.Sub(started).Milliseconds()
+	n.record(delivery)
+	return delivery
+}
+
+// post makes one delivery attempt and returns the reply's status code, if any.
+func (n *Notifier) post(ctx context.Context, webhook Webhook, payload Payload, body []byte, timeout time.Duration) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "service-watch")
+	req.Header.Set("X-ServiceWatch-Event", payload.Event)
+	req.Header.Set("X-ServiceWatch-Delivery", payload.ID)
+	req.Header.Set("X-ServiceWatch-Timestamp", timestamp)
+	if webhook.Secret != "" {
+		req.Header.Set("X-ServiceWatch-Signature", "sha256="+Sign(webhook.Secret, timestamp, body))
+	}
+	for key, value := range webhook.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// record adds a delivery to the in-memory history and the delivery log.
+func (n *Notifier) record(delivery Delivery) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	n.deliveries = append(n.deliveries, delivery)
+	if len(n.deliveries) > maxDeliveries {
+		n.deliveries = n.deliveries[len(n.deliveries)-maxDeliveries:]
+	}
+	if n.deliveryLog != nil {
+		json.NewEncoder(n.deliveryLog).Encode(delivery)
+	}
+}
+
+// Sign returns the hex HMAC-SHA256 of "timestamp.body" under secret, as sent in
+// X-ServiceWatch-Signature. Receivers recompute it to verify a delivery.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// matchAny reports whether value equals one of patterns, where a trailing "*" matches any suffix.
+func matchAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(value, prefix) {
+				return true
+			}
+		} else if pattern == value {
+			return true
+		}
+	}
+	return false
+}
+
+func newDeliveryID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,169 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+)
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		secret, timestamp, body string
+		want                    string
+	}{
+		{"shh", "1700000000", `{"event":"service_failed"}`, "ef4bc9b6aa02251f7e78add3c5e7dde75bb6f90a8e9db4c66a2f9ab25b7cfdd2"},
+		{"", "0", "", "b849d5a581847b281957065739df36df2463d1977ea8d6e1e4e6cf33fadc68c3"},
+	}
+	for _, tt := range tests {
+		if got := Sign(tt.secret, tt.timestamp, []byte(tt.body)); got != tt.want {
+			t.Errorf("Sign(%q, %q, %q) = %s, want %s", tt.secret, tt.timestamp, tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestFilterMatches(t *testing.T) {
+	event := core.Event{
+		Type:  "restart_failed",
+		Level: "ERROR",
+		Data:  map[string]interface{}{"serviceName": "nginx"},
+	}
+	tests := []struct {
+		name   string
+		filter Filter
+		event  core.Event
+		want   bool
+	}{
+		{name: "empty", filter: Filter{}, event: event, want: true},
+		{name: "event", filter: Filter{Events: []string{"service_failed", "restart_failed"}}, event: event, want: true},
+		{name: "other event", filter: Filter{Events: []string{"service_failed"}}, event: event, want: false},
+		{name: "event prefix", filter: Filter{Events: []string{"restart_*"}}, event: event, want: true},
+		{name: "prefix is not a substring", filter: Filter{Events: []string{"failed*"}}, event: event, want: false},
+		{name: "level ignores case", filter: Filter{Levels: []string{"error"}}, event: event, want: true},
+		{name: "other level", filter: Filter{Levels: []string{"INFO"}}, event: event, want: false},
+		{name: "service", filter: Filter{Services: []string{"nginx"}}, event: event, want: true},
+		{name: "service prefix", filter: Filter{Services: []string{"ng*"}}, event: event, want: true},
+		{name: "other service", filter: Filter{Services: []string{"mysql"}}, event: event, want: false},
+		{name: "event without a service", filter: Filter{Services: []string{"nginx"}}, event: core.Event{Type: "host_resources", Level: "INFO"}, want: false},
+		{name: "all must match", filter: Filter{Events: []string{"restart_*"}, Levels: []string{"INFO"}, Services: []string{"nginx"}}, event: event, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(tt.event); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// receiver is a webhook endpoint that answers with the given status codes in
+// turn, the last one repeating, and records the requests it got.
+type receiver struct {
+	mu       sync.Mutex
+	codes    []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.requests = append(rc.requests, r)
+	rc.bodies = append(rc.bodies, body)
+	code := rc.codes[0]
+	if len(rc.codes) > 1 {
+		rc.codes = rc.codes[1:]
+	}
+	w.WriteHeader(code)
+}
+
+func TestDeliverRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		codes        []int
+		maxRetries   int
+		wantStatus   string
+		wantAttempts int
+		wantCode     int
+	}{
+		{name: "first try", codes: []int{204}, wantStatus: "delivered", wantAttempts: 1, wantCode: 204},
+		{name: "retried until delivered", codes: []int{503, 500, 200}, wantStatus: "delivered", wantAttempts: 3, wantCode: 200},
+		{name: "rate limited", codes: []int{429, 200}, wantStatus: "delivered", wantAttempts: 2, wantCode: 200},
+		{name: "retries run out", codes: []int{502}, maxRetries: 1, wantStatus: "failed", wantAttempts: 2, wantCode: 502},
+		{name: "default retries", codes: []int{500}, wantStatus: "failed", wantAttempts: 4, wantCode: 500},
+		{name: "no retries", codes: []int{500}, maxRetries: -1, wantStatus: "failed", wantAttempts: 1, wantCode: 500},
+		{name: "client error is final", codes: []int{400, 200}, wantStatus: "failed", wantAttempts: 1, wantCode: 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := &receiver{codes: tt.codes}
+			srv := httptest.NewServer(rc)
+			defer srv.Close()
+
+			webhook := Webhook{Name: "hook", URL: srv.URL, Secret: "shh", MaxRetries: tt.maxRetries, Headers: map[string]string{"Authorization": "Bearer token"}}
+			var log strings.Builder
+			n := New([]Webhook{webhook}, srv.Client(), &log)
+			n.retryDelay = time.Millisecond
+
+			payload := Payload{ID: "abc", Event: "service_failed", Level: "ERROR", Time: time.Now(), Data: map[string]interface{}{"serviceName": "nginx"}}
+			delivery := n.deliver(context.Background(), webhook, payload)
+			if delivery.Status != tt.wantStatus || delivery.Attempts != tt.wantAttempts || delivery.StatusCode != tt.wantCode {
+				t.Errorf("delivery = %+v, want %s after %d attempts with %d", delivery, tt.wantStatus, tt.wantAttempts, tt.wantCode)
+			}
+			if len(rc.requests) != tt.wantAttempts {
+				t.Fatalf("receiver got %d requests, want %d", len(rc.requests), tt.wantAttempts)
+			}
+
+			for i, r := range rc.requests {
+				if id := r.Header.Get("X-ServiceWatch-Delivery"); id != "abc" {
+					t.Errorf("attempt %d delivery ID = %q, want the same for every attempt", i+1, id)
+				}
+				want := "sha256=" + Sign("shh", r.Header.Get("X-ServiceWatch-Timestamp"), rc.bodies[i])
+				if got := r.Header.Get("X-ServiceWatch-Signature"); got != want {
+					t.Errorf("attempt %d signature = %q, want %q", i+1, got, want)
+				}
+				if r.Header.Get("Authorization") != "Bearer token" || r.Header.Get("X-ServiceWatch-Event") != "service_failed" {
+					t.Errorf("attempt %d headers = %v", i+1, r.Header)
+				}
+			}
+
+			var logged Delivery
+			if err := json.Unmarshal([]byte(log.String()), &logged); err != nil || logged.Status != tt.wantStatus {
+				t.Errorf("delivery log = %q (%v)", log.String(), err)
+			}
+			if recent := n.Deliveries(); len(recent) != 1 || recent[0].ID != "abc" {
+				t.Errorf("Deliveries() = %+v", recent)
+			}
+		})
+	}
+}
+
+func TestNotifyDropsWhenQueueIsFull(t *testing.T) {
+	n := New([]Webhook{
+		{Name: "errors", URL: "http://localhost", Filter: Filter{Levels: []string{"ERROR"}}},
+		{Name: "all", URL: "http://localhost"},
+	}, http.DefaultClient, nil)
+
+	// Nothing is delivering, so the queues fill up
+	for i := 0; i < queueSize+2; i++ {
+		n.Notify(core.Event{Type: "service_status", Level: "INFO", Time: time.Now()})
+	}
+	if got := len(n.queues["errors"]); got != 0 {
+		t.Errorf("%d INFO events queued for an ERROR-only webhook", got)
+	}
+	if got := len(n.queues["all"]); got != queueSize {
+		t.Errorf("%d events queued, want %d", got, queueSize)
+	}
+	dropped := n.Deliveries()
+	if len(dropped) != 2 || dropped[0].Status != "dropped" || dropped[0].Webhook != "all" {
+		t.Errorf("Deliveries() = %+v, want two drops for all", dropped)
+	}
+}
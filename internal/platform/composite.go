@@ -0,0 +1,164 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+)
+
+// Backend is a named ServiceManager combined into a Composite.
+type Backend struct {
+	Name    string
+	Manager core.ServiceManager
+}
+
+// Composite is a ServiceManager that multiplexes several backends.
+// Services are addressed as "<backend>:<name>", e.g. "systemd:nginx.service" or "docker:redis".
+type Composite struct {
+	backends []Backend
+	byName   map[string]core.ServiceManager
+}
+
+// NewComposite combines backends under their names.
+func NewComposite(backends ...Backend) *Composite {
+	c := &Composite{
+		backends: backends,
+		byName:   make(map[string]core.ServiceManager, len(backends)),
+	}
+	for _, b := range backends {
+		c.byName[b.Name] = b.Manager
+	}
+	return c
+}
+
+// List implements core.ServiceManager.
+// A backend that fails to list is skipped so one unreachable daemon doesn't hide the rest.
+func (c *Composite) List(ctx context.Context) ([]core.Service, error) {
+	var out []core.Service
+	var errs []error
+	for _, b := range c.backends {
+		services, err := b.Manager.List(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", b.Name, err))
+			continue
+		}
+		for _, svc := range services {
+			out = append(out, qualify(b.Name, svc))
+		}
+	}
+	if len(errs) == len(c.backends) && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return out, nil
+}
+
+// Get implements core.ServiceManager.
+func (c *Composite) Get(ctx context.Context, name string) (core.Service, error) {
+	backend, mgr, svcName, err := c.route(name)
+	if err != nil {
+		return core.Service{}, err
+	}
+	svc, err := mgr.Get(ctx, svcName)
+	if err != nil {
+		return core.Service{}, err
+	}
+	return qualify(backend, svc), nil
+}
+
+// Start implements core.ServiceManager.
+func (c *Composite) Start(ctx context.Context, name string) error {
+	_, mgr, svcName, err := c.route(name)
+	if err != nil {
+		return err
+	}
+	return mgr.Start(ctx, svcName)
+}
+
+// Stop implements core.ServiceManager.
+func (c *Composite) Stop(ctx context.Context, name string) error {
+	_, mgr, svcName, err := c.route(name)
+	if err != nil {
+		return err
+	}
+	return mgr.Stop(ctx, svcName)
+}
+
+// Restart implements core.ServiceManager.
+func (c *Composite) Restart(ctx context.Context, name string) error {
+	_, mgr, svcName, err := c.route(name)
+	if err != nil {
+		return err
+	}
+	return mgr.Restart(ctx, svcName)
+}
+
+// Watch implements core.StateWatcher by merging the streams of backends that support it.
+// Backends without push support are left to the caller's polling.
+func (c *Composite) Watch(ctx context.Context) <-chan core.StateChange {
+	out := make(chan core.StateChange, 16)
+	var wg sync.WaitGroup
+	for _, b := range c.backends {
+		sw, ok := b.Manager.(core.StateWatcher)
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func(name string, changes <-chan core.StateChange) {
+			defer wg.Done()
+			for change := range changes {
+				if change.Error != "" {
+					change.Error = name + ": " + change.Error
+				} else {
+					change.Name = name + ":" + change.Name
+				}
+				select {
+				case out <- change:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(b.Name, sw.Watch(ctx))
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// NormalizeName implements core.NameNormalizer for backends that support it.
+func (c *Composite) NormalizeName(name string) string {
+	backend, mgr, svcName, err := c.route(name)
+	if err != nil {
+		return name
+	}
+	if n, ok := mgr.(core.NameNormalizer); ok {
+		svcName = n.NormalizeName(svcName)
+	}
+	return backend + ":" + svcName
+}
+
+// route splits a namespaced service name and finds the backend that owns it.
+func (c *Composite) route(name string) (string, core.ServiceManager, string, error) {
+	backend, svcName, ok := strings.Cut(name, ":")
+	if !ok {
+		return "", nil, "", fmt.Errorf("service name must be prefixed with a backend, e.g. %s:%s", c.backends[0].Name, name)
+	}
+	mgr, exists := c.byName[backend]
+	if !exists {
+		return "", nil, "", fmt.Errorf("unknown backend: %s", backend)
+	}
+	return backend, mgr, svcName, nil
+}
+
+// qualify prefixes a backend's service with the backend name.
+func qualify(backend string, svc core.Service) core.Service {
+	svc.Name = backend + ":" + svc.Name
+	svc.Backend = backend
+	return svc
+}
@@ -0,0 +1,163 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+)
+
+// fakeBackend is a ServiceManager over a fixed set of services that records the
+// actions it's asked to perform.
+type fakeBackend struct {
+	services []string
+	listErr  error
+	actions  []string
+}
+
+func (f *fakeBackend) List(ctx context.Context) ([]core.Service, error) {
+	if f.listErr != nil {
+		return nil, f.listErr
+	}
+	var out []core.Service
+	for _, name := range f.services {
+		out = append(out, core.Service{Name: name, State: "running"})
+	}
+	return out, nil
+}
+
+func (f *fakeBackend) Get(ctx context.Context, name string) (core.Service, error) {
+	for _, svc := range f.services {
+		if svc == name {
+			return core.Service{Name: name, State: "running"}, nil
+		}
+	}
+	return core.Service{}, fmt.Errorf("service not found: %s", name)
+}
+
+func (f *fakeBackend) act(action, name string) error {
+	if _, err := f.Get(context.Background(), name); err != nil {
+		return err
+	}
+	f.actions = append(f.actions, action+" "+name)
+	return nil
+}
+
+func (f *fakeBackend) Start(ctx context.Context, name string) error   { return f.act("start", name) }
+func (f *fakeBackend) Stop(ctx context.Context, name string) error    { return f.act("stop", name) }
+func (f *fakeBackend) Restart(ctx context.Context, name string) error { return f.act("restart", name) }
+
+func TestCompositeRouting(t *testing.T) {
+	systemd := &fakeBackend{services: []string{"nginx.service"}}
+	docker := &fakeBackend{services: []string{"redis", "nginx.service"}}
+	c := NewComposite(Backend{"systemd", systemd}, Backend{"docker", docker})
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		service string
+		backend *fakeBackend
+		wantErr string
+	}{
+		{name: "prefixed", service: "systemd:nginx.service", backend: systemd},
+		{name: "same name on another backend", service: "docker:nginx.service", backend: docker},
+		{name: "second backend", service: "docker:redis", backend: docker},
+		{name: "unprefixed", service: "nginx.service", wantErr: "service name must be prefixed with a backend, e.g. systemd:nginx.service"},
+		{name: "unknown backend", service: "podman:redis", wantErr: "unknown backend: podman"},
+		{name: "unknown service", service: "docker:postgres", wantErr: "service not found: postgres"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			systemd.actions, docker.actions = nil, nil
+
+			svc, err := c.Get(ctx, tt.service)
+			errs := []error{err}
+			for _, action := range []func(context.Context, string) error{c.Start, c.Stop, c.Restart} {
+				errs = append(errs, action(ctx, tt.service))
+			}
+			for _, err := range errs {
+				if tt.wantErr == "" && err != nil {
+					t.Fatalf("err = %v", err)
+				}
+				if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+			}
+			if tt.wantErr != "" {
+				if len(systemd.actions)+len(docker.actions) != 0 {
+					t.Errorf("actions reached a backend: %v %v", systemd.actions, docker.actions)
+				}
+				return
+			}
+
+			backend, bare, _ := strings.Cut(tt.service, ":")
+			if svc.Name != tt.service || svc.Backend != backend {
+				t.Errorf("Get = %+v, want name %s on %s", svc, tt.service, backend)
+			}
+			want := []string{"start " + bare, "stop " + bare, "restart " + bare}
+			if !reflect.DeepEqual(tt.backend.actions, want) {
+				t.Errorf("actions = %v, want %v", tt.backend.actions, want)
+			}
+		})
+	}
+}
+
+func TestCompositeList(t *testing.T) {
+	unreachable := errors.New("connection refused")
+	tests := []struct {
+		name    string
+		systemd *fakeBackend
+		docker  *fakeBackend
+		want    []string
+		wantErr string
+	}{
+		{
+			name:    "all backends",
+			systemd: &fakeBackend{services: []string{"nginx.service"}},
+			docker:  &fakeBackend{services: []string{"redis"}},
+			want:    []string{"systemd:nginx.service", "docker:redis"},
+		},
+		{
+			// One unreachable daemon doesn't hide the rest
+			name:    "one backend fails",
+			systemd: &fakeBackend{services: []string{"nginx.service"}},
+			docker:  &fakeBackend{listErr: unreachable},
+			want:    []string{"systemd:nginx.service"},
+		},
+		{
+			name:    "every backend fails",
+			systemd: &fakeBackend{listErr: errors.New("no bus")},
+			docker:  &fakeBackend{listErr: unreachable},
+			wantErr: "systemd: no bus\ndocker: connection refused",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewComposite(Backend{"systemd", tt.systemd}, Backend{"docker", tt.docker})
+			services, err := c.List(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("err = %v, want %q", err, tt.wantErr)
+				}
+				if !errors.Is(err, unreachable) {
+					t.Error("backend error not wrapped")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, svc := range services {
+				names = append(names, svc.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("services = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
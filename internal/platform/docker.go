@@ -0,0 +1,360 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+)
+
+// DefaultDockerHost is the Docker Engine socket on Linux hosts.
+const DefaultDockerHost = "unix:///var/run/docker.sock"
+
+// dockerRequestTimeout bounds each API call, so a hung daemon can't stall callers.
+// It leaves room for a stop, which waits up to 10s for the container to exit.
+const dockerRequestTimeout = 30 * time.Second
+
+var errContainerNotFound = errors.New("container not found")
+
+// Docker is a ServiceManager that treats containers as services,
+// speaking the Docker Engine HTTP API.
+type Docker struct {
+	client  *http.Client
+	baseURL string
+	timeout time.Duration // Deadline for each request other than the event stream
+}
+
+// NewDocker creates a Docker manager for a host like "unix:///var/run/docker.sock" or "tcp://127.0.0.1:2375".
+func NewDocker(host string) (*Docker, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid docker host: %w", err)
+	}
+
+	switch u.Scheme {
+	case "unix":
+		socketPath := u.Path
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socketPath)
+			},
+		}
+		// The host part is ignored when dialing the socket
+		return NewDockerWithClient(&http.Client{Transport: transport}, "http://docker"), nil
+	case "tcp", "http":
+		return NewDockerWithClient(&http.Client{}, "http://"+u.Host), nil
+	default:
+		return nil, fmt.Errorf("unsupported docker host scheme: %s", u.Scheme)
+	}
+}
+
+// NewDockerWithClient creates a Docker manager that sends requests to baseURL using client.
+func NewDockerWithClient(client *http.Client, baseURL string) *Docker {
+	return &Docker{client: client, baseURL: strings.TrimRight(baseURL, "/"), timeout: dockerRequestTimeout}
+}
+
+type dockerContainerSummary struct {
+	ID    string   `json:"Id"`
+	Names []string `json:"Names"`
+	Image string   `json:"Image"`
+	State string   `json:"State"`
+}
+
+type dockerContainer struct {
+	ID    string `json:"Id"`
+	Name  string `json:"Name"`
+	State struct {
+		Status    string `json:"Status"`
+		Running   bool   `json:"Running"`
+		Pid       int    `json:"Pid"`
+		StartedAt string `json:"StartedAt"`
+	} `json:"State"`
+	Config struct {
+		Image string `json:"Image"`
+	} `json:"Config"`
+	HostConfig struct {
+		RestartPolicy struct {
+			Name string `json:"Name"`
+		} `json:"RestartPolicy"`
+	} `json:"HostConfig"`
+}
+
+type dockerStats struct {
+	CPUStats    dockerCPUStats `json:"cpu_stats"`
+	PreCPUStats dockerCPUStats `json:"precpu_stats"`
+	MemoryStats struct {
+		Usage uint64            `json:"usage"`
+		Stats map[string]uint64 `json:"stats"`
+	} `json:"memory_stats"`
+}
+
+type dockerCPUStats struct {
+	CPUUsage struct {
+		TotalUsage  uint64   `json:"total_usage"`
+		PercpuUsage []uint64 `json:"percpu_usage"`
+	} `json:"cpu_usage"`
+	SystemUsage uint64 `json:"system_cpu_usage"`
+	OnlineCPUs  uint32 `json:"online_cpus"`
+}
+
+// List implements core.ServiceManager.
+func (d *Docker) List(ctx context.Context) ([]core.Service, error) {
+	var containers []dockerContainerSummary
+	if err := d.do(ctx, http.MethodGet, "/containers/json?all=1", &containers); err != nil {
+		return nil, err
+	}
+
+	out := make([]core.Service, 0, len(containers))
+	for _, c := range containers {
+		name := c.ID
+		if len(c.Names) > 0 {
+			name = strings.TrimPrefix(c.Names[0], "/")
+		}
+		out = append(out, core.Service{
+			Name:        name,
+			DisplayName: c.Image,
+			State:       containerStateToString(c.State),
+		})
+	}
+	return out, nil
+}
+
+// Get implements core.ServiceManager.
+func (d *Docker) Get(ctx context.Context, name string) (core.Service, error) {
+	var c dockerContainer
+	if err := d.container(ctx, http.MethodGet, name, "json", &c); err != nil {
+		return core.Service{}, err
+	}
+
+	svcData := core.Service{
+		Name:        strings.TrimPrefix(c.Name, "/"),
+		DisplayName: c.Config.Image,
+		State:       containerStateToString(c.State.Status),
+		StartType:   restartPolicyToString(c.HostConfig.RestartPolicy.Name),
+		CanStop:     c.State.Running,
+		PID:         c.State.Pid,
+	}
+
+	// Get resource usage if container is running
+	if c.State.Running {
+		var stats dockerStats
+		if err := d.container(ctx, http.MethodGet, name, "stats?stream=false", &stats); err == nil {
+			svcData.CPUPercent = containerCPUPercent(stats)
+			svcData.MemoryMB = float64(containerMemoryBytes(stats)) / 1024 / 1024 // Convert bytes to MB
+		}
+
+		if startedAt, err := time.Parse(time.RFC3339Nano, c.State.StartedAt); err == nil {
+			svcData.UptimeSeconds = int64(time.Since(startedAt).Seconds())
+		}
+	}
+
+	return svcData, nil
+}
+
+// Start implements core.ServiceManager.
+func (d *Docker) Start(ctx context.Context, name string) error {
+	return d.container(ctx, http.MethodPost, name, "start", nil)
+}
+
+// Stop implements core.ServiceManager.
+func (d *Docker) Stop(ctx context.Context, name string) error {
+	return d.container(ctx, http.MethodPost, name, "stop", nil)
+}
+
+// Restart implements core.ServiceManager.
+func (d *Docker) Restart(ctx context.Context, name string) error {
+	return d.container(ctx, http.MethodPost, name, "restart", nil)
+}
+
+type dockerEvent struct {
+	Action string `json:"Action"`
+	Actor  struct {
+		Attributes map[string]string `json:"Attributes"`
+	} `json:"Actor"`
+	TimeNano int64 `json:"timeNano"`
+}
+
+// Watch implements core.StateWatcher using the Engine's event stream.
+// The stream is re-established if the daemon drops it, until ctx is cancelled;
+// each failure is reported on the channel.
+func (d *Docker) Watch(ctx context.Context) <-chan core.StateChange {
+	ch := make(chan core.StateChange, 16)
+	go func() {
+		defer close(ch)
+		for {
+			err := d.streamEvents(ctx, ch)
+			if ctx.Err() != nil {
+				return
+			}
+			select {
+			case ch <- core.StateChange{Error: fmt.Sprintf("docker: event stream failed: %v", err), Time: time.Now()}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(5 * time.Second):
+			}
+		}
+	}()
+	return ch
+}
+
+func (d *Docker) streamEvents(ctx context.Context, ch chan<- core.StateChange) error {
+	filters := url.QueryEscape(`{"type":["container"]}`)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.baseURL+"/events?filters="+filters, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("docker: %s", resp.Status)
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var event dockerEvent
+		if err := decoder.Decode(&event); err != nil {
+			return err
+		}
+
+		var state string
+		switch event.Action {
+		case "start", "unpause":
+			state = "running"
+		case "die":
+			state = "stopped"
+		case "pause":
+			state = "paused"
+		default:
+			continue
+		}
+
+		select {
+		case ch <- core.StateChange{
+			Name:  event.Actor.Attributes["name"],
+			State: state,
+			Time:  time.Unix(0, event.TimeNano),
+		}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// container sends a request for one container's endpoint, reporting unknown containers by name.
+func (d *Docker) container(ctx context.Context, method, name, endpoint string, out interface{}) error {
+	err := d.do(ctx, method, "/containers/"+url.PathEscape(name)+"/"+endpoint, out)
+	if errors.Is(err, errContainerNotFound) {
+		return fmt.Errorf("service not found: %s", name)
+	}
+	return err
+}
+
+// do sends a request to the Engine API and decodes a JSON reply into out when out is non-nil.
+func (d *Docker) do(ctx context.Context, method, path string, out interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, d.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		// Container already in the requested state
+		return nil
+	case resp.StatusCode == http.StatusNotFound:
+		return errContainerNotFound
+	case resp.StatusCode >= 300:
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("docker: %s", apiErr.Message)
+		}
+		return fmt.Errorf("docker: %s", resp.Status)
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func containerStateToString(state string) string {
+	switch state {
+	case "running":
+		return "running"
+	case "created", "exited", "dead":
+		return "stopped"
+	case "restarting":
+		return "start_pending"
+	case "removing":
+		return "stop_pending"
+	case "paused":
+		return "paused"
+	default:
+		return "unknown"
+	}
+}
+
+func restartPolicyToString(policy string) string {
+	switch policy {
+	case "always", "unless-stopped", "on-failure":
+		return "auto"
+	case "", "no":
+		return "manual"
+	default:
+		return "unknown"
+	}
+}
+
+// containerCPUPercent uses the same formula as `docker stats`.
+func containerCPUPercent(stats dockerStats) float64 {
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemUsage) - float64(stats.PreCPUStats.SystemUsage)
+	if cpuDelta <= 0 || systemDelta <= 0 {
+		return 0
+	}
+	cpus := float64(stats.CPUStats.OnlineCPUs)
+	if cpus == 0 {
+		cpus = float64(len(stats.CPUStats.CPUUsage.PercpuUsage))
+	}
+	return cpuDelta / systemDelta * cpus * 100
+}
+
+// containerMemoryBytes excludes page cache, as `docker stats` does.
+func containerMemoryBytes(stats dockerStats) uint64 {
+	usage := stats.MemoryStats.Usage
+	cache, ok := stats.MemoryStats.Stats["inactive_file"] // cgroup v2
+	if !ok {
+		cache = stats.MemoryStats.Stats["total_inactive_file"] // cgroup v1
+	}
+	if cache < usage {
+		return usage - cache
+	}
+	return usage
+}
@@ -0,0 +1,221 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+)
+
+// fakeEngine serves the parts of the Docker Engine API the backend uses.
+func fakeEngine(t *testing.T) (*Docker, *[]string) {
+	t.Helper()
+	var mu sync.Mutex
+	var actions []string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /containers/json", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("all") != "1" {
+			t.Errorf("List didn't ask for stopped containers: %s", r.URL)
+		}
+		json.NewEncoder(w).Encode([]map[string]interface{}{
+			{"Id": "abc123", "Names": []string{"/web"}, "Image": "nginx:1.27", "State": "running"},
+			{"Id": "def456", "Names": []string{"/worker"}, "Image": "worker", "State": "exited"},
+			{"Id": "0123456789", "Image": "scratch", "State": "restarting"},
+		})
+	})
+	mux.HandleFunc("GET /containers/web/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+			"Id": "abc123",
+			"Name": "/web",
+			"State": {"Status": "running", "Running": true, "Pid": 4242, "StartedAt": "` + time.Now().Add(-time.Hour).Format(time.RFC3339Nano) + `"},
+			"Config": {"Image": "nginx:1.27"},
+			"HostConfig": {"RestartPolicy": {"Name": "unless-stopped"}}
+		}`))
+	})
+	mux.HandleFunc("GET /containers/{name}/json", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "No such container: ` + r.PathValue("name") + `"}`))
+	})
+	mux.HandleFunc("GET /containers/web/stats", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("stream") != "false" {
+			t.Errorf("stats requested as a stream: %s", r.URL)
+		}
+		w.Write([]byte(`{
+			"cpu_stats": {"cpu_usage": {"total_usage": 300}, "system_cpu_usage": 2000, "online_cpus": 4},
+			"precpu_stats": {"cpu_usage": {"total_usage": 100}, "system_cpu_usage": 1000},
+			"memory_stats": {"usage": 73400320, "stats": {"inactive_file": 10485760}}
+		}`))
+	})
+	mux.HandleFunc("POST /containers/{name}/{action}", func(w http.ResponseWriter, r *http.Request) {
+		name, action := r.PathValue("name"), r.PathValue("action")
+		switch {
+		case name == "missing":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "No such container: missing"}`))
+			return
+		case name == "broken":
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"message": "cannot start container: port is already allocated"}`))
+			return
+		case name == "web" && action == "start":
+			w.WriteHeader(http.StatusNotModified) // Already running
+			return
+		}
+		mu.Lock()
+		actions = append(actions, action+" "+name)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return NewDockerWithClient(srv.Client(), srv.URL), &actions
+}
+
+func TestDockerList(t *testing.T) {
+	d, _ := fakeEngine(t)
+	services, err := d.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct{ name, image, state string }{
+		{"web", "nginx:1.27", "running"},
+		{"worker", "worker", "stopped"},
+		{"0123456789", "scratch", "start_pending"},
+	}
+	if len(services) != len(want) {
+		t.Fatalf("List() returned %d services, want %d", len(services), len(want))
+	}
+	for i, w := range want {
+		if got := services[i]; got.Name != w.name || got.DisplayName != w.image || got.State != w.state {
+			t.Errorf("services[%d] = %+v, want %+v", i, got, w)
+		}
+	}
+}
+
+func TestDockerGetWithStats(t *testing.T) {
+	d, _ := fakeEngine(t)
+	svc, err := d.Get(context.Background(), "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if svc.Name != "web" || svc.State != "running" || svc.StartType != "auto" || svc.PID != 4242 || !svc.CanStop {
+		t.Errorf("Get(web) = %+v", svc)
+	}
+	// 200 of 1000 system ticks across 4 CPUs
+	if svc.CPUPercent != 80 {
+		t.Errorf("CPUPercent = %v, want 80", svc.CPUPercent)
+	}
+	// 70MB used less 10MB of inactive page cache
+	if svc.MemoryMB != 60 {
+		t.Errorf("MemoryMB = %v, want 60", svc.MemoryMB)
+	}
+	if math.Abs(float64(svc.UptimeSeconds)-3600) > 5 {
+		t.Errorf("UptimeSeconds = %d, want about 3600", svc.UptimeSeconds)
+	}
+
+	if _, err := d.Get(context.Background(), "nope"); err == nil || err.Error() != "service not found: nope" {
+		t.Errorf("Get(nope) error = %v, want service not found", err)
+	}
+}
+
+func TestDockerActions(t *testing.T) {
+	tests := []struct {
+		name    string
+		action  func(d *Docker) error
+		wantErr string
+		want    string // Request the engine should have acted on
+	}{
+		{name: "start", action: func(d *Docker) error { return d.Start(context.Background(), "worker") }, want: "start worker"},
+		{name: "start already running", action: func(d *Docker) error { return d.Start(context.Background(), "web") }},
+		{name: "stop", action: func(d *Docker) error { return d.Stop(context.Background(), "web") }, want: "stop web"},
+		{name: "restart", action: func(d *Docker) error { return d.Restart(context.Background(), "web") }, want: "restart web"},
+		{name: "unknown container", action: func(d *Docker) error { return d.Start(context.Background(), "missing") }, wantErr: "service not found: missing"},
+		{name: "engine error", action: func(d *Docker) error { return d.Start(context.Background(), "broken") }, wantErr: "docker: cannot start container: port is already allocated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, actions := fakeEngine(t)
+			err := tt.action(d)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+			if got := strings.Join(*actions, ","); got != tt.want {
+				t.Errorf("engine saw %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDockerRequestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release // A daemon that never answers
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	d := NewDockerWithClient(srv.Client(), srv.URL)
+	d.timeout = 100 * time.Millisecond
+
+	started := time.Now()
+	if _, err := d.List(context.Background()); err == nil {
+		t.Fatal("List() against a hung daemon succeeded")
+	}
+	if elapsed := time.Since(started); elapsed > 2*time.Second {
+		t.Errorf("List() took %v to give up", elapsed)
+	}
+}
+
+func TestDockerWatchReportsStreamErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/events" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		// One event, then the daemon drops the stream
+		w.Write([]byte(`{"Action": "die", "Actor": {"Attributes": {"name": "web"}}, "timeNano": 1700000000000000000}` + "\n"))
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := NewDockerWithClient(srv.Client(), srv.URL).Watch(ctx)
+
+	want := []core.StateChange{
+		{Name: "web", State: "stopped", Time: time.Unix(0, 1700000000000000000)},
+		{Error: "docker: event stream failed: EOF"},
+	}
+	for _, w := range want {
+		select {
+		case got := <-changes:
+			got.Time = got.Time.Truncate(0)
+			if w.Error != "" {
+				got.Time = time.Time{}
+			}
+			if got != w {
+				t.Errorf("change = %+v, want %+v", got, w)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no change, want %+v", w)
+		}
+	}
+
+	// Cancelling ends the stream without another error
+	cancel()
+	for change := range changes {
+		if change.Error != "" {
+			t.Errorf("error reported after cancel: %s", change.Error)
+		}
+	}
+}
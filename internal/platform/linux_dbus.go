@@ -0,0 +1,222 @@
+//go:build linux
+
+package platform
+
+import (
+	"context"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	systemdDest      = "org.freedesktop.systemd1"
+	systemdPath      = dbus.ObjectPath("/org/freedesktop/systemd1")
+	systemdManager   = "org.freedesktop.systemd1.Manager"
+	systemdUnitIface = "org.freedesktop.systemd1.Unit"
+	systemdSvcIface  = "org.freedesktop.systemd1.Service"
+)
+
+// unitStatus is one row of systemd's ListUnits reply.
+type unitStatus struct {
+	Name        string
+	Description string
+	LoadState   string
+	ActiveState string
+	SubState    string
+}
+
+// systemdConn is the subset of the systemd D-Bus API used by systemdSvc.
+// It is an interface so the manager can be exercised without a system bus.
+type systemdConn interface {
+	// Lists units currently loaded by systemd.
+	ListUnits(ctx context.Context) ([]unitStatus, error)
+	// Lists installed unit files, keyed by unit name, with their UnitFileState.
+	ListUnitFiles(ctx context.Context) (map[string]string, error)
+	// Gets the Unit (and, for services, Service) properties of a unit, loading it if needed.
+	UnitProperties(ctx context.Context, unit string) (map[string]interface{}, error)
+	// Queues a start job for a unit.
+	StartUnit(ctx context.Context, unit string) error
+	// Queues a stop job for a unit.
+	StopUnit(ctx context.Context, unit string) error
+	// Queues a restart job for a unit.
+	RestartUnit(ctx context.Context, unit string) error
+	// Streams ActiveState/SubState changes of units until ctx is cancelled.
+	SubscribeUnits(ctx context.Context) (<-chan unitStatus, error)
+	// Closes the underlying connection.
+	Close() error
+}
+
+// dbusConn talks to systemd over a real D-Bus connection.
+type dbusConn struct {
+	conn *dbus.Conn
+}
+
+func dialSystemBus(ctx context.Context) (systemdConn, error) {
+	conn, err := dbus.ConnectSystemBus(dbus.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return &dbusConn{conn: conn}, nil
+}
+
+func (c *dbusConn) manager() dbus.BusObject {
+	return c.conn.Object(systemdDest, systemdPath)
+}
+
+func (c *dbusConn) ListUnits(ctx context.Context) ([]unitStatus, error) {
+	// a(ssssssouso): name, description, load, active, sub, followed, path, job id, job type, job path
+	var rows [][]interface{}
+	if err := c.manager().CallWithContext(ctx, systemdManager+".ListUnits", 0).Store(&rows); err != nil {
+		return nil, err
+	}
+
+	out := make([]unitStatus, 0, len(rows))
+	for _, row := range rows {
+		if len(row) < 5 {
+			continue
+		}
+		u := unitStatus{}
+		u.Name, _ = row[0].(string)
+		u.Description, _ = row[1].(string)
+		u.LoadState, _ = row[2].(string)
+		u.ActiveState, _ = row[3].(string)
+		u.SubState, _ = row[4].(string)
+		out = append(out, u)
+	}
+	return out, nil
+}
+
+func (c *dbusConn) ListUnitFiles(ctx context.Context) (map[string]string, error) {
+	// a(ss): unit file path, state
+	var rows [][]interface{}
+	if err := c.manager().CallWithContext(ctx, systemdManager+".ListUnitFiles", 0).Store(&rows); err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]string, len(rows))
+	for _, row := range rows {
+		if len(row) < 2 {
+			continue
+		}
+		file, _ := row[0].(string)
+		state, _ := row[1].(string)
+		out[path.Base(file)] = state
+	}
+	return out, nil
+}
+
+func (c *dbusConn) UnitProperties(ctx context.Context, unit string) (map[string]interface{}, error) {
+	var unitPath dbus.ObjectPath
+	if err := c.manager().CallWithContext(ctx, systemdManager+".LoadUnit", 0, unit).Store(&unitPath); err != nil {
+		return nil, err
+	}
+
+	obj := c.conn.Object(systemdDest, unitPath)
+	props := make(map[string]interface{})
+	ifaces := []string{systemdUnitIface}
+	if path.Ext(unit) == ".service" {
+		ifaces = append(ifaces, systemdSvcIface)
+	}
+	for _, iface := range ifaces {
+		var values map[string]dbus.Variant
+		if err := obj.CallWithContext(ctx, "org.freedesktop.DBus.Properties.GetAll", 0, iface).Store(&values); err != nil {
+			return nil, err
+		}
+		for k, v := range values {
+			props[k] = v.Value()
+		}
+	}
+	return props, nil
+}
+
+func (c *dbusConn) StartUnit(ctx context.Context, unit string) error {
+	return c.manager().CallWithContext(ctx, systemdManager+".StartUnit", 0, unit, "replace").Err
+}
+
+func (c *dbusConn) StopUnit(ctx context.Context, unit string) error {
+	return c.manager().CallWithContext(ctx, systemdManager+".StopUnit", 0, unit, "replace").Err
+}
+
+func (c *dbusConn) RestartUnit(ctx context.Context, unit string) error {
+	return c.manager().CallWithContext(ctx, systemdManager+".RestartUnit", 0, unit, "replace").Err
+}
+
+func (c *dbusConn) SubscribeUnits(ctx context.Context) (<-chan unitStatus, error) {
+	// systemd only emits unit signals to clients that have subscribed
+	if err := c.manager().CallWithContext(ctx, systemdManager+".Subscribe", 0).Err; err != nil {
+		return nil, err
+	}
+	err := c.conn.AddMatchSignalContext(ctx,
+		dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
+		dbus.WithMatchMember("PropertiesChanged"),
+		dbus.WithMatchPathNamespace("/org/freedesktop/systemd1/unit"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	signals := make(chan *dbus.Signal, 64)
+	c.conn.Signal(signals)
+
+	out := make(chan unitStatus, 16)
+	go func() {
+		defer close(out)
+		defer c.conn.RemoveSignal(signals)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig, ok := <-signals:
+				if !ok {
+					return
+				}
+				// Body: interface name, changed properties, invalidated properties
+				if len(sig.Body) < 2 {
+					continue
+				}
+				iface, _ := sig.Body[0].(string)
+				changed, _ := sig.Body[1].(map[string]dbus.Variant)
+				active, ok := changed["ActiveState"]
+				if iface != systemdUnitIface || !ok {
+					continue
+				}
+				u := unitStatus{Name: unitNameFromPath(sig.Path)}
+				u.ActiveState, _ = active.Value().(string)
+				if sub, ok := changed["SubState"]; ok {
+					u.SubState, _ = sub.Value().(string)
+				}
+				select {
+				case out <- u:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (c *dbusConn) Close() error {
+	return c.conn.Close()
+}
+
+// unitNameFromPath reverses systemd's object path escaping,
+// e.g. /org/freedesktop/systemd1/unit/nginx_2eservice -> nginx.service.
+func unitNameFromPath(p dbus.ObjectPath) string {
+	escaped := path.Base(string(p))
+	var b strings.Builder
+	for i := 0; i < len(escaped); i++ {
+		if escaped[i] == '_' && i+2 < len(escaped) {
+			if v, err := strconv.ParseUint(escaped[i+1:i+3], 16, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(escaped[i])
+	}
+	return b.String()
+}
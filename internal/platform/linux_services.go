@@ -4,28 +4,305 @@ package platform
 
 import (
 	"context"
-	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
 
 	"github.com/ethan-mdev/service-watch/internal/core"
+	"github.com/shirou/gopsutil/v4/process"
 )
 
-// Linux implementation of ServiceManager
-type stubSvc struct{}
+// OSBackendName names the native backend when several are combined.
+const OSBackendName = "systemd"
 
-func newServiceManager() core.ServiceManager { return &stubSvc{} }
+// Linux implementation of ServiceManager backed by systemd.
+type systemdSvc struct {
+	dial func(ctx context.Context) (systemdConn, error)
+}
+
+func newServiceManager() core.ServiceManager { return newSystemdSvc(dialSystemBus) }
+
+// newSystemdSvc creates a systemd ServiceManager using the given connection factory.
+func newSystemdSvc(dial func(ctx context.Context) (systemdConn, error)) *systemdSvc {
+	return &systemdSvc{dial: dial}
+}
+
+func (s *systemdSvc) List(ctx context.Context) ([]core.Service, error) {
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	units, err := conn.ListUnits(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unit file states aren't part of ListUnits, so fetch them in one call
+	fileStates, err := conn.ListUnitFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]core.Service, 0, len(units))
+	seen := make(map[string]bool, len(units))
+	for _, u := range units {
+		if !strings.HasSuffix(u.Name, ".service") || u.LoadState == "not-found" {
+			continue
+		}
+		seen[u.Name] = true
+		out = append(out, core.Service{
+			Name:        u.Name,
+			DisplayName: u.Description,
+			State:       activeStateToString(u.ActiveState, u.SubState),
+			StartType:   unitFileStateToString(fileStates[u.Name]),
+		})
+	}
+
+	// Installed but never loaded services are only reported by ListUnitFiles
+	for name, state := range fileStates {
+		if seen[name] || !strings.HasSuffix(name, ".service") || strings.Contains(name, "@.") {
+			continue
+		}
+		out = append(out, core.Service{
+			Name:      name,
+			State:     "stopped",
+			StartType: unitFileStateToString(state),
+		})
+	}
+
+	return out, nil
+}
+
+func (s *systemdSvc) Get(ctx context.Context, name string) (core.Service, error) {
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return core.Service{}, err
+	}
+	defer conn.Close()
+
+	unit := unitName(name)
+	props, err := conn.UnitProperties(ctx, unit)
+	if err != nil {
+		return core.Service{}, err
+	}
+	if propString(props, "LoadState") == "not-found" {
+		return core.Service{}, fmt.Errorf("service not found: %s", name)
+	}
+
+	activeState := propString(props, "ActiveState")
+	svcData := core.Service{
+		Name:        unit,
+		DisplayName: propString(props, "Description"),
+		State:       activeStateToString(activeState, propString(props, "SubState")),
+		StartType:   unitFileStateToString(propString(props, "UnitFileState")),
+		CanStop:     propBool(props, "CanStop"),
+		PID:         int(propUint(props, "MainPID")),
+	}
+
+	// Get resource usage if service is running
+	if activeState == "active" && svcData.PID != 0 {
+		if proc, err := process.NewProcess(int32(svcData.PID)); err == nil {
+			// CPU percentage
+			if cpuPercent, err := proc.CPUPercent(); err == nil {
+				svcData.CPUPercent = cpuPercent
+			}
 
-func (s *stubSvc) List(ctx context.Context) ([]core.Service, error) {
-	return nil, errors.New("not implemented on linux")
+			// Memory usage, preferring the cgroup total so child processes are included
+			if memCurrent := propUint(props, "MemoryCurrent"); memCurrent != 0 && memCurrent != math.MaxUint64 {
+				svcData.MemoryMB = float64(memCurrent) / 1024 / 1024
+			} else if memInfo, err := proc.MemoryInfo(); err == nil {
+				svcData.MemoryMB = float64(memInfo.RSS) / 1024 / 1024 // Convert bytes to MB
+			}
+		}
+
+		// Uptime (ActiveEnterTimestamp is in microseconds since the epoch)
+		if enteredUsec := propUint(props, "ActiveEnterTimestamp"); enteredUsec != 0 {
+			svcData.UptimeSeconds = int64(time.Since(time.UnixMicro(int64(enteredUsec))).Seconds())
+		}
+	}
+
+	return svcData, nil
 }
-func (s *stubSvc) Get(ctx context.Context, name string) (core.Service, error) {
-	return core.Service{}, errors.New("not implemented on linux")
+
+func (s *systemdSvc) Start(ctx context.Context, name string) error {
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	unit := unitName(name)
+	if err := conn.StartUnit(ctx, unit); err != nil {
+		return err
+	}
+	return waitActiveState(ctx, conn, unit, "active")
 }
-func (s *stubSvc) Start(ctx context.Context, name string) error {
-	return errors.New("not implemented on linux")
+
+func (s *systemdSvc) Stop(ctx context.Context, name string) error {
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	unit := unitName(name)
+	if err := conn.StopUnit(ctx, unit); err != nil {
+		return err
+	}
+	return waitActiveState(ctx, conn, unit, "inactive", "failed")
 }
-func (s *stubSvc) Stop(ctx context.Context, name string) error {
-	return errors.New("not implemented on linux")
+
+func (s *systemdSvc) Restart(ctx context.Context, name string) error {
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	unit := unitName(name)
+	if err := conn.RestartUnit(ctx, unit); err != nil {
+		return err
+	}
+	return waitActiveState(ctx, conn, unit, "active")
 }
-func (s *stubSvc) Restart(ctx context.Context, name string) error {
-	return errors.New("not implemented on linux")
+
+// Watch implements core.StateWatcher using systemd's PropertiesChanged signals.
+// The channel closes if the bus connection can't be established or drops, after
+// reporting why on the channel.
+func (s *systemdSvc) Watch(ctx context.Context) <-chan core.StateChange {
+	ch := make(chan core.StateChange, 16)
+	go func() {
+		defer close(ch)
+		report := func(format string, err error) {
+			select {
+			case ch <- core.StateChange{Error: fmt.Sprintf(format, err), Time: time.Now()}:
+			case <-ctx.Done():
+			}
+		}
+
+		conn, err := s.dial(ctx)
+		if err != nil {
+			report("systemd: state stream unavailable: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		units, err := conn.SubscribeUnits(ctx)
+		if err != nil {
+			report("systemd: subscribing to unit changes failed: %v", err)
+			return
+		}
+		for u := range units {
+			if !strings.HasSuffix(u.Name, ".service") {
+				continue
+			}
+			select {
+			case ch <- core.StateChange{
+				Name:  u.Name,
+				State: activeStateToString(u.ActiveState, u.SubState),
+				Time:  time.Now(),
+			}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+func waitActiveState(ctx context.Context, conn systemdConn, unit string, want ...string) error {
+	tick := time.NewTicker(200 * time.Millisecond)
+	defer tick.Stop()
+	timeout := time.NewTimer(20 * time.Second)
+	defer timeout.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timeout.C:
+			return fmt.Errorf("timeout waiting for %s", strings.Join(want, "|"))
+		case <-tick.C:
+			props, err := conn.UnitProperties(ctx, unit)
+			if err != nil {
+				return err
+			}
+			state := propString(props, "ActiveState")
+			for _, w := range want {
+				if state == w {
+					return nil
+				}
+			}
+			// A unit that failed while starting will never become active
+			if state == "failed" && want[0] == "active" {
+				return fmt.Errorf("unit %s failed to start", unit)
+			}
+		}
+	}
+}
+
+// NormalizeName implements core.NameNormalizer, so "nginx" and "nginx.service"
+// are recognised as the same unit.
+func (s *systemdSvc) NormalizeName(name string) string {
+	return unitName(name)
+}
+
+// unitName appends the .service suffix when the caller passed a bare name.
+func unitName(name string) string {
+	if strings.Contains(name, ".") {
+		return name
+	}
+	return name + ".service"
+}
+
+func activeStateToString(activeState, subState string) string {
+	switch activeState {
+	case "active", "reloading":
+		return "running"
+	case "inactive", "failed":
+		return "stopped"
+	case "activating":
+		// Waiting out RestartSec after a crash; nothing is running yet
+		if subState == "auto-restart" {
+			return "stopped"
+		}
+		return "start_pending"
+	case "deactivating":
+		return "stop_pending"
+	default:
+		return "unknown"
+	}
+}
+
+func unitFileStateToString(state string) string {
+	switch state {
+	case "enabled", "enabled-runtime", "alias", "indirect", "generated":
+		return "auto"
+	case "disabled", "static", "linked", "linked-runtime":
+		return "manual"
+	case "masked", "masked-runtime":
+		return "disabled"
+	default:
+		return "unknown"
+	}
+}
+
+func propString(props map[string]interface{}, key string) string {
+	v, _ := props[key].(string)
+	return v
+}
+
+func propBool(props map[string]interface{}, key string) bool {
+	v, _ := props[key].(bool)
+	return v
+}
+
+func propUint(props map[string]interface{}, key string) uint64 {
+	switch v := props[key].(type) {
+	case uint32:
+		return uint64(v)
+	case uint64:
+		return v
+	default:
+		return 0
+	}
 }
@@ -0,0 +1,254 @@
+//go:build linux
+
+package platform
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+)
+
+// fakeConn is an in-memory systemdConn.
+type fakeConn struct {
+	mu      sync.Mutex
+	units   []unitStatus
+	files   map[string]string
+	props   map[string]map[string]interface{}
+	states  []string // ActiveStates reported by successive UnitProperties calls; the last one sticks
+	jobs    []string // Queued jobs, e.g. "start nginx.service"
+	changes chan unitStatus
+}
+
+func (c *fakeConn) ListUnits(ctx context.Context) ([]unitStatus, error) {
+	return c.units, nil
+}
+
+func (c *fakeConn) ListUnitFiles(ctx context.Context) (map[string]string, error) {
+	return c.files, nil
+}
+
+func (c *fakeConn) UnitProperties(ctx context.Context, unit string) (map[string]interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	props := map[string]interface{}{"LoadState": "not-found"}
+	if p, ok := c.props[unit]; ok {
+		props = make(map[string]interface{}, len(p))
+		for k, v := range p {
+			props[k] = v
+		}
+	}
+	if len(c.states) > 0 {
+		props["ActiveState"] = c.states[0]
+		if len(c.states) > 1 {
+			c.states = c.states[1:]
+		}
+	}
+	return props, nil
+}
+
+func (c *fakeConn) job(kind, unit string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.jobs = append(c.jobs, kind+" "+unit)
+	return nil
+}
+
+func (c *fakeConn) StartUnit(ctx context.Context, unit string) error   { return c.job("start", unit) }
+func (c *fakeConn) StopUnit(ctx context.Context, unit string) error    { return c.job("stop", unit) }
+func (c *fakeConn) RestartUnit(ctx context.Context, unit string) error { return c.job("restart", unit) }
+
+func (c *fakeConn) SubscribeUnits(ctx context.Context) (<-chan unitStatus, error) {
+	return c.changes, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func newFakeSvc(conn *fakeConn) *systemdSvc {
+	return newSystemdSvc(func(ctx context.Context) (systemdConn, error) { return conn, nil })
+}
+
+func TestActiveStateToString(t *testing.T) {
+	tests := []struct {
+		active, sub string
+		want        string
+	}{
+		{"active", "running", "running"},
+		{"active", "exited", "running"},
+		{"reloading", "reload", "running"},
+		{"inactive", "dead", "stopped"},
+		{"failed", "failed", "stopped"},
+		{"activating", "start-pre", "start_pending"},
+		{"activating", "auto-restart", "stopped"},
+		{"deactivating", "stop-sigterm", "stop_pending"},
+		{"maintenance", "", "unknown"},
+	}
+	for _, tt := range tests {
+		if got := activeStateToString(tt.active, tt.sub); got != tt.want {
+			t.Errorf("activeStateToString(%q, %q) = %q, want %q", tt.active, tt.sub, got, tt.want)
+		}
+	}
+}
+
+func TestSystemdList(t *testing.T) {
+	conn := &fakeConn{
+		units: []unitStatus{
+			{Name: "nginx.service", Description: "nginx", LoadState: "loaded", ActiveState: "active", SubState: "running"},
+			{Name: "cron.service", LoadState: "loaded", ActiveState: "activating", SubState: "auto-restart"},
+			{Name: "gone.service", LoadState: "not-found", ActiveState: "inactive"},
+			{Name: "tmp.mount", LoadState: "loaded", ActiveState: "active"},
+		},
+		files: map[string]string{
+			"nginx.service":  "enabled",
+			"cron.service":   "disabled",
+			"backup.service": "static",
+			"getty@.service": "enabled",
+			"sockets.target": "static",
+		},
+	}
+
+	services, err := newFakeSvc(conn).List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, svc := range services {
+		got[svc.Name] = svc.State + "/" + svc.StartType
+	}
+	want := map[string]string{
+		"nginx.service":  "running/auto",
+		"cron.service":   "stopped/manual",
+		"backup.service": "stopped/manual",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("List() = %v, want %v", got, want)
+	}
+	for name, state := range want {
+		if got[name] != state {
+			t.Errorf("%s = %q, want %q", name, got[name], state)
+		}
+	}
+}
+
+func TestSystemdGet(t *testing.T) {
+	conn := &fakeConn{props: map[string]map[string]interface{}{
+		"nginx.service": {
+			"LoadState":     "loaded",
+			"ActiveState":   "deactivating",
+			"SubState":      "stop-sigterm",
+			"Description":   "A high performance web server",
+			"UnitFileState": "enabled",
+			"CanStop":       true,
+		},
+	}}
+	svc := newFakeSvc(conn)
+
+	got, err := svc.Get(context.Background(), "nginx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "nginx.service" || got.State != "stop_pending" || !got.CanStop {
+		t.Errorf("Get(nginx) = %+v", got)
+	}
+
+	if _, err := svc.Get(context.Background(), "missing"); err == nil || !strings.Contains(err.Error(), "service not found") {
+		t.Errorf("Get(missing) error = %v, want service not found", err)
+	}
+}
+
+func TestSystemdActionsWaitForState(t *testing.T) {
+	tests := []struct {
+		name    string
+		action  func(s *systemdSvc, ctx context.Context) error
+		states  []string
+		job     string
+		wantErr string
+	}{
+		{
+			name:   "start becomes active",
+			action: func(s *systemdSvc, ctx context.Context) error { return s.Start(ctx, "nginx") },
+			states: []string{"activating", "activating", "active"},
+			job:    "start nginx.service",
+		},
+		{
+			name:    "start fails",
+			action:  func(s *systemdSvc, ctx context.Context) error { return s.Start(ctx, "nginx") },
+			states:  []string{"activating", "failed"},
+			job:     "start nginx.service",
+			wantErr: "failed to start",
+		},
+		{
+			name:   "stop ends failed",
+			action: func(s *systemdSvc, ctx context.Context) error { return s.Stop(ctx, "nginx.service") },
+			states: []string{"deactivating", "failed"},
+			job:    "stop nginx.service",
+		},
+		{
+			name:   "restart",
+			action: func(s *systemdSvc, ctx context.Context) error { return s.Restart(ctx, "nginx") },
+			states: []string{"active"},
+			job:    "restart nginx.service",
+		},
+		{
+			name:    "start times out",
+			action:  func(s *systemdSvc, ctx context.Context) error { return s.Start(ctx, "nginx") },
+			states:  []string{"activating"},
+			job:     "start nginx.service",
+			wantErr: context.DeadlineExceeded.Error(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := &fakeConn{states: tt.states, props: map[string]map[string]interface{}{
+				"nginx.service": {"LoadState": "loaded"},
+			}}
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+
+			err := tt.action(newFakeSvc(conn), ctx)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+			if len(conn.jobs) != 1 || conn.jobs[0] != tt.job {
+				t.Errorf("jobs = %v, want [%s]", conn.jobs, tt.job)
+			}
+		})
+	}
+}
+
+func TestSystemdWatch(t *testing.T) {
+	conn := &fakeConn{changes: make(chan unitStatus, 3)}
+	conn.changes <- unitStatus{Name: "tmp.mount", ActiveState: "active"}
+	conn.changes <- unitStatus{Name: "nginx.service", ActiveState: "activating", SubState: "auto-restart"}
+	conn.changes <- unitStatus{Name: "nginx.service", ActiveState: "active", SubState: "running"}
+	close(conn.changes)
+
+	var got []string
+	for change := range newFakeSvc(conn).Watch(context.Background()) {
+		got = append(got, change.Name+"="+change.State)
+	}
+	want := []string{"nginx.service=stopped", "nginx.service=running"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Watch() = %v, want %v", got, want)
+	}
+}
+
+func TestSystemdWatchReportsDialErrors(t *testing.T) {
+	svc := newSystemdSvc(func(ctx context.Context) (systemdConn, error) {
+		return nil, errors.New("no bus")
+	})
+	var got []core.StateChange
+	for change := range svc.Watch(context.Background()) {
+		got = append(got, change)
+	}
+	if len(got) != 1 || got[0].Error != "systemd: state stream unavailable: no bus" {
+		t.Errorf("Watch() = %+v, want one error", got)
+	}
+}
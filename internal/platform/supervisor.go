@@ -0,0 +1,325 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+const defaultStopTimeout = 10 * time.Second
+
+// ProcessSpec describes a command line run as a service by the supervisor.
+type ProcessSpec struct {
+	Name        string        `json:"name"`
+	DisplayName string        `json:"displayName,omitempty"`
+	Command     []string      `json:"command"`               // argv, Command[0] is the executable
+	Env         []string      `json:"env,omitempty"`         // KEY=VALUE pairs added to the inherited environment
+	Dir         string        `json:"dir,omitempty"`         // Working directory
+	User        string        `json:"user,omitempty"`        // Run as this user (unix only)
+	AutoStart   bool          `json:"autoStart,omitempty"`   // Start when service-watch starts
+	StopTimeout core.Duration `json:"stopTimeout,omitempty"` // Grace period between SIGTERM and SIGKILL
+}
+
+// supervisedProc tracks one running instance of a ProcessSpec.
+type supervisedProc struct {
+	cmd       *exec.Cmd
+	startedAt time.Time
+	stopping  bool
+	done      chan struct{} // closed once the process has exited
+	output    io.WriteCloser
+}
+
+// Supervisor is a ServiceManager that spawns and tracks plain processes itself,
+// for hosts without an init system.
+type Supervisor struct {
+	mutex    sync.Mutex
+	specs    map[string]ProcessSpec
+	procs    map[string]*supervisedProc
+	logDir   string
+	watchers map[chan core.StateChange]bool
+}
+
+// NewSupervisor loads process specs from a JSON config file and starts those marked autoStart.
+// Output of each process is written to logDir/<name>.log; an empty logDir discards it.
+func NewSupervisor(configPath, logDir string) (*Supervisor, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var specs []ProcessSpec
+	if err := json.Unmarshal(data, &specs); err != nil {
+		return nil, fmt.Errorf("invalid supervisor config: %w", err)
+	}
+
+	for _, spec := range specs {
+		if spec.Name == "" || len(spec.Command) == 0 {
+			return nil, fmt.Errorf("invalid supervisor config: every service needs a name and command")
+		}
+	}
+
+	s := NewSupervisorFromSpecs(specs, logDir)
+	for _, spec := range specs {
+		if spec.AutoStart {
+			if err := s.Start(context.Background(), spec.Name); err != nil {
+				return nil, fmt.Errorf("failed to start %s: %w", spec.Name, err)
+			}
+		}
+	}
+	return s, nil
+}
+
+// NewSupervisorFromSpecs creates a supervisor for the given specs without starting anything.
+func NewSupervisorFromSpecs(specs []ProcessSpec, logDir string) *Supervisor {
+	s := &Supervisor{
+		specs:    make(map[string]ProcessSpec, len(specs)),
+		procs:    make(map[string]*supervisedProc),
+		logDir:   logDir,
+		watchers: make(map[chan core.StateChange]bool),
+	}
+	for _, spec := range specs {
+		s.specs[spec.Name] = spec
+	}
+	return s
+}
+
+// List implements core.ServiceManager.
+func (s *Supervisor) List(ctx context.Context) ([]core.Service, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	out := make([]core.Service, 0, len(s.specs))
+	for name, spec := range s.specs {
+		out = append(out, core.Service{
+			Name:        name,
+			DisplayName: spec.DisplayName,
+			State:       s.stateLocked(name),
+			StartType:   startTypeForSpec(spec),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// Get implements core.ServiceManager.
+func (s *Supervisor) Get(ctx context.Context, name string) (core.Service, error) {
+	s.mutex.Lock()
+	spec, exists := s.specs[name]
+	if !exists {
+		s.mutex.Unlock()
+		return core.Service{}, fmt.Errorf("service not found: %s", name)
+	}
+	svcData := core.Service{
+		Name:        name,
+		DisplayName: spec.DisplayName,
+		State:       s.stateLocked(name),
+		StartType:   startTypeForSpec(spec),
+		CanStop:     true,
+	}
+	var startedAt time.Time
+	if p := s.procs[name]; p != nil && svcData.State != "stopped" {
+		svcData.PID = p.cmd.Process.Pid
+		startedAt = p.startedAt
+	}
+	s.mutex.Unlock()
+
+	// Get resource usage if service is running
+	if svcData.State == "running" && svcData.PID != 0 {
+		if proc, err := process.NewProcess(int32(svcData.PID)); err == nil {
+			if cpuPercent, err := proc.CPUPercent(); err == nil {
+				svcData.CPUPercent = cpuPercent
+			}
+			if memInfo, err := proc.MemoryInfo(); err == nil {
+				svcData.MemoryMB = float64(memInfo.RSS) / 1024 / 1024 // Convert bytes to MB
+			}
+		}
+		svcData.UptimeSeconds = int64(time.Since(startedAt).Seconds())
+	}
+
+	return svcData, nil
+}
+
+// Start implements core.ServiceManager.
+func (s *Supervisor) Start(ctx context.Context, name string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	spec, exists := s.specs[name]
+	if !exists {
+		return fmt.Errorf("service not found: %s", name)
+	}
+	if s.stateLocked(name) != "stopped" {
+		return fmt.Errorf("service already running: %s", name)
+	}
+
+	cmd := exec.Command(spec.Command[0], spec.Command[1:]...)
+	cmd.Dir = spec.Dir
+	cmd.Env = append(os.Environ(), spec.Env...)
+	// Don't let a grandchild holding stdout open keep Wait from returning
+	cmd.WaitDelay = 2 * time.Second
+	if err := configureCmd(cmd, spec.User); err != nil {
+		return err
+	}
+
+	output := s.openOutput(name)
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	if err := cmd.Start(); err != nil {
+		output.Close()
+		return err
+	}
+
+	p := &supervisedProc{
+		cmd:       cmd,
+		startedAt: time.Now(),
+		done:      make(chan struct{}),
+		output:    output,
+	}
+	s.procs[name] = p
+	s.notifyLocked(name, "running")
+
+	go func() {
+		cmd.Wait()
+		p.output.Close()
+		s.mutex.Lock()
+		close(p.done)
+		s.notifyLocked(name, "stopped")
+		s.mutex.Unlock()
+	}()
+
+	return nil
+}
+
+// Stop implements core.ServiceManager.
+// The process gets SIGTERM and is killed if it hasn't exited after the spec's stop timeout.
+func (s *Supervisor) Stop(ctx context.Context, name string) error {
+	s.mutex.Lock()
+	spec, exists := s.specs[name]
+	if !exists {
+		s.mutex.Unlock()
+		return fmt.Errorf("service not found: %s", name)
+	}
+	p := s.procs[name]
+	if p == nil || s.stateLocked(name) == "stopped" {
+		s.mutex.Unlock()
+		return fmt.Errorf("service not running: %s", name)
+	}
+	p.stopping = true
+	s.mutex.Unlock()
+
+	if err := terminate(p.cmd.Process); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		kill(p.cmd.Process)
+	}
+
+	grace := time.Duration(spec.StopTimeout)
+	if grace <= 0 {
+		grace = defaultStopTimeout
+	}
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+
+	select {
+	case <-p.done:
+		return nil
+	case <-timer.C:
+		kill(p.cmd.Process)
+	case <-ctx.Done():
+		kill(p.cmd.Process)
+	}
+	<-p.done
+	return nil
+}
+
+// Restart implements core.ServiceManager.
+func (s *Supervisor) Restart(ctx context.Context, name string) error {
+	if svc, err := s.Get(ctx, name); err != nil {
+		return err
+	} else if svc.State != "stopped" {
+		if err := s.Stop(ctx, name); err != nil {
+			return err
+		}
+	}
+	return s.Start(ctx, name)
+}
+
+// Watch implements core.StateWatcher. Process starts and exits are reported as they happen.
+func (s *Supervisor) Watch(ctx context.Context) <-chan core.StateChange {
+	ch := make(chan core.StateChange, 16)
+	s.mutex.Lock()
+	s.watchers[ch] = true
+	s.mutex.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		s.mutex.Lock()
+		delete(s.watchers, ch)
+		close(ch)
+		s.mutex.Unlock()
+	}()
+	return ch
+}
+
+// notifyLocked sends a state change to every watcher without blocking. Caller must hold the mutex.
+func (s *Supervisor) notifyLocked(name, state string) {
+	change := core.StateChange{Name: name, State: state, Time: time.Now()}
+	for ch := range s.watchers {
+		select {
+		case ch <- change:
+		default:
+		}
+	}
+}
+
+// stateLocked reports the state of a supervised process. Caller must hold the mutex.
+func (s *Supervisor) stateLocked(name string) string {
+	p := s.procs[name]
+	if p == nil {
+		return "stopped"
+	}
+	select {
+	case <-p.done:
+		return "stopped"
+	default:
+	}
+	if p.stopping {
+		return "stop_pending"
+	}
+	return "running"
+}
+
+func (s *Supervisor) openOutput(name string) io.WriteCloser {
+	if s.logDir == "" {
+		return nopWriteCloser{io.Discard}
+	}
+	return &lumberjack.Logger{
+		Filename:   filepath.Join(s.logDir, name+".log"),
+		MaxSize:    10, // MB
+		MaxBackups: 3,
+		Compress:   true,
+	}
+}
+
+func startTypeForSpec(spec ProcessSpec) string {
+	if spec.AutoStart {
+		return "auto"
+	}
+	return "manual"
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
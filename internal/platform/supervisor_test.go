@@ -0,0 +1,226 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+)
+
+// TestHelperProcess isn't a real test: it's the process the supervisor runs in
+// the tests below, selected by the argument after "--".
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("SERVICE_WATCH_HELPER") != "1" {
+		return
+	}
+	mode := os.Args[len(os.Args)-1]
+	fmt.Println("helper started:", mode)
+	fmt.Fprintln(os.Stderr, "helper stderr")
+	switch mode {
+	case "exit":
+		os.Exit(0)
+	case "ignore-term":
+		signal.Ignore(syscall.SIGTERM)
+	}
+	time.Sleep(time.Minute)
+	os.Exit(0)
+}
+
+func helperSpec(name, mode string) ProcessSpec {
+	return ProcessSpec{
+		Name:    name,
+		Command: []string{os.Args[0], "-test.run=^TestHelperProcess$", "--", mode},
+		Env:     []string{"SERVICE_WATCH_HELPER=1"},
+	}
+}
+
+// awaitState waits for the service to reach state.
+func awaitState(t *testing.T, s *Supervisor, name, state string) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		svc, err := s.Get(context.Background(), name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if svc.State == state {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s is %s, want %s", name, svc.State, state)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// awaitOutput waits for the service's log to contain want.
+func awaitOutput(t *testing.T, logDir, name, want string) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		data, _ := os.ReadFile(filepath.Join(logDir, name+".log"))
+		if strings.Contains(string(data), want) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s output = %q, want %q", name, data, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSupervisorStartStop(t *testing.T) {
+	logDir := t.TempDir()
+	s := NewSupervisorFromSpecs([]ProcessSpec{helperSpec("worker", "serve"), helperSpec("api", "serve")}, logDir)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := s.Watch(ctx)
+
+	services, err := s.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(services) != 2 || services[0].Name != "api" || services[0].State != "stopped" || services[1].State != "stopped" {
+		t.Fatalf("services = %+v, want api and worker stopped", services)
+	}
+
+	if err := s.Start(ctx, "worker"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Start(ctx, "worker"); err == nil {
+		t.Error("started worker twice")
+	}
+	svc, _ := s.Get(ctx, "worker")
+	if svc.State != "running" || svc.PID == 0 {
+		t.Errorf("worker = %+v, want running with a PID", svc)
+	}
+	awaitOutput(t, logDir, "worker", "helper started: serve")
+	awaitOutput(t, logDir, "worker", "helper stderr")
+
+	if err := s.Stop(ctx, "worker"); err != nil {
+		t.Fatal(err)
+	}
+	if svc, _ := s.Get(ctx, "worker"); svc.State != "stopped" || svc.PID != 0 {
+		t.Errorf("worker = %+v, want stopped once Stop returns", svc)
+	}
+	if err := s.Stop(ctx, "worker"); err == nil {
+		t.Error("stopped a stopped worker")
+	}
+
+	for _, want := range []string{"running", "stopped"} {
+		select {
+		case change := <-changes:
+			if change.Name != "worker" || change.State != want {
+				t.Errorf("change = %+v, want worker %s", change, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no %s change", want)
+		}
+	}
+
+	for _, call := range []func(context.Context, string) error{s.Start, s.Stop, s.Restart} {
+		if err := call(ctx, "missing"); err == nil || !strings.Contains(err.Error(), "service not found") {
+			t.Errorf("err = %v, want service not found", err)
+		}
+	}
+}
+
+func TestSupervisorProcessExit(t *testing.T) {
+	logDir := t.TempDir()
+	s := NewSupervisorFromSpecs([]ProcessSpec{helperSpec("oneshot", "exit")}, logDir)
+	ctx := context.Background()
+
+	if err := s.Start(ctx, "oneshot"); err != nil {
+		t.Fatal(err)
+	}
+	awaitState(t, s, "oneshot", "stopped")
+	awaitOutput(t, logDir, "oneshot", "helper started: exit")
+
+	// Restart of a stopped process just starts it
+	if err := s.Restart(ctx, "oneshot"); err != nil {
+		t.Fatal(err)
+	}
+	awaitState(t, s, "oneshot", "stopped")
+}
+
+func TestSupervisorRestart(t *testing.T) {
+	s := NewSupervisorFromSpecs([]ProcessSpec{helperSpec("worker", "serve")}, "")
+	ctx := context.Background()
+	if err := s.Start(ctx, "worker"); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := s.Get(ctx, "worker")
+
+	if err := s.Restart(ctx, "worker"); err != nil {
+		t.Fatal(err)
+	}
+	after, _ := s.Get(ctx, "worker")
+	if after.State != "running" || after.PID == before.PID {
+		t.Errorf("worker = %+v, want running as a new process (was PID %d)", after, before.PID)
+	}
+	s.Stop(ctx, "worker")
+}
+
+func TestSupervisorStopKillsAfterTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no SIGTERM to ignore on windows")
+	}
+	spec := helperSpec("stubborn", "ignore-term")
+	spec.StopTimeout = core.Duration(200 * time.Millisecond)
+	logDir := t.TempDir()
+	s := NewSupervisorFromSpecs([]ProcessSpec{spec}, logDir)
+	ctx := context.Background()
+	if err := s.Start(ctx, "stubborn"); err != nil {
+		t.Fatal(err)
+	}
+	// Wait until SIGTERM is ignored before sending it
+	awaitOutput(t, logDir, "stubborn", "helper stderr")
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- s.Stop(ctx, "stubborn") }()
+	awaitState(t, s, "stubborn", "stop_pending")
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Stop didn't kill the process after its stop timeout")
+	}
+	if svc, _ := s.Get(ctx, "stubborn"); svc.State != "stopped" {
+		t.Errorf("stubborn = %+v, want stopped", svc)
+	}
+}
+
+func TestNewSupervisor(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{name: "valid", config: `[{"name":"worker","command":["worker"]}]`},
+		{name: "no command", config: `[{"name":"worker"}]`, wantErr: "needs a name and command"},
+		{name: "not JSON", config: `{`, wantErr: "invalid supervisor config"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "supervisor.json")
+			os.WriteFile(path, []byte(tt.config), 0644)
+			_, err := NewSupervisor(path, "")
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("err = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
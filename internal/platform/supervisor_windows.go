@@ -0,0 +1,27 @@
+//go:build windows
+
+package platform
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// configureCmd rejects options Windows can't honour.
+func configureCmd(cmd *exec.Cmd, username string) error {
+	if username != "" {
+		return errors.New("running supervised services as another user is not supported on windows")
+	}
+	return nil
+}
+
+// terminate stops a supervised process. Windows has no SIGTERM, so this kills it outright.
+func terminate(p *os.Process) error {
+	return p.Kill()
+}
+
+// kill forcibly stops a supervised process.
+func kill(p *os.Process) error {
+	return p.Kill()
+}
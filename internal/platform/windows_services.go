@@ -14,6 +14,9 @@ import (
 	"github.com/shirou/gopsutil/v4/process"
 )
 
+// OSBackendName names the native backend when several are combined.
+const OSBackendName = "windows"
+
 // Windows specific implementation of ServiceManager
 type winSvc struct{}
 
@@ -0,0 +1,309 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+	"github.com/ethan-mdev/service-watch/internal/logger"
+)
+
+// holdGrace keeps a scheduled stop's maintenance window open a little past the
+// start that ends it, so the monitor doesn't race the scheduled start.
+const holdGrace = time.Minute
+
+// defaultHold is how long a scheduled stop's maintenance window lasts when the
+// schedule has no hold and nothing starts the service again.
+const defaultHold = 24 * time.Hour
+
+// Scheduler runs service actions when their schedules fire.
+type Scheduler struct {
+	schedules core.ScheduleManager
+	watchlist core.WatchlistManager
+	services  core.ServiceManager
+	log       *logger.Logger
+
+	mutex  sync.Mutex
+	states map[string]*scheduleState
+}
+
+// scheduleState is runtime bookkeeping kept for each schedule.
+type scheduleState struct {
+	cron   string // Expression NextRun was computed from
+	status core.ScheduleStatus
+}
+
+// New creates a scheduler for the schedules of scheduleMgr.
+func New(scheduleMgr core.ScheduleManager, watchlistMgr core.WatchlistManager, svcMgr core.ServiceManager, log *logger.Logger) *Scheduler {
+	return &Scheduler{
+		schedules: scheduleMgr,
+		watchlist: watchlistMgr,
+		services:  svcMgr,
+		log:       log,
+		states:    make(map[string]*scheduleState),
+	}
+}
+
+// Run fires schedules until ctx is cancelled. Runs missed while the scheduler
+// wasn't running are not made up.
+func (s *Scheduler) Run(ctx context.Context) {
+	s.log.Info("scheduler_started", nil)
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	s.tick(ctx, time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			s.log.Info("scheduler_stopped", nil)
+			return
+		case now := <-ticker.C:
+			s.tick(ctx, now)
+		}
+	}
+}
+
+// ScheduleStatus implements core.ScheduleStatusProvider.
+func (s *Scheduler) ScheduleStatus(id string) (core.ScheduleStatus, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	state, exists := s.states[id]
+	if !exists {
+		return core.ScheduleStatus{}, false
+	}
+	return state.status, true
+}
+
+// tick picks up new and changed schedules and starts those that are due.
+func (s *Scheduler) tick(ctx context.Context, now time.Time) {
+	schedules, err := s.schedules.List(ctx)
+	if err != nil {
+		s.log.Error("scheduler_list_failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	current := make(map[string]bool, len(schedules))
+	for _, schedule := range schedules {
+		current[schedule.ID] = true
+
+		state, exists := s.states[schedule.ID]
+		if !exists || state.cron != schedule.Cron {
+			state = &scheduleState{cron: schedule.Cron}
+			state.status.NextRun = schedule.Next(now)
+			s.states[schedule.ID] = state
+			s.log.Info("schedule_registered", map[string]interface{}{
+				"scheduleId": schedule.ID,
+				"schedule":   schedule.Label(),
+				"nextRun":    state.status.NextRun.Format(time.RFC3339),
+			})
+			continue
+		}
+
+		if state.status.NextRun.IsZero() || now.Before(state.status.NextRun) {
+			continue
+		}
+		state.status.NextRun = schedule.Next(now)
+
+		if state.status.Running {
+			s.log.Error("schedule_skipped", map[string]interface{}{
+				"scheduleId": schedule.ID,
+				"schedule":   schedule.Label(),
+				"reason":     "previous run still in progress",
+				"nextRun":    state.status.NextRun.Format(time.RFC3339),
+			})
+			continue
+		}
+		state.status.Running = true
+		state.status.LastRun = now
+		go s.run(ctx, schedule, state.status.NextRun)
+	}
+
+	// Drop bookkeeping for removed schedules
+	for id := range s.states {
+		if !current[id] {
+			delete(s.states, id)
+		}
+	}
+}
+
+// run applies a schedule's action to each of its targets and records the outcome.
+func (s *Scheduler) run(ctx context.Context, schedule core.Schedule, nextRun time.Time) {
+	targets, err := s.targets(ctx, schedule)
+	if err == nil && len(targets) == 0 {
+		err = fmt.Errorf("no watchlist items tagged %s", schedule.Tag)
+	}
+	if err != nil {
+		s.log.Error("schedule_failed", map[string]interface{}{
+			"scheduleId": schedule.ID,
+			"action":     schedule.Action,
+			"error":      err.Error(),
+			"nextRun":    nextRun.Format(time.RFC3339),
+		})
+		s.finish(schedule.ID, err)
+		return
+	}
+
+	s.log.Info("schedule_run", map[string]interface{}{
+		"scheduleId": schedule.ID,
+		"schedule":   schedule.Label(),
+		"action":     schedule.Action,
+		"targets":    targets,
+		"nextRun":    nextRun.Format(time.RFC3339),
+	})
+
+	for _, name := range targets {
+		started := time.Now()
+		if actionErr := s.apply(ctx, schedule, name); actionErr != nil {
+			err = actionErr
+			s.log.Error("schedule_failed", map[string]interface{}{
+				"scheduleId":  schedule.ID,
+				"serviceName": name,
+				"action":      schedule.Action,
+				"error":       actionErr.Error(),
+				"durationMs":  time.Since(started).Milliseconds(),
+				"nextRun":     nextRun.Format(time.RFC3339),
+			})
+			continue
+		}
+		s.log.Info("schedule_succeeded", map[string]interface{}{
+			"scheduleId":  schedule.ID,
+			"serviceName": name,
+			"action":      schedule.Action,
+			"durationMs":  time.Since(started).Milliseconds(),
+			"nextRun":     nextRun.Format(time.RFC3339),
+		})
+	}
+	s.finish(schedule.ID, err)
+}
+
+// finish records the outcome of a run; err is the last failure, if any.
+func (s *Scheduler) finish(id string, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	state, exists := s.states[id]
+	if !exists {
+		return
+	}
+	state.status.Running = false
+	state.status.LastResult = "ok"
+	state.status.LastError = ""
+	if err != nil {
+		state.status.LastResult = "failed"
+		state.status.LastError = err.Error()
+	}
+}
+
+// targets resolves the services a schedule acts on. Group members are started
+// dependencies first and stopped in reverse.
+func (s *Scheduler) targets(ctx context.Context, schedule core.Schedule) ([]string, error) {
+	if schedule.ServiceName != "" {
+		return []string{schedule.ServiceName}, nil
+	}
+
+	items, err := s.watchlist.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	members, err := core.GroupMembers(items, schedule.Tag)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.Action == "stop" {
+		slices.Reverse(members)
+	}
+	return members, nil
+}
+
+// apply runs the schedule's action on one service.
+func (s *Scheduler) apply(ctx context.Context, schedule core.Schedule, name string) error {
+	switch schedule.Action {
+	case "start":
+		return s.services.Start(ctx, name)
+	case "stop":
+		s.hold(ctx, schedule, name)
+		return s.services.Stop(ctx, name)
+	case "restart":
+		return s.services.Restart(ctx, name)
+	default:
+		return fmt.Errorf("unknown schedule action: %q", schedule.Action)
+	}
+}
+
+// hold opens a maintenance window on a watched service before a scheduled stop,
+// so the monitor doesn't undo it. It lasts for the schedule's hold or, by default,
+// until the next scheduled start or restart of the service, or defaultHold if
+// there is none. Each fire replaces the schedule's previous window.
+func (s *Scheduler) hold(ctx context.Context, schedule core.Schedule, name string) {
+	item, err := s.watchlist.Get(ctx, name)
+	if err != nil || !item.AutoRestart {
+		return // Nothing would restart it
+	}
+
+	now := time.Now()
+	end := now.Add(defaultHold)
+	if schedule.Hold > 0 {
+		end = now.Add(time.Duration(schedule.Hold))
+	} else if next := s.nextStart(ctx, item, now); !next.IsZero() {
+		end = next.Add(holdGrace)
+	}
+	window := core.MaintenanceWindow{
+		ID:     holdWindowID(schedule),
+		Reason: "scheduled stop: " + schedule.Label(),
+		Start:  now.Format(time.RFC3339),
+		End:    end.Format(time.RFC3339),
+	}
+
+	// The window may still be open from the last fire; it isn't an error if not
+	s.watchlist.RemoveMaintenanceWindow(ctx, name, window.ID)
+	if _, err := s.watchlist.AddMaintenanceWindow(ctx, name, window); err != nil {
+		s.log.Error("schedule_hold_failed", map[string]interface{}{
+			"scheduleId":  schedule.ID,
+			"serviceName": name,
+			"error":       err.Error(),
+		})
+		return
+	}
+	s.log.Info("schedule_hold", map[string]interface{}{
+		"scheduleId":  schedule.ID,
+		"serviceName": name,
+		"windowId":    window.ID,
+		"until":       window.End,
+	})
+}
+
+// holdWindowID names the maintenance window a stop schedule holds its targets with.
+func holdWindowID(schedule core.Schedule) string {
+	return "schedule-" + schedule.ID
+}
+
+// nextStart returns when a start or restart schedule next brings item back up,
+// or zero if none does.
+func (s *Scheduler) nextStart(ctx context.Context, item core.WatchlistItem, now time.Time) time.Time {
+	schedules, err := s.schedules.List(ctx)
+	if err != nil {
+		return time.Time{}
+	}
+
+	var next time.Time
+	for _, schedule := range schedules {
+		if schedule.Action == "stop" {
+			continue
+		}
+		if schedule.ServiceName != item.ServiceName && (schedule.Tag == "" || !item.HasTag(schedule.Tag)) {
+			continue
+		}
+		if t := schedule.Next(now); !t.IsZero() && (next.IsZero() || t.Before(next)) {
+			next = t
+		}
+	}
+	return next
+}
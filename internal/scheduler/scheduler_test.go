@@ -0,0 +1,327 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+	"github.com/ethan-mdev/service-watch/internal/logger"
+	"github.com/ethan-mdev/service-watch/internal/storage"
+)
+
+// fakeServices is a ServiceManager that records actions, in order. While block
+// is set, actions wait for it to be closed.
+type fakeServices struct {
+	mu      sync.Mutex
+	actions []string
+	block   chan struct{}
+}
+
+func (f *fakeServices) act(action, name string) error {
+	f.mu.Lock()
+	block := f.block
+	f.mu.Unlock()
+	if block != nil {
+		<-block
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.actions = append(f.actions, action+" "+name)
+	return nil
+}
+
+func (f *fakeServices) done() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.actions)
+}
+
+func (f *fakeServices) List(ctx context.Context) ([]core.Service, error) { return nil, nil }
+func (f *fakeServices) Get(ctx context.Context, name string) (core.Service, error) {
+	return core.Service{Name: name, State: "running"}, nil
+}
+func (f *fakeServices) Start(ctx context.Context, name string) error   { return f.act("start", name) }
+func (f *fakeServices) Stop(ctx context.Context, name string) error    { return f.act("stop", name) }
+func (f *fakeServices) Restart(ctx context.Context, name string) error { return f.act("restart", name) }
+
+func newTestScheduler(t *testing.T) (*Scheduler, core.ScheduleManager, core.WatchlistManager, *fakeServices) {
+	t.Helper()
+	dir := t.TempDir()
+	log, err := logger.Start(filepath.Join(dir, "events.jsonl"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { log.Close() })
+	svc := &fakeServices{}
+	schedules := storage.NewJSONSchedules(filepath.Join(dir, "schedules.json"))
+	wl := storage.NewJSONWatchlist(filepath.Join(dir, "watchlist.json"), svc)
+	return New(schedules, wl, svc, log), schedules, wl, svc
+}
+
+func addSchedule(t *testing.T, schedules core.ScheduleManager, schedule core.Schedule) core.Schedule {
+	t.Helper()
+	schedule, err := schedules.Add(context.Background(), schedule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schedule
+}
+
+// waitIdle waits for the schedule's run to finish and returns its status.
+func waitIdle(t *testing.T, s *Scheduler, id string) core.ScheduleStatus {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status, ok := s.ScheduleStatus(id)
+		if ok && !status.Running {
+			return status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("schedule %s still running", id)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestTickFiresWhenDue(t *testing.T) {
+	s, schedules, _, svc := newTestScheduler(t)
+	ctx := context.Background()
+	schedule := addSchedule(t, schedules, core.Schedule{Cron: "0 3 * * *", Action: "restart", ServiceName: "app"})
+	start := time.Date(2025, 6, 1, 2, 0, 0, 0, time.Local)
+	firstRun := time.Date(2025, 6, 1, 3, 0, 0, 0, time.Local)
+
+	// Picked up without firing
+	s.tick(ctx, start)
+	status, _ := s.ScheduleStatus(schedule.ID)
+	if !status.NextRun.Equal(firstRun) {
+		t.Fatalf("nextRun = %v, want %v", status.NextRun, firstRun)
+	}
+	s.tick(ctx, firstRun.Add(-time.Second))
+	if status, _ := s.ScheduleStatus(schedule.ID); status.Running || !status.LastRun.IsZero() {
+		t.Fatalf("fired early: %+v", status)
+	}
+
+	s.tick(ctx, firstRun)
+	status = waitIdle(t, s, schedule.ID)
+	if got := svc.done(); !slices.Equal(got, []string{"restart app"}) {
+		t.Errorf("actions = %v, want [restart app]", got)
+	}
+	if !status.LastRun.Equal(firstRun) || status.LastResult != "ok" || !status.NextRun.Equal(firstRun.AddDate(0, 0, 1)) {
+		t.Errorf("status = %+v, want run at %v and next run a day later", status, firstRun)
+	}
+
+	// A changed expression starts the bookkeeping over
+	schedules.Remove(ctx, schedule.ID)
+	schedule.Cron = "30 3 * * *"
+	addSchedule(t, schedules, schedule)
+	s.tick(ctx, firstRun.Add(time.Minute))
+	status, _ = s.ScheduleStatus(schedule.ID)
+	if want := firstRun.Add(30 * time.Minute); !status.NextRun.Equal(want) || !status.LastRun.IsZero() {
+		t.Errorf("status = %+v, want fresh with next run %v", status, want)
+	}
+
+	// Removed schedules are forgotten
+	schedules.Remove(ctx, schedule.ID)
+	s.tick(ctx, firstRun.Add(time.Hour))
+	if _, ok := s.ScheduleStatus(schedule.ID); ok {
+		t.Error("status kept for a removed schedule")
+	}
+}
+
+func TestTickSkipsWhileRunning(t *testing.T) {
+	s, schedules, _, svc := newTestScheduler(t)
+	ctx := context.Background()
+	schedule := addSchedule(t, schedules, core.Schedule{Cron: "* * * * *", Action: "start", ServiceName: "app"})
+	start := time.Date(2025, 6, 1, 2, 0, 30, 0, time.Local)
+
+	svc.block = make(chan struct{})
+	s.tick(ctx, start)
+	s.tick(ctx, start.Add(30*time.Second)) // 02:01, starts a run that blocks
+	s.tick(ctx, start.Add(90*time.Second)) // 02:02, skipped
+	status, _ := s.ScheduleStatus(schedule.ID)
+	if !status.Running {
+		t.Fatal("run not in progress")
+	}
+	if want := start.Add(150 * time.Second); !status.NextRun.Equal(want) {
+		t.Errorf("nextRun = %v, want the skipped run to move it on to %v", status.NextRun, want)
+	}
+
+	close(svc.block)
+	waitIdle(t, s, schedule.ID)
+	if got := svc.done(); len(got) != 1 {
+		t.Errorf("actions = %v, want one start", got)
+	}
+}
+
+func TestTargetsOrderGroups(t *testing.T) {
+	s, _, wl, _ := newTestScheduler(t)
+	ctx := context.Background()
+	for _, name := range []string{"web", "db", "cache", "other"} {
+		if err := wl.Add(ctx, name, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"web", "db", "cache"} {
+		if err := wl.SetTags(ctx, name, []string{"stack"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wl.SetDependencies(ctx, "web", []string{"db", "cache"})
+	wl.SetDependencies(ctx, "cache", []string{"db"})
+
+	tests := []struct {
+		schedule core.Schedule
+		want     []string
+	}{
+		{core.Schedule{Action: "start", Tag: "stack"}, []string{"db", "cache", "web"}},
+		{core.Schedule{Action: "restart", Tag: "stack"}, []string{"db", "cache", "web"}},
+		{core.Schedule{Action: "stop", Tag: "stack"}, []string{"web", "cache", "db"}},
+		{core.Schedule{Action: "stop", ServiceName: "db"}, []string{"db"}},
+		{core.Schedule{Action: "start", Tag: "none"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.schedule.Label(), func(t *testing.T) {
+			got, err := s.targets(ctx, tt.schedule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("targets = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextStart(t *testing.T) {
+	s, schedules, wl, _ := newTestScheduler(t)
+	ctx := context.Background()
+	wl.Add(ctx, "app", true)
+	wl.SetTags(ctx, "app", []string{"stack"})
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.Local)
+
+	item, _ := wl.Get(ctx, "app")
+	if next := s.nextStart(ctx, item, now); !next.IsZero() {
+		t.Errorf("nextStart = %v with no schedules, want zero", next)
+	}
+
+	addSchedule(t, schedules, core.Schedule{Cron: "0 13 * * *", Action: "stop", ServiceName: "app"})    // Ignored
+	addSchedule(t, schedules, core.Schedule{Cron: "0 14 * * *", Action: "start", ServiceName: "other"}) // Ignored
+	addSchedule(t, schedules, core.Schedule{Cron: "0 18 * * *", Action: "start", ServiceName: "app"})
+	addSchedule(t, schedules, core.Schedule{Cron: "0 16 * * *", Action: "restart", Tag: "stack"})
+	want := time.Date(2025, 6, 1, 16, 0, 0, 0, time.Local)
+	if next := s.nextStart(ctx, item, now); !next.Equal(want) {
+		t.Errorf("nextStart = %v, want %v from the group restart", next, want)
+	}
+}
+
+func TestHold(t *testing.T) {
+	tests := []struct {
+		name        string
+		hold        time.Duration
+		startCron   string // Schedule bringing the service back, if any
+		autoRestart bool
+		wantEnd     func(now time.Time, start core.Schedule) time.Time // Zero for no window
+	}{
+		{
+			name:        "hold",
+			hold:        2 * time.Hour,
+			autoRestart: true,
+			wantEnd:     func(now time.Time, _ core.Schedule) time.Time { return now.Add(2 * time.Hour) },
+		},
+		{
+			name:        "until next start",
+			startCron:   "0 6 * * *",
+			autoRestart: true,
+			wantEnd:     func(now time.Time, start core.Schedule) time.Time { return start.Next(now).Add(holdGrace) },
+		},
+		{
+			// Nothing starts it again, so the window still ends
+			name:        "open-ended",
+			autoRestart: true,
+			wantEnd:     func(now time.Time, _ core.Schedule) time.Time { return now.Add(defaultHold) },
+		},
+		{
+			name:    "no auto-restart",
+			wantEnd: func(time.Time, core.Schedule) time.Time { return time.Time{} },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, schedules, wl, _ := newTestScheduler(t)
+			ctx := context.Background()
+			wl.Add(ctx, "app", tt.autoRestart)
+			stop := addSchedule(t, schedules, core.Schedule{Cron: "0 22 * * 5", Action: "stop", ServiceName: "app", Hold: core.Duration(tt.hold)})
+			var start core.Schedule
+			if tt.startCron != "" {
+				start = addSchedule(t, schedules, core.Schedule{Cron: tt.startCron, Action: "start", ServiceName: "app"})
+			}
+
+			// Each fire replaces the window rather than adding another
+			for fire := 0; fire < 3; fire++ {
+				now := time.Now()
+				s.hold(ctx, stop, "app")
+				item, _ := wl.Get(ctx, "app")
+				want := tt.wantEnd(now, start)
+				if want.IsZero() {
+					if len(item.Maintenance) != 0 {
+						t.Fatalf("windows = %+v, want none", item.Maintenance)
+					}
+					continue
+				}
+				if len(item.Maintenance) != 1 {
+					t.Fatalf("fire %d: %d windows, want 1", fire, len(item.Maintenance))
+				}
+				window := item.Maintenance[0]
+				if window.ID != holdWindowID(stop) {
+					t.Errorf("window ID = %s, want %s", window.ID, holdWindowID(stop))
+				}
+				end, err := time.Parse(time.RFC3339, window.End)
+				if err != nil {
+					t.Fatalf("window end %q: %v", window.End, err)
+				}
+				if diff := end.Sub(want); diff < -2*time.Second || diff > 2*time.Second {
+					t.Errorf("window ends %v, want %v", end, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRunStopsGroupInReverseAndHolds(t *testing.T) {
+	s, schedules, wl, svc := newTestScheduler(t)
+	ctx := context.Background()
+	for _, name := range []string{"db", "web"} {
+		wl.Add(ctx, name, true)
+		wl.SetTags(ctx, name, []string{"stack"})
+	}
+	wl.SetDependencies(ctx, "web", []string{"db"})
+	stop := addSchedule(t, schedules, core.Schedule{Cron: "0 22 * * *", Action: "stop", Tag: "stack"})
+
+	s.tick(ctx, time.Date(2025, 6, 1, 21, 0, 0, 0, time.Local))
+	s.tick(ctx, time.Date(2025, 6, 1, 22, 0, 0, 0, time.Local))
+	if status := waitIdle(t, s, stop.ID); status.LastResult != "ok" {
+		t.Errorf("status = %+v, want ok", status)
+	}
+	if got, want := svc.done(), []string{"stop web", "stop db"}; !slices.Equal(got, want) {
+		t.Errorf("actions = %v, want %v", got, want)
+	}
+	for _, name := range []string{"db", "web"} {
+		item, _ := wl.Get(ctx, name)
+		if len(item.Maintenance) != 1 {
+			t.Errorf("%s has %d windows, want 1", name, len(item.Maintenance))
+		}
+	}
+
+	// An empty group fails the run
+	empty := addSchedule(t, schedules, core.Schedule{Cron: "0 22 * * *", Action: "start", Tag: "none"})
+	s.tick(ctx, time.Date(2025, 6, 2, 21, 0, 0, 0, time.Local))
+	s.tick(ctx, time.Date(2025, 6, 2, 22, 0, 0, 0, time.Local))
+	if status := waitIdle(t, s, empty.ID); status.LastResult != "failed" || status.LastError != fmt.Sprintf("no watchlist items tagged %s", "none") {
+		t.Errorf("status = %+v, want failed for the empty group", status)
+	}
+}
@@ -2,6 +2,7 @@ package sse
 
 import (
 	"sync"
+	"sync/atomic"
 
 	"github.com/ethan-mdev/service-watch/internal/core"
 )
@@ -9,6 +10,7 @@ import (
 // Represents an SSE client.
 type Client struct {
 	Channel chan core.Event
+	dropped atomic.Int64 // Events that didn't fit in Channel
 }
 
 // Manages SSE clients and broadcasts events to them.
@@ -50,6 +52,7 @@ func (b *Broadcaster) Broadcast(event core.Event) {
 		select {
 		case client.Channel <- event:
 		default:
+			client.dropped.Add(1)
 		}
 	}
 }
@@ -0,0 +1,72 @@
+package sse
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+)
+
+const (
+	// subscriptionBuffer is how many events an in-process consumer can fall behind
+	// before Broadcast starts dropping them.
+	subscriptionBuffer = 256
+	// dropReportInterval is how often a subscription reports the events it dropped.
+	dropReportInterval = time.Minute
+)
+
+// ErrorLogger is where a subscription reports dropped events; *logger.Logger is one.
+type ErrorLogger interface {
+	Error(eventType string, data map[string]interface{})
+}
+
+// Subscription is an in-process consumer of the broadcaster's events, such as
+// the alert engine or the webhook notifier.
+type Subscription struct {
+	Events <-chan core.Event
+
+	broadcaster *Broadcaster
+	client      *Client
+}
+
+// Subscribe registers a consumer called name. Events it falls too far behind to
+// take are dropped rather than holding up the logger, and reported to log as an
+// events_dropped error at most once every dropReportInterval, until ctx is
+// cancelled. log may be nil.
+func (b *Broadcaster) Subscribe(ctx context.Context, name string, log ErrorLogger) *Subscription {
+	client := &Client{Channel: make(chan core.Event, subscriptionBuffer)}
+	b.RegisterClient(client)
+
+	// Reported from here rather than from Broadcast, which runs under the logger's lock
+	go func() {
+		ticker := time.NewTicker(dropReportInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				reportDropped(client, name, log)
+				return
+			case <-ticker.C:
+				reportDropped(client, name, log)
+			}
+		}
+	}()
+	return &Subscription{Events: client.Channel, broadcaster: b, client: client}
+}
+
+// Close unregisters the subscription and closes Events.
+func (s *Subscription) Close() {
+	s.broadcaster.UnregisterClient(s.client)
+}
+
+func reportDropped(client *Client, name string, log ErrorLogger) {
+	dropped := client.dropped.Swap(0)
+	if dropped == 0 || log == nil {
+		return
+	}
+	log.Error("events_dropped", map[string]interface{}{
+		"subscriber": name,
+		"dropped":    dropped,
+		"message":    "Subscriber fell behind the event stream",
+	})
+}
@@ -0,0 +1,74 @@
+package sse
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+)
+
+type recordingLogger struct {
+	mu      sync.Mutex
+	entries []map[string]interface{}
+}
+
+func (l *recordingLogger) Error(eventType string, data map[string]interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	data["event"] = eventType
+	l.entries = append(l.entries, data)
+}
+
+func (l *recordingLogger) logged() []map[string]interface{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]map[string]interface{}(nil), l.entries...)
+}
+
+func TestSubscriptionReportsDrops(t *testing.T) {
+	b := NewBroadcaster()
+	log := &recordingLogger{}
+	ctx, cancel := context.WithCancel(context.Background())
+	sub := b.Subscribe(ctx, "slow", log)
+	defer sub.Close()
+
+	// Nothing reads, so everything past the buffer is dropped
+	for i := 0; i < subscriptionBuffer+40; i++ {
+		b.Broadcast(core.Event{Type: "service_status"})
+	}
+	if got := len(sub.Events); got != subscriptionBuffer {
+		t.Errorf("%d events buffered, want %d", got, subscriptionBuffer)
+	}
+
+	// The drops are reported when the subscription ends, if not before
+	cancel()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(log.logged()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("drops never reported")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	entries := log.logged()
+	if len(entries) != 1 || entries[0]["event"] != "events_dropped" || entries[0]["subscriber"] != "slow" || entries[0]["dropped"] != int64(40) {
+		t.Errorf("logged %v, want one events_dropped for 40 events", entries)
+	}
+}
+
+func TestSubscriptionCloses(t *testing.T) {
+	b := NewBroadcaster()
+	sub := b.Subscribe(context.Background(), "quiet", nil)
+	b.Broadcast(core.Event{Type: "watcher_started"})
+	sub.Close()
+
+	if event, ok := <-sub.Events; !ok || event.Type != "watcher_started" {
+		t.Errorf("first event = %+v, %v", event, ok)
+	}
+	if _, ok := <-sub.Events; ok {
+		t.Error("Events still open after Close")
+	}
+	// Broadcasting after Close reaches nobody and doesn't panic
+	b.Broadcast(core.Event{Type: "watcher_stopped"})
+}
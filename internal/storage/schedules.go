@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+)
+
+type jsonSchedules struct {
+	mutex     sync.RWMutex
+	filepath  string
+	schedules map[string]*core.Schedule
+}
+
+func NewJSONSchedules(filepath string) core.ScheduleManager {
+	schedules := &jsonSchedules{
+		filepath:  filepath,
+		schedules: make(map[string]*core.Schedule),
+	}
+	schedules.load()
+	return schedules
+}
+
+// List implements core.ScheduleManager.
+func (j *jsonSchedules) List(ctx context.Context) ([]core.Schedule, error) {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+
+	schedules := make([]core.Schedule, 0, len(j.schedules))
+	for _, schedule := range j.schedules {
+		schedules = append(schedules, *schedule)
+	}
+	sort.Slice(schedules, func(i, k int) bool { return schedules[i].ID < schedules[k].ID })
+	return schedules, nil
+}
+
+// Get implements core.ScheduleManager.
+func (j *jsonSchedules) Get(ctx context.Context, id string) (core.Schedule, error) {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+
+	schedule, exists := j.schedules[id]
+	if !exists {
+		return core.Schedule{}, fmt.Errorf("schedule not found: %s", id)
+	}
+	return *schedule, nil
+}
+
+// Add implements core.ScheduleManager.
+func (j *jsonSchedules) Add(ctx context.Context, schedule core.Schedule) (core.Schedule, error) {
+	if err := schedule.Validate(); err != nil {
+		return core.Schedule{}, err
+	}
+	if schedule.ID == "" {
+		schedule.ID = newID()
+	}
+	schedule.Status = nil
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if _, exists := j.schedules[schedule.ID]; exists {
+		return core.Schedule{}, fmt.Errorf("schedule already exists: %s", schedule.ID)
+	}
+	j.schedules[schedule.ID] = &schedule
+	return schedule, j.save()
+}
+
+// Remove implements core.ScheduleManager.
+func (j *jsonSchedules) Remove(ctx context.Context, id string) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if _, exists := j.schedules[id]; !exists {
+		return fmt.Errorf("schedule not found: %s", id)
+	}
+	delete(j.schedules, id)
+	return j.save()
+}
+
+func (j *jsonSchedules) load() error {
+	data, err := os.ReadFile(j.filepath)
+	if os.IsNotExist(err) {
+		return nil // fresh start
+	}
+	if err != nil {
+		return err
+	}
+
+	var schedules []core.Schedule
+	if err := json.Unmarshal(data, &schedules); err != nil {
+		return err
+	}
+
+	for i := range schedules {
+		j.schedules[schedules[i].ID] = &schedules[i]
+	}
+	return nil
+}
+
+func (j *jsonSchedules) save() error {
+	schedules := make([]core.Schedule, 0, len(j.schedules))
+	for _, schedule := range j.schedules {
+		schedules = append(schedules, *schedule)
+	}
+	sort.Slice(schedules, func(i, k int) bool { return schedules[i].ID < schedules[k].ID })
+
+	data, err := json.MarshalIndent(schedules, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(j.filepath, data, 0644)
+}
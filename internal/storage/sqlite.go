@@ -0,0 +1,576 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+
+	_ "modernc.org/sqlite" // Pure-Go driver, registered as "sqlite"
+)
+
+// migrations bring the schema up to date, in order. PRAGMA user_version records
+// how many have been applied; append new steps, never edit applied ones.
+var migrations = []string{
+	`CREATE TABLE watchlist (
+		service_name       TEXT PRIMARY KEY,
+		auto_restart       INTEGER NOT NULL DEFAULT 0,
+		restart_count      INTEGER NOT NULL DEFAULT 0,
+		fail_count         INTEGER NOT NULL DEFAULT 0,
+		last_restart       TEXT NOT NULL DEFAULT '',
+		restart_dependents INTEGER NOT NULL DEFAULT 0,
+		tags               TEXT, -- JSON columns, NULL when unset
+		restart_policy     TEXT,
+		hooks              TEXT,
+		health_checks      TEXT,
+		thresholds         TEXT,
+		quarantine         TEXT,
+		maintenance        TEXT,
+		depends_on         TEXT
+	);
+	CREATE TABLE restart_history (
+		id            INTEGER PRIMARY KEY AUTOINCREMENT,
+		service_name  TEXT NOT NULL REFERENCES watchlist (service_name) ON DELETE CASCADE,
+		restarted_at  TEXT NOT NULL,
+		restart_count INTEGER NOT NULL
+	);
+	CREATE INDEX restart_history_service ON restart_history (service_name, id);
+	CREATE TABLE meta (
+		key   TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	);`,
+	`CREATE TABLE schedules (
+		id           TEXT PRIMARY KEY,
+		name         TEXT NOT NULL DEFAULT '',
+		cron         TEXT NOT NULL,
+		action       TEXT NOT NULL,
+		service_name TEXT NOT NULL DEFAULT '',
+		tag          TEXT NOT NULL DEFAULT '',
+		hold         INTEGER NOT NULL DEFAULT 0 -- Nanoseconds
+	);`,
+}
+
+// itemColumns are the watchlist columns in the order scanItem reads them.
+const itemColumns = `service_name, auto_restart, restart_count, fail_count, last_restart, restart_dependents,
+	tags, restart_policy, hooks, health_checks, thresholds, quarantine, maintenance, depends_on`
+
+type sqliteWatchlist struct {
+	db         *sql.DB
+	svcManager core.ServiceManager
+}
+
+// OpenSQLite opens the database at dbPath that keeps the watchlist and schedules,
+// creating it and applying any pending migrations. The first time, items and
+// schedules are imported from the JSON files at watchlistImport and
+// schedulesImport if they exist; the files are left as they are.
+func OpenSQLite(dbPath, watchlistImport, schedulesImport string, svcManager core.ServiceManager) (core.WatchlistManager, core.ScheduleManager, error) {
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return nil, nil, err
+	}
+	db, err := sql.Open("sqlite", "file:"+dbPath+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, nil, err
+	}
+	// One connection serialises transactions, so writers never see SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	if err := migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, nil, fmt.Errorf("failed to migrate %s: %w", dbPath, err)
+	}
+	if err := importWatchlist(ctx, db, watchlistImport); err != nil {
+		db.Close()
+		return nil, nil, fmt.Errorf("failed to import %s: %w", watchlistImport, err)
+	}
+	if err := importSchedules(ctx, db, schedulesImport); err != nil {
+		db.Close()
+		return nil, nil, fmt.Errorf("failed to import %s: %w", schedulesImport, err)
+	}
+	return &sqliteWatchlist{db: db, svcManager: svcManager}, &sqliteSchedules{db: db}, nil
+}
+
+// migrate applies the migrations the database hasn't had yet, each in its own transaction.
+func migrate(ctx context.Context, db *sql.DB) error {
+	var version int
+	if err := db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("schema version %d is newer than this build supports (%d)", version, len(migrations))
+	}
+	for ; version < len(migrations); version++ {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, migrations[version]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", version+1, err)
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", version+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importOnce runs fn to fill a new table from the JSON file at path, unless the
+// import named key has already run. It is recorded in meta even when there was
+// no file, so it never runs again.
+func importOnce(ctx context.Context, db *sql.DB, key, path string, fn func(tx *sql.Tx) (int, error)) error {
+	return inTx(ctx, db, func(tx *sql.Tx) error {
+		var done string
+		err := tx.QueryRowContext(ctx, "SELECT value FROM meta WHERE key = ?", key).Scan(&done)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		imported, err := fn(tx)
+		if err != nil {
+			return err
+		}
+		note := fmt.Sprintf("%d rows from %s at %s", imported, path, time.Now().Format(time.RFC3339))
+		_, err = tx.ExecContext(ctx, "INSERT INTO meta (key, value) VALUES (?, ?)", key, note)
+		return err
+	})
+}
+
+// importWatchlist copies the items of a JSON watchlist into a new database.
+func importWatchlist(ctx context.Context, db *sql.DB, path string) error {
+	return importOnce(ctx, db, "json_import", path, func(tx *sql.Tx) (int, error) {
+		source := &jsonWatchlist{filepath: path, items: make(map[string]*core.WatchlistItem)}
+		if err := source.load(); err != nil {
+			return 0, err
+		}
+		for _, item := range source.items {
+			if err := saveItem(ctx, tx, item); err != nil {
+				return 0, err
+			}
+		}
+		return len(source.items), nil
+	})
+}
+
+// List implements core.WatchlistManager.
+func (s *sqliteWatchlist) List(ctx context.Context) ([]core.WatchlistItem, error) {
+	items, err := loadItems(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		// Populate current service state
+		if svc, err := s.svcManager.Get(ctx, items[i].ServiceName); err == nil {
+			items[i].Service = &svc
+		}
+	}
+	return items, nil
+}
+
+// Get implements core.WatchlistManager.
+func (s *sqliteWatchlist) Get(ctx context.Context, serviceName string) (core.WatchlistItem, error) {
+	item, err := loadItem(ctx, s.db, serviceName)
+	if err != nil {
+		return core.WatchlistItem{}, err
+	}
+	// Populate current service state
+	if svc, err := s.svcManager.Get(ctx, serviceName); err == nil {
+		item.Service = &svc
+	}
+	return item, nil
+}
+
+// Add implements core.WatchlistManager.
+func (s *sqliteWatchlist) Add(ctx context.Context, serviceName string, autoRestart bool) error {
+	// Verify service exists
+	if _, err := s.svcManager.Get(ctx, serviceName); err != nil {
+		return fmt.Errorf("service not found: %s", serviceName)
+	}
+
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		if _, err := loadItem(ctx, tx, serviceName); err == nil {
+			return fmt.Errorf("service already in watchlist: %s", serviceName)
+		}
+		return saveItem(ctx, tx, &core.WatchlistItem{ServiceName: serviceName, AutoRestart: autoRestart})
+	})
+}
+
+// Remove implements core.WatchlistManager.
+func (s *sqliteWatchlist) Remove(ctx context.Context, serviceName string) error {
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		items, err := loadItems(ctx, tx)
+		if err != nil {
+			return err
+		}
+		if err := core.ValidateRemoval(items, serviceName); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "DELETE FROM watchlist WHERE service_name = ?", serviceName)
+		return err
+	})
+}
+
+// Update implements core.WatchlistManager.
+func (s *sqliteWatchlist) Update(ctx context.Context, serviceName string, autoRestart bool) error {
+	return s.modify(ctx, serviceName, func(item *core.WatchlistItem) error {
+		if autoRestart {
+			item.FailCount = 0
+		}
+		item.AutoRestart = autoRestart
+		return nil
+	})
+}
+
+// IncrementRestartCount implements core.WatchlistManager. The restart is
+// recorded in restart_history in the same transaction.
+func (s *sqliteWatchlist) IncrementRestartCount(ctx context.Context, serviceName string) error {
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		item, err := loadItem(ctx, tx, serviceName)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		item.RestartCount++
+		item.LastRestart = now.Format(time.RFC3339)
+		if err := saveItem(ctx, tx, &item); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO restart_history (service_name, restarted_at, restart_count) VALUES (?, ?, ?)",
+			serviceName, now.UTC().Format(time.RFC3339Nano), item.RestartCount)
+		return err
+	})
+}
+
+// Restarts implements core.RestartHistory.
+func (s *sqliteWatchlist) Restarts(ctx context.Context, serviceName string, limit int) ([]core.RestartRecord, error) {
+	if _, err := loadItem(ctx, s.db, serviceName); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT restarted_at, restart_count FROM restart_history WHERE service_name = ? ORDER BY id DESC LIMIT ?",
+		serviceName, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []core.RestartRecord{}
+	for rows.Next() {
+		record := core.RestartRecord{ServiceName: serviceName}
+		var restartedAt string
+		if err := rows.Scan(&restartedAt, &record.RestartCount); err != nil {
+			return nil, err
+		}
+		record.Time, _ = time.Parse(time.RFC3339Nano, restartedAt)
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// IncrementFailCount implements core.WatchlistManager.
+func (s *sqliteWatchlist) IncrementFailCount(ctx context.Context, serviceName string) error {
+	return s.modify(ctx, serviceName, func(item *core.WatchlistItem) error {
+		item.FailCount++
+		return nil
+	})
+}
+
+// ResetFailCount implements core.WatchlistManager.
+func (s *sqliteWatchlist) ResetFailCount(ctx context.Context, serviceName string) error {
+	return s.modify(ctx, serviceName, func(item *core.WatchlistItem) error {
+		item.FailCount = 0
+		return nil
+	})
+}
+
+// SetRestartPolicy implements core.WatchlistManager.
+func (s *sqliteWatchlist) SetRestartPolicy(ctx context.Context, serviceName string, policy *core.RestartPolicy) error {
+	if policy != nil {
+		if err := policy.Validate(); err != nil {
+			return err
+		}
+	}
+	return s.modify(ctx, serviceName, func(item *core.WatchlistItem) error {
+		item.RestartPolicy = policy
+		return nil
+	})
+}
+
+// SetRestartHooks implements core.WatchlistManager.
+func (s *sqliteWatchlist) SetRestartHooks(ctx context.Context, serviceName string, hooks *core.RestartHooks) error {
+	if hooks != nil {
+		if err := hooks.Validate(); err != nil {
+			return err
+		}
+	}
+	return s.modify(ctx, serviceName, func(item *core.WatchlistItem) error {
+		item.Hooks = hooks
+		return nil
+	})
+}
+
+// SetHealthChecks implements core.WatchlistManager.
+func (s *sqliteWatchlist) SetHealthChecks(ctx context.Context, serviceName string, checks []core.HealthCheck) error {
+	if err := core.ValidateHealthChecks(checks); err != nil {
+		return err
+	}
+	return s.modify(ctx, serviceName, func(item *core.WatchlistItem) error {
+		item.HealthChecks = checks
+		return nil
+	})
+}
+
+// SetThresholdRules implements core.WatchlistManager.
+func (s *sqliteWatchlist) SetThresholdRules(ctx context.Context, serviceName string, rules []core.ThresholdRule) error {
+	if err := core.ValidateThresholdRules(rules); err != nil {
+		return err
+	}
+	return s.modify(ctx, serviceName, func(item *core.WatchlistItem) error {
+		item.Thresholds = rules
+		return nil
+	})
+}
+
+// SetQuarantine implements core.WatchlistManager.
+func (s *sqliteWatchlist) SetQuarantine(ctx context.Context, serviceName string, quarantine *core.Quarantine) error {
+	return s.modify(ctx, serviceName, func(item *core.WatchlistItem) error {
+		item.Quarantine = quarantine
+		return nil
+	})
+}
+
+// AddMaintenanceWindow implements core.WatchlistManager.
+// Ad-hoc windows that have already closed are pruned at the same time.
+func (s *sqliteWatchlist) AddMaintenanceWindow(ctx context.Context, serviceName string, window core.MaintenanceWindow) (core.MaintenanceWindow, error) {
+	if err := window.Validate(); err != nil {
+		return core.MaintenanceWindow{}, err
+	}
+	if window.ID == "" {
+		window.ID = newID()
+	}
+
+	err := s.modify(ctx, serviceName, func(item *core.WatchlistItem) error {
+		return item.AddMaintenanceWindow(window, time.Now())
+	})
+	return window, err
+}
+
+// RemoveMaintenanceWindow implements core.WatchlistManager.
+func (s *sqliteWatchlist) RemoveMaintenanceWindow(ctx context.Context, serviceName string, id string) error {
+	return s.modify(ctx, serviceName, func(item *core.WatchlistItem) error {
+		return item.RemoveMaintenanceWindow(id)
+	})
+}
+
+// SetDependencies implements core.WatchlistManager.
+func (s *sqliteWatchlist) SetDependencies(ctx context.Context, serviceName string, dependsOn []string) error {
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		items, err := loadItems(ctx, tx)
+		if err != nil {
+			return err
+		}
+		if err := core.ValidateDependencies(items, serviceName, dependsOn); err != nil {
+			return err
+		}
+
+		item, err := loadItem(ctx, tx, serviceName)
+		if err != nil {
+			return err
+		}
+		item.DependsOn = dependsOn
+		return saveItem(ctx, tx, &item)
+	})
+}
+
+// SetRestartDependents implements core.WatchlistManager.
+func (s *sqliteWatchlist) SetRestartDependents(ctx context.Context, serviceName string, restartDependents bool) error {
+	return s.modify(ctx, serviceName, func(item *core.WatchlistItem) error {
+		item.RestartDependents = restartDependents
+		return nil
+	})
+}
+
+// SetTags implements core.WatchlistManager.
+func (s *sqliteWatchlist) SetTags(ctx context.Context, serviceName string, tags []string) error {
+	if err := core.ValidateTags(tags); err != nil {
+		return err
+	}
+	return s.modify(ctx, serviceName, func(item *core.WatchlistItem) error {
+		item.Tags = tags
+		return nil
+	})
+}
+
+// UpdateItem implements core.WatchlistManager.
+func (s *sqliteWatchlist) UpdateItem(ctx context.Context, serviceName string, update core.WatchlistUpdate) error {
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		items, err := loadItems(ctx, tx)
+		if err != nil {
+			return err
+		}
+		item, err := loadItem(ctx, tx, serviceName)
+		if err != nil {
+			return err
+		}
+		if err := update.Apply(items, &item); err != nil {
+			return err
+		}
+		return saveItem(ctx, tx, &item)
+	})
+}
+
+// inTx runs fn in a transaction, committing if it succeeds.
+func inTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// modify applies fn to a watchlist item and saves the result in one transaction.
+func (s *sqliteWatchlist) modify(ctx context.Context, serviceName string, fn func(item *core.WatchlistItem) error) error {
+	return inTx(ctx, s.db, func(tx *sql.Tx) error {
+		item, err := loadItem(ctx, tx, serviceName)
+		if err != nil {
+			return err
+		}
+		if err := fn(&item); err != nil {
+			return err
+		}
+		return saveItem(ctx, tx, &item)
+	})
+}
+
+// querier is satisfied by both *sql.DB and *sql.Tx.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func loadItem(ctx context.Context, q querier, serviceName string) (core.WatchlistItem, error) {
+	row := q.QueryRowContext(ctx, "SELECT "+itemColumns+" FROM watchlist WHERE service_name = ?", serviceName)
+	item, err := scanItem(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return core.WatchlistItem{}, fmt.Errorf("%w: %s", core.ErrNotInWatchlist, serviceName)
+	}
+	return item, err
+}
+
+func loadItems(ctx context.Context, q querier) ([]core.WatchlistItem, error) {
+	rows, err := q.QueryContext(ctx, "SELECT "+itemColumns+" FROM watchlist ORDER BY service_name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []core.WatchlistItem{}
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// scanItem reads a row of itemColumns.
+func scanItem(row interface{ Scan(dest ...any) error }) (core.WatchlistItem, error) {
+	var item core.WatchlistItem
+	var tags, policy, hooks, checks, thresholds, quarantine, maintenance, dependsOn sql.NullString
+	err := row.Scan(&item.ServiceName, &item.AutoRestart, &item.RestartCount, &item.FailCount, &item.LastRestart, &item.RestartDependents,
+		&tags, &policy, &hooks, &checks, &thresholds, &quarantine, &maintenance, &dependsOn)
+	if err != nil {
+		return core.WatchlistItem{}, err
+	}
+
+	columns := []struct {
+		value sql.NullString
+		dest  any
+	}{
+		{tags, &item.Tags},
+		{policy, &item.RestartPolicy},
+		{hooks, &item.Hooks},
+		{checks, &item.HealthChecks},
+		{thresholds, &item.Thresholds},
+		{quarantine, &item.Quarantine},
+		{maintenance, &item.Maintenance},
+		{dependsOn, &item.DependsOn},
+	}
+	for _, column := range columns {
+		if !column.value.Valid {
+			continue
+		}
+		if err := json.Unmarshal([]byte(column.value.String), column.dest); err != nil {
+			return core.WatchlistItem{}, fmt.Errorf("corrupt watchlist row %s: %w", item.ServiceName, err)
+		}
+	}
+	return item, nil
+}
+
+// saveItem inserts or replaces a watchlist row. The embedded service data and
+// status aren't stored, just the watchlist config.
+func saveItem(ctx context.Context, tx *sql.Tx, item *core.WatchlistItem) error {
+	jsonColumns := []struct {
+		value any
+		unset bool
+	}{
+		{item.Tags, len(item.Tags) == 0},
+		{item.RestartPolicy, item.RestartPolicy == nil},
+		{item.Hooks, item.Hooks == nil},
+		{item.HealthChecks, len(item.HealthChecks) == 0},
+		{item.Thresholds, len(item.Thresholds) == 0},
+		{item.Quarantine, item.Quarantine == nil},
+		{item.Maintenance, len(item.Maintenance) == 0},
+		{item.DependsOn, len(item.DependsOn) == 0},
+	}
+	args := []any{item.ServiceName, item.AutoRestart, item.RestartCount, item.FailCount, item.LastRestart, item.RestartDependents}
+	for _, column := range jsonColumns {
+		if column.unset {
+			args = append(args, nil)
+			continue
+		}
+		data, err := json.Marshal(column.value)
+		if err != nil {
+			return err
+		}
+		args = append(args, string(data))
+	}
+
+	_, err := tx.ExecContext(ctx, `INSERT INTO watchlist (`+itemColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (service_name) DO UPDATE SET
+			auto_restart = excluded.auto_restart,
+			restart_count = excluded.restart_count,
+			fail_count = excluded.fail_count,
+			last_restart = excluded.last_restart,
+			restart_dependents = excluded.restart_dependents,
+			tags = excluded.tags,
+			restart_policy = excluded.restart_policy,
+			hooks = excluded.hooks,
+			health_checks = excluded.health_checks,
+			thresholds = excluded.thresholds,
+			quarantine = excluded.quarantine,
+			maintenance = excluded.maintenance,
+			depends_on = excluded.depends_on`, args...)
+	return err
+}
@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+)
+
+// sqliteSchedules keeps schedules in the watchlist's SQLite database.
+type sqliteSchedules struct {
+	db *sql.DB
+}
+
+const scheduleColumns = "id, name, cron, action, service_name, tag, hold"
+
+// importSchedules copies the schedules of a schedules.json file into a new database.
+func importSchedules(ctx context.Context, db *sql.DB, path string) error {
+	return importOnce(ctx, db, "json_schedules_import", path, func(tx *sql.Tx) (int, error) {
+		source := &jsonSchedules{filepath: path, schedules: make(map[string]*core.Schedule)}
+		if err := source.load(); err != nil {
+			return 0, err
+		}
+		for _, schedule := range source.schedules {
+			if err := insertSchedule(ctx, tx, *schedule); err != nil {
+				return 0, err
+			}
+		}
+		return len(source.schedules), nil
+	})
+}
+
+// List implements core.ScheduleManager.
+func (s *sqliteSchedules) List(ctx context.Context) ([]core.Schedule, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+scheduleColumns+" FROM schedules ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []core.Schedule{}
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, rows.Err()
+}
+
+// Get implements core.ScheduleManager.
+func (s *sqliteSchedules) Get(ctx context.Context, id string) (core.Schedule, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT "+scheduleColumns+" FROM schedules WHERE id = ?", id)
+	schedule, err := scanSchedule(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return core.Schedule{}, fmt.Errorf("schedule not found: %s", id)
+	}
+	return schedule, err
+}
+
+// Add implements core.ScheduleManager.
+func (s *sqliteSchedules) Add(ctx context.Context, schedule core.Schedule) (core.Schedule, error) {
+	if err := schedule.Validate(); err != nil {
+		return core.Schedule{}, err
+	}
+	if schedule.ID == "" {
+		schedule.ID = newID()
+	}
+	schedule.Status = nil
+
+	err := inTx(ctx, s.db, func(tx *sql.Tx) error {
+		var exists int
+		err := tx.QueryRowContext(ctx, "SELECT 1 FROM schedules WHERE id = ?", schedule.ID).Scan(&exists)
+		if err == nil {
+			return fmt.Errorf("schedule already exists: %s", schedule.ID)
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		return insertSchedule(ctx, tx, schedule)
+	})
+	if err != nil {
+		return core.Schedule{}, err
+	}
+	return schedule, nil
+}
+
+// Remove implements core.ScheduleManager.
+func (s *sqliteSchedules) Remove(ctx context.Context, id string) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM schedules WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return fmt.Errorf("schedule not found: %s", id)
+	}
+	return nil
+}
+
+func insertSchedule(ctx context.Context, tx *sql.Tx, schedule core.Schedule) error {
+	_, err := tx.ExecContext(ctx, "INSERT INTO schedules ("+scheduleColumns+") VALUES (?, ?, ?, ?, ?, ?, ?)",
+		schedule.ID, schedule.Name, schedule.Cron, schedule.Action, schedule.ServiceName, schedule.Tag, int64(schedule.Hold))
+	return err
+}
+
+func scanSchedule(row interface{ Scan(dest ...any) error }) (core.Schedule, error) {
+	var schedule core.Schedule
+	var hold int64
+	err := row.Scan(&schedule.ID, &schedule.Name, &schedule.Cron, &schedule.Action, &schedule.ServiceName, &schedule.Tag, &hold)
+	schedule.Hold = core.Duration(hold)
+	return schedule, err
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+)
+
+func TestSQLiteSchedules(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "data", "watchlist.db")
+	schedulesJSON := filepath.Join(dir, "schedules.json")
+	err := os.WriteFile(schedulesJSON, []byte(`[{"id": "nightly", "cron": "0 3 * * *", "action": "restart", "serviceName": "web"}]`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, schedules, err := OpenSQLite(dbPath, filepath.Join(dir, "watchlist.json"), schedulesJSON, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := schedules.Get(ctx, "nightly"); err != nil || got.Action != "restart" {
+		t.Fatalf("imported schedule = %+v, %v", got, err)
+	}
+
+	added, err := schedules.Add(ctx, core.Schedule{Cron: "0 18 * * 5", Action: "stop", Tag: "batch", Hold: core.Duration(60 * time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := schedules.Add(ctx, added); err == nil || err.Error() != "schedule already exists: "+added.ID {
+		t.Errorf("adding a duplicate: %v", err)
+	}
+	if _, err := schedules.Add(ctx, core.Schedule{Cron: "bogus", Action: "stop", Tag: "batch"}); err == nil {
+		t.Error("added a schedule with an invalid cron expression")
+	}
+	if err := schedules.Remove(ctx, "nightly"); err != nil {
+		t.Fatal(err)
+	}
+	if err := schedules.Remove(ctx, "nightly"); err == nil || err.Error() != "schedule not found: nightly" {
+		t.Errorf("removing twice: %v", err)
+	}
+
+	// Reopening keeps the changes rather than importing schedules.json again
+	_, schedules, err = OpenSQLite(dbPath, filepath.Join(dir, "watchlist.json"), schedulesJSON, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := schedules.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].ID != added.ID || list[0].Hold != added.Hold || list[0].Tag != "batch" {
+		t.Errorf("after reopening: %+v", list)
+	}
+}
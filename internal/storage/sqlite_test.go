@@ -0,0 +1,214 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+)
+
+// anyService is a ServiceManager on which every service exists.
+type anyService struct{}
+
+func (anyService) List(ctx context.Context) ([]core.Service, error) { return nil, nil }
+func (anyService) Get(ctx context.Context, name string) (core.Service, error) {
+	return core.Service{Name: name, State: "running"}, nil
+}
+func (anyService) Start(ctx context.Context, name string) error   { return nil }
+func (anyService) Stop(ctx context.Context, name string) error    { return nil }
+func (anyService) Restart(ctx context.Context, name string) error { return nil }
+
+func openTestDB(t *testing.T, dir string) core.WatchlistManager {
+	t.Helper()
+	wl, _, err := OpenSQLite(filepath.Join(dir, "data", "watchlist.db"),
+		filepath.Join(dir, "watchlist.json"), filepath.Join(dir, "schedules.json"), anyService{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { wl.(*sqliteWatchlist).db.Close() })
+	return wl
+}
+
+// rawDB opens the test database alongside the store, to look behind its back.
+func rawDB(t *testing.T, dir string) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(dir, "data", "watchlist.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func schemaVersion(t *testing.T, db *sql.DB) int {
+	t.Helper()
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		t.Fatal(err)
+	}
+	return version
+}
+
+func TestMigrate(t *testing.T) {
+	t.Run("new database", func(t *testing.T) {
+		dir := t.TempDir()
+		openTestDB(t, dir)
+		db := rawDB(t, dir)
+		if v := schemaVersion(t, db); v != len(migrations) {
+			t.Errorf("user_version = %d, want %d", v, len(migrations))
+		}
+		for _, table := range []string{"watchlist", "restart_history", "meta", "schedules"} {
+			var name string
+			if err := db.QueryRow("SELECT name FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&name); err != nil {
+				t.Errorf("table %s: %v", table, err)
+			}
+		}
+	})
+
+	t.Run("older database", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.MkdirAll(filepath.Join(dir, "data"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		db := rawDB(t, dir)
+		if _, err := db.Exec(migrations[0] + "PRAGMA user_version = 1;"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec("INSERT INTO watchlist (service_name, auto_restart) VALUES ('web', 1)"); err != nil {
+			t.Fatal(err)
+		}
+
+		wl := openTestDB(t, dir)
+		if v := schemaVersion(t, db); v != len(migrations) {
+			t.Errorf("user_version = %d, want %d", v, len(migrations))
+		}
+		if item, err := wl.Get(context.Background(), "web"); err != nil || !item.AutoRestart {
+			t.Errorf("item from before the migration = %+v, %v", item, err)
+		}
+	})
+
+	t.Run("newer database", func(t *testing.T) {
+		dir := t.TempDir()
+		openTestDB(t, dir)
+		if _, err := rawDB(t, dir).Exec(fmt.Sprintf("PRAGMA user_version = %d", len(migrations)+1)); err != nil {
+			t.Fatal(err)
+		}
+		_, _, err := OpenSQLite(filepath.Join(dir, "data", "watchlist.db"), "", "", anyService{})
+		if err == nil || !strings.Contains(err.Error(), "newer than this build supports") {
+			t.Errorf("opening a newer database: %v", err)
+		}
+	})
+}
+
+func TestImportRunsOnce(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	items := `[
+		{"serviceName": "db", "autoRestart": true, "restartCount": 4, "tags": ["billing"]},
+		{"serviceName": "web", "autoRestart": false, "dependsOn": ["db"]}
+	]`
+	if err := os.WriteFile(filepath.Join(dir, "watchlist.json"), []byte(items), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	wl := openTestDB(t, dir)
+	list, err := wl.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].ServiceName != "db" || !list[0].AutoRestart || list[0].RestartCount != 4 || !list[0].HasTag("billing") ||
+		len(list[1].DependsOn) != 1 || list[1].DependsOn[0] != "db" {
+		t.Fatalf("imported items = %+v", list)
+	}
+	var note string
+	if err := rawDB(t, dir).QueryRow("SELECT value FROM meta WHERE key = 'json_import'").Scan(&note); err != nil || !strings.HasPrefix(note, "2 rows from") {
+		t.Errorf("import note = %q, %v", note, err)
+	}
+
+	// Neither the database's own changes nor later edits to the file are undone by reopening
+	if err := wl.SetDependencies(ctx, "web", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := wl.Remove(ctx, "web"); err != nil {
+		t.Fatal(err)
+	}
+	wl.(*sqliteWatchlist).db.Close()
+	if err := os.WriteFile(filepath.Join(dir, "watchlist.json"), []byte(`[{"serviceName": "other"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err = openTestDB(t, dir).List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].ServiceName != "db" {
+		t.Errorf("after reopening = %+v, want only db", list)
+	}
+}
+
+func TestRestartHistory(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	wl := openTestDB(t, dir)
+	history := wl.(core.RestartHistory)
+	if err := wl.Add(ctx, "web", true); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := wl.IncrementRestartCount(ctx, "web"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	records, err := history.Restarts(ctx, "web", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].RestartCount != 3 || records[1].RestartCount != 2 || records[0].Time.Before(records[1].Time) {
+		t.Errorf("Restarts(web, 2) = %+v, want the last two, newest first", records)
+	}
+
+	if _, err := history.Restarts(ctx, "nope", 10); !errors.Is(err, core.ErrNotInWatchlist) {
+		t.Errorf("Restarts(nope) error = %v, want ErrNotInWatchlist", err)
+	}
+	if err := wl.IncrementRestartCount(ctx, "nope"); !errors.Is(err, core.ErrNotInWatchlist) {
+		t.Errorf("IncrementRestartCount(nope) error = %v, want ErrNotInWatchlist", err)
+	}
+
+	// Removing an item drops its history with it
+	if err := wl.Add(ctx, "db", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := wl.IncrementRestartCount(ctx, "db"); err != nil {
+		t.Fatal(err)
+	}
+	if err := wl.Remove(ctx, "db"); err != nil {
+		t.Fatal(err)
+	}
+	var left int
+	if err := rawDB(t, dir).QueryRow("SELECT count(*) FROM restart_history WHERE service_name = 'db'").Scan(&left); err != nil || left != 0 {
+		t.Errorf("%d history rows left for a removed item (%v)", left, err)
+	}
+
+	// The count and the history are written together: when recording the restart
+	// fails, the count doesn't move either
+	if _, err := rawDB(t, dir).Exec("DROP TABLE restart_history"); err != nil {
+		t.Fatal(err)
+	}
+	if err := wl.IncrementRestartCount(ctx, "web"); err == nil {
+		t.Fatal("IncrementRestartCount succeeded without a restart_history table")
+	}
+	item, err := wl.Get(ctx, "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.RestartCount != 3 {
+		t.Errorf("RestartCount = %d after a failed restart record, want 3", item.RestartCount)
+	}
+}
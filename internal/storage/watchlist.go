@@ -2,6 +2,8 @@ package storage
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -52,7 +54,7 @@ func (j *jsonWatchlist) Get(ctx context.Context, serviceName string) (core.Watch
 	j.mutex.RUnlock()
 
 	if !exists {
-		return core.WatchlistItem{}, fmt.Errorf("service not in watchlist: %s", serviceName)
+		return core.WatchlistItem{}, fmt.Errorf("%w: %s", core.ErrNotInWatchlist, serviceName)
 	}
 
 	result := *item
@@ -91,8 +93,8 @@ func (j *jsonWatchlist) Remove(ctx context.Context, serviceName string) error {
 	j.mutex.Lock()
 	defer j.mutex.Unlock()
 
-	if _, exists := j.items[serviceName]; !exists {
-		return fmt.Errorf("service not in watchlist: %s", serviceName)
+	if err := core.ValidateRemoval(j.snapshot(), serviceName); err != nil {
+		return err
 	}
 
 	delete(j.items, serviceName)
@@ -106,7 +108,7 @@ func (j *jsonWatchlist) Update(ctx context.Context, serviceName string, autoRest
 
 	item, exists := j.items[serviceName]
 	if !exists {
-		return fmt.Errorf("service not in watchlist: %s", serviceName)
+		return fmt.Errorf("%w: %s", core.ErrNotInWatchlist, serviceName)
 	}
 	if autoRestart {
 		item.FailCount = 0
@@ -139,13 +141,10 @@ func (j *jsonWatchlist) save() error {
 	items := make([]core.WatchlistItem, 0, len(j.items))
 	for _, item := range j.items {
 		// Don't save the embedded service data, just the watchlist config
-		items = append(items, core.WatchlistItem{
-			ServiceName:  item.ServiceName,
-			AutoRestart:  item.AutoRestart,
-			RestartCount: item.RestartCount,
-			LastRestart:  item.LastRestart,
-			FailCount:    item.FailCount,
-		})
+		saved := *item
+		saved.Service = nil
+		saved.Status = nil
+		items = append(items, saved)
 	}
 
 	data, err := json.MarshalIndent(items, "", "  ")
@@ -163,10 +162,175 @@ func (j *jsonWatchlist) IncrementRestartCount(ctx context.Context, serviceName s
 
 	item, exists := j.items[serviceName]
 	if !exists {
-		return fmt.Errorf("service not in watchlist: %s", serviceName)
+		return fmt.Errorf("%w: %s", core.ErrNotInWatchlist, serviceName)
 	}
 
 	item.RestartCount++
 	item.LastRestart = time.Now().Format(time.RFC3339)
 	return j.save()
 }
+
+// IncrementFailCount implements core.WatchlistManager.
+func (j *jsonWatchlist) IncrementFailCount(ctx context.Context, serviceName string) error {
+	return j.modify(serviceName, func(item *core.WatchlistItem) error {
+		item.FailCount++
+		return nil
+	})
+}
+
+// ResetFailCount implements core.WatchlistManager.
+func (j *jsonWatchlist) ResetFailCount(ctx context.Context, serviceName string) error {
+	return j.modify(serviceName, func(item *core.WatchlistItem) error {
+		item.FailCount = 0
+		return nil
+	})
+}
+
+// SetRestartPolicy implements core.WatchlistManager.
+func (j *jsonWatchlist) SetRestartPolicy(ctx context.Context, serviceName string, policy *core.RestartPolicy) error {
+	if policy != nil {
+		if err := policy.Validate(); err != nil {
+			return err
+		}
+	}
+	return j.modify(serviceName, func(item *core.WatchlistItem) error {
+		item.RestartPolicy = policy
+		return nil
+	})
+}
+
+// SetRestartHooks implements core.WatchlistManager.
+func (j *jsonWatchlist) SetRestartHooks(ctx context.Context, serviceName string, hooks *core.RestartHooks) error {
+	if hooks != nil {
+		if err := hooks.Validate(); err != nil {
+			return err
+		}
+	}
+	return j.modify(serviceName, func(item *core.WatchlistItem) error {
+		item.Hooks = hooks
+		return nil
+	})
+}
+
+// SetHealthChecks implements core.WatchlistManager.
+func (j *jsonWatchlist) SetHealthChecks(ctx context.Context, serviceName string, checks []core.HealthCheck) error {
+	if err := core.ValidateHealthChecks(checks); err != nil {
+		return err
+	}
+	return j.modify(serviceName, func(item *core.WatchlistItem) error {
+		item.HealthChecks = checks
+		return nil
+	})
+}
+
+// SetThresholdRules implements core.WatchlistManager.
+func (j *jsonWatchlist) SetThresholdRules(ctx context.Context, serviceName string, rules []core.ThresholdRule) error {
+	if err := core.ValidateThresholdRules(rules); err != nil {
+		return err
+	}
+	return j.modify(serviceName, func(item *core.WatchlistItem) error {
+		item.Thresholds = rules
+		return nil
+	})
+}
+
+// SetQuarantine implements core.WatchlistManager.
+func (j *jsonWatchlist) SetQuarantine(ctx context.Context, serviceName string, quarantine *core.Quarantine) error {
+	return j.modify(serviceName, func(item *core.WatchlistItem) error {
+		item.Quarantine = quarantine
+		return nil
+	})
+}
+
+// AddMaintenanceWindow implements core.WatchlistManager.
+// Ad-hoc windows that have already closed are pruned at the same time.
+func (j *jsonWatchlist) AddMaintenanceWindow(ctx context.Context, serviceName string, window core.MaintenanceWindow) (core.MaintenanceWindow, error) {
+	if err := window.Validate(); err != nil {
+		return core.MaintenanceWindow{}, err
+	}
+	if window.ID == "" {
+		window.ID = newID()
+	}
+
+	err := j.modify(serviceName, func(item *core.WatchlistItem) error {
+		return item.AddMaintenanceWindow(window, time.Now())
+	})
+	return window, err
+}
+
+// RemoveMaintenanceWindow implements core.WatchlistManager.
+func (j *jsonWatchlist) RemoveMaintenanceWindow(ctx context.Context, serviceName string, id string) error {
+	return j.modify(serviceName, func(item *core.WatchlistItem) error {
+		return item.RemoveMaintenanceWindow(id)
+	})
+}
+
+// SetDependencies implements core.WatchlistManager.
+func (j *jsonWatchlist) SetDependencies(ctx context.Context, serviceName string, dependsOn []string) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if err := core.ValidateDependencies(j.snapshot(), serviceName, dependsOn); err != nil {
+		return err
+	}
+
+	j.items[serviceName].DependsOn = dependsOn
+	return j.save()
+}
+
+// SetRestartDependents implements core.WatchlistManager.
+func (j *jsonWatchlist) SetRestartDependents(ctx context.Context, serviceName string, restartDependents bool) error {
+	return j.modify(serviceName, func(item *core.WatchlistItem) error {
+		item.RestartDependents = restartDependents
+		return nil
+	})
+}
+
+// SetTags implements core.WatchlistManager.
+func (j *jsonWatchlist) SetTags(ctx context.Context, serviceName string, tags []string) error {
+	if err := core.ValidateTags(tags); err != nil {
+		return err
+	}
+	return j.modify(serviceName, func(item *core.WatchlistItem) error {
+		item.Tags = tags
+		return nil
+	})
+}
+
+// UpdateItem implements core.WatchlistManager.
+func (j *jsonWatchlist) UpdateItem(ctx context.Context, serviceName string, update core.WatchlistUpdate) error {
+	return j.modify(serviceName, func(item *core.WatchlistItem) error {
+		return update.Apply(j.snapshot(), item)
+	})
+}
+
+// snapshot copies the watchlist config. Caller must hold the mutex.
+func (j *jsonWatchlist) snapshot() []core.WatchlistItem {
+	items := make([]core.WatchlistItem, 0, len(j.items))
+	for _, item := range j.items {
+		items = append(items, *item)
+	}
+	return items
+}
+
+// modify applies fn to a watchlist item under the write lock and saves the result.
+func (j *jsonWatchlist) modify(serviceName string, fn func(item *core.WatchlistItem) error) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	item, exists := j.items[serviceName]
+	if !exists {
+		return fmt.Errorf("%w: %s", core.ErrNotInWatchlist, serviceName)
+	}
+	if err := fn(item); err != nil {
+		return err
+	}
+	return j.save()
+}
+
+// newID returns a short random identifier.
+func newID() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
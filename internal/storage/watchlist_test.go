@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+)
+
+func TestUpdateItem(t *testing.T) {
+	stores := []struct {
+		name string
+		open func(t *testing.T) core.WatchlistManager
+	}{
+		{"json", func(t *testing.T) core.WatchlistManager {
+			return NewJSONWatchlist(filepath.Join(t.TempDir(), "watchlist.json"), anyService{})
+		}},
+		{"sqlite", func(t *testing.T) core.WatchlistManager { return openTestDB(t, t.TempDir()) }},
+	}
+
+	for _, store := range stores {
+		t.Run(store.name, func(t *testing.T) {
+			ctx := context.Background()
+			wl := store.open(t)
+			for _, name := range []string{"db", "api"} {
+				if err := wl.Add(ctx, name, false); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := wl.SetDependencies(ctx, "api", []string{"db"}); err != nil {
+				t.Fatal(err)
+			}
+			before, _ := wl.Get(ctx, "db")
+
+			// A cycle in dependsOn rejects the fields before it too
+			autoRestart := true
+			tags := []string{"backend"}
+			cycle := []string{"api"}
+			err := wl.UpdateItem(ctx, "db", core.WatchlistUpdate{AutoRestart: &autoRestart, Tags: &tags, DependsOn: &cycle})
+			if err == nil {
+				t.Fatal("dependency cycle accepted")
+			}
+			after, _ := wl.Get(ctx, "db")
+			if !reflect.DeepEqual(before, after) {
+				t.Errorf("rejected update changed the item: %+v -> %+v", before, after)
+			}
+
+			if err := wl.UpdateItem(ctx, "db", core.WatchlistUpdate{AutoRestart: &autoRestart, Tags: &tags}); err != nil {
+				t.Fatal(err)
+			}
+			after, _ = wl.Get(ctx, "db")
+			if !after.AutoRestart || !after.HasTag("backend") {
+				t.Errorf("update not applied: %+v", after)
+			}
+
+			// Clearing the policy is a change, not an absent field
+			policy := &core.RestartPolicy{MaxAttempts: 3}
+			if err := wl.UpdateItem(ctx, "db", core.WatchlistUpdate{RestartPolicy: &policy}); err != nil {
+				t.Fatal(err)
+			}
+			policy = nil
+			if err := wl.UpdateItem(ctx, "db", core.WatchlistUpdate{RestartPolicy: &policy}); err != nil {
+				t.Fatal(err)
+			}
+			if after, _ = wl.Get(ctx, "db"); after.RestartPolicy != nil {
+				t.Errorf("restart policy = %+v, want cleared", after.RestartPolicy)
+			}
+
+			err = wl.UpdateItem(ctx, "missing", core.WatchlistUpdate{AutoRestart: &autoRestart})
+			if !errors.Is(err, core.ErrNotInWatchlist) {
+				t.Errorf("update of missing item = %v, want ErrNotInWatchlist", err)
+			}
+		})
+	}
+}
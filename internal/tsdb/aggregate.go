@@ -0,0 +1,120 @@
+package tsdb
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Bucket is one step of an aggregated series.
+type Bucket struct {
+	Time  time.Time `json:"time"` // Bucket start
+	Value float64   `json:"value"`
+	Count int       `json:"count"` // Samples in the bucket
+}
+
+// Summary describes a series over a whole range.
+type Summary struct {
+	Count int        `json:"count"`
+	Min   float64    `json:"min"`
+	Max   float64    `json:"max"`
+	Avg   float64    `json:"avg"`
+	P95   float64    `json:"p95"`
+	Last  float64    `json:"last"`
+	From  *time.Time `json:"from,omitempty"` // First point in the range
+	To    *time.Time `json:"to,omitempty"`   // Last point in the range
+}
+
+// Aggregate groups points into buckets of step aligned to the epoch and reduces
+// each with agg: avg, min, max or p95. Empty buckets are left out. Percentiles of
+// downsampled points are taken over their averages, so they are approximate.
+func Aggregate(points []Point, step time.Duration, agg string) ([]Bucket, error) {
+	if step <= 0 {
+		return nil, fmt.Errorf("step must be positive")
+	}
+	reduce, ok := reducers[agg]
+	if !ok {
+		return nil, fmt.Errorf("agg must be one of avg, min, max, p95")
+	}
+
+	buckets := []Bucket{}
+	for start := 0; start < len(points); {
+		bucketTime := points[start].Time.Truncate(step)
+		end := start
+		for end < len(points) && points[end].Time.Truncate(step).Equal(bucketTime) {
+			end++
+		}
+		group := points[start:end]
+		count := 0
+		for _, p := range group {
+			count += p.Count
+		}
+		buckets = append(buckets, Bucket{Time: bucketTime, Value: reduce(group), Count: count})
+		start = end
+	}
+	return buckets, nil
+}
+
+// Summarize computes statistics over all points.
+func Summarize(points []Point) Summary {
+	if len(points) == 0 {
+		return Summary{}
+	}
+	var total Point
+	for _, p := range points {
+		total.merge(p)
+	}
+	last := points[len(points)-1]
+	return Summary{
+		Count: total.Count,
+		Min:   total.Min,
+		Max:   total.Max,
+		Avg:   total.Avg(),
+		P95:   percentile(points, 0.95),
+		Last:  last.Avg(),
+		From:  &points[0].Time,
+		To:    &last.Time,
+	}
+}
+
+var reducers = map[string]func([]Point) float64{
+	"avg": func(points []Point) float64 {
+		var total Point
+		for _, p := range points {
+			total.merge(p)
+		}
+		return total.Avg()
+	},
+	"min": func(points []Point) float64 {
+		min := math.Inf(1)
+		for _, p := range points {
+			min = math.Min(min, p.Min)
+		}
+		return min
+	},
+	"max": func(points []Point) float64 {
+		max := math.Inf(-1)
+		for _, p := range points {
+			max = math.Max(max, p.Max)
+		}
+		return max
+	},
+	"p95": func(points []Point) float64 {
+		return percentile(points, 0.95)
+	},
+}
+
+// percentile returns the nearest-rank percentile q of the point averages.
+func percentile(points []Point, q float64) float64 {
+	values := make([]float64, len(points))
+	for i, p := range points {
+		values[i] = p.Avg()
+	}
+	sort.Float64s(values)
+	rank := int(math.Ceil(q*float64(len(values)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return values[rank]
+}
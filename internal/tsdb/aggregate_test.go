@@ -0,0 +1,121 @@
+package tsdb
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func sample(t time.Time, value float64) Point {
+	return Point{Time: t, Min: value, Max: value, Sum: value, Count: 1}
+}
+
+func TestAggregate(t *testing.T) {
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	points := []Point{
+		sample(start.Add(3*time.Minute), 4),
+		sample(start.Add(4*time.Minute), 8),
+		sample(start.Add(7*time.Minute), 1),
+		// Nothing between 12:10 and 12:15
+		sample(start.Add(16*time.Minute), 5),
+		{Time: start.Add(18 * time.Minute), Min: 1, Max: 9, Sum: 15, Count: 3}, // Downsampled
+	}
+	tests := []struct {
+		agg  string
+		step time.Duration
+		want []Bucket
+	}{
+		{
+			agg:  "avg",
+			step: 5 * time.Minute,
+			want: []Bucket{{start, 6, 2}, {start.Add(5 * time.Minute), 1, 1}, {start.Add(15 * time.Minute), 5, 4}},
+		},
+		{
+			agg:  "min",
+			step: 5 * time.Minute,
+			want: []Bucket{{start, 4, 2}, {start.Add(5 * time.Minute), 1, 1}, {start.Add(15 * time.Minute), 1, 4}},
+		},
+		{
+			agg:  "max",
+			step: 5 * time.Minute,
+			want: []Bucket{{start, 8, 2}, {start.Add(5 * time.Minute), 1, 1}, {start.Add(15 * time.Minute), 9, 4}},
+		},
+		{
+			// Over point averages, so the downsampled point counts as 5
+			agg:  "p95",
+			step: 5 * time.Minute,
+			want: []Bucket{{start, 8, 2}, {start.Add(5 * time.Minute), 1, 1}, {start.Add(15 * time.Minute), 5, 4}},
+		},
+		{
+			// Buckets start on whole steps, not at the first point
+			agg:  "max",
+			step: time.Hour,
+			want: []Bucket{{start, 9, 7}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.agg+"/"+tt.step.String(), func(t *testing.T) {
+			got, err := Aggregate(points, tt.step, tt.agg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buckets = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got, err := Aggregate(nil, time.Minute, "avg"); err != nil || got == nil || len(got) != 0 {
+		t.Errorf("empty range = %#v, %v, want no buckets", got, err)
+	}
+	if _, err := Aggregate(points, 0, "avg"); err == nil {
+		t.Error("no error for a zero step")
+	}
+	if _, err := Aggregate(points, time.Minute, "median"); err == nil {
+		t.Error("no error for an unknown agg")
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	var points []Point
+	for n := 1; n <= 20; n++ {
+		points = append(points, sample(start.Add(time.Duration(n)*time.Minute), float64(n)))
+	}
+	points = append(points, Point{Time: start.Add(time.Hour), Min: 2, Max: 40, Sum: 63, Count: 3})
+
+	got := Summarize(points)
+	first, last := start.Add(time.Minute), start.Add(time.Hour)
+	want := Summary{Count: 23, Min: 1, Max: 40, Avg: 273.0 / 23, P95: 20, Last: 21, From: &first, To: &last}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("summary = %+v, want %+v", got, want)
+	}
+
+	if got := Summarize(nil); !reflect.DeepEqual(got, Summary{}) {
+		t.Errorf("empty summary = %+v, want zero", got)
+	}
+}
+
+func TestPickTier(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		from time.Time
+		step time.Duration
+		want string
+	}{
+		{name: "fine step", from: now.Add(-time.Hour), step: 10 * time.Second, want: "raw"},
+		{name: "minute step", from: now.Add(-time.Hour), step: time.Minute, want: "1m"},
+		{name: "hour step", from: now.Add(-time.Hour), step: 2 * time.Hour, want: "1h"},
+		{name: "raw expired", from: now.Add(-48 * time.Hour), step: time.Second, want: "1m"},
+		{name: "minutes expired", from: now.AddDate(0, 0, -60), step: time.Minute, want: "1h"},
+		{name: "beyond retention", from: now.AddDate(-2, 0, 0), step: time.Minute, want: "1h"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PickTier(tt.from, tt.step, now); got.Name != tt.want {
+				t.Errorf("tier = %s, want %s", got.Name, tt.want)
+			}
+		})
+	}
+}
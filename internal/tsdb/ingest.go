@@ -0,0 +1,107 @@
+package tsdb
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethan-mdev/service-watch/internal/core"
+	"github.com/ethan-mdev/service-watch/internal/logger"
+	"github.com/ethan-mdev/service-watch/internal/sse"
+)
+
+// sampleFields lists the numeric fields stored from each sample event.
+var sampleFields = map[string][]string{
+	"service_status": {"cpuPercent", "memoryMB", "uptimeSec"},
+	"host_resources": {"cpuPercent", "usedMB", "usedPercent"},
+}
+
+// Fields lists the fields stored for services and for the host.
+func Fields(series string) []string {
+	if series == HostSeries {
+		return sampleFields["host_resources"]
+	}
+	return sampleFields["service_status"]
+}
+
+// Run subscribes to the broadcaster and stores samples from service_status and
+// host_resources events until ctx is cancelled, then closes the store.
+func (s *Store) Run(ctx context.Context, broadcaster *sse.Broadcaster, log *logger.Logger) {
+	sub := broadcaster.Subscribe(ctx, "metrics_store", log)
+	defer sub.Close()
+
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	// Only the first of a run of failures is logged, so a full disk doesn't flood the log
+	failing := false
+	report := func(err error) {
+		if err != nil && !failing {
+			log.Error("metrics_store_failed", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+		failing = err != nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.Close()
+			return
+		case event := <-sub.Events:
+			if _, ok := sampleFields[event.Type]; ok {
+				report(s.Record(event))
+			}
+		case now := <-ticker.C:
+			report(s.Compact(now))
+		}
+	}
+}
+
+// Record stores the numeric fields of a sample event. Other events are ignored.
+func (s *Store) Record(event core.Event) error {
+	fields, ok := sampleFields[event.Type]
+	if !ok {
+		return nil
+	}
+	data, _ := event.Data.(map[string]interface{})
+	series := HostSeries
+	if event.Type == "service_status" {
+		series, _ = data["serviceName"].(string)
+		if series == "" {
+			return nil
+		}
+	}
+	t := event.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+
+	for _, field := range fields {
+		value, ok := toFloat(data[field])
+		if !ok {
+			continue
+		}
+		if err := s.Append(series, field, t, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	default:
+		return 0, false
+	}
+}
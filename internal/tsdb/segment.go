@@ -0,0 +1,163 @@
+package tsdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Records are a little-endian key length, the key, the point time in unix
+// nanoseconds, min, max and sum as float64 bits and the count.
+const recordFixedSize = 2 + 8 + 8 + 8 + 8 + 4
+
+// segment is one append-only file of a tier, holding the points of every
+// series for a fixed span of time. Its index lives in memory.
+type segment struct {
+	start   time.Time
+	end     time.Time
+	path    string
+	size    int64
+	offsets map[string][]int64 // Record offsets by key, in append order
+	file    *os.File           // Open for appending once written to
+}
+
+func segmentPath(dir string, tier Tier, start time.Time) string {
+	return filepath.Join(dir, tier.Name, strconv.FormatInt(start.Unix(), 10)+".seg")
+}
+
+// loadSegment indexes an existing segment file. A torn record at the end, left by
+// a crash mid-write, is cut off.
+func loadSegment(path string, span time.Duration) (*segment, error) {
+	unix, err := strconv.ParseInt(strings.TrimSuffix(filepath.Base(path), ".seg"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected segment name: %s", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	seg := &segment{
+		start:   time.Unix(unix, 0),
+		path:    path,
+		offsets: make(map[string][]int64),
+	}
+	seg.end = seg.start.Add(span)
+	for seg.size < int64(len(data)) {
+		key, _, n, ok := decodeRecord(data[seg.size:])
+		if !ok {
+			if err := os.Truncate(path, seg.size); err != nil {
+				return nil, err
+			}
+			break
+		}
+		seg.offsets[key] = append(seg.offsets[key], seg.size)
+		seg.size += int64(n)
+	}
+	return seg, nil
+}
+
+func (seg *segment) append(key string, p Point) error {
+	if seg.file == nil {
+		if err := os.MkdirAll(filepath.Dir(seg.path), 0755); err != nil {
+			return err
+		}
+		file, err := os.OpenFile(seg.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		seg.file = file
+	}
+	record := encodeRecord(key, p)
+	if _, err := seg.file.Write(record); err != nil {
+		return err
+	}
+	seg.offsets[key] = append(seg.offsets[key], seg.size)
+	seg.size += int64(len(record))
+	return nil
+}
+
+// readRecords returns the points of key between from and to inclusive from the
+// records at offsets in the segment file at path. A file removed by Compact
+// since the offsets were taken holds nothing in range any more.
+func readRecords(path, key string, offsets []int64, from, to time.Time) ([]Point, error) {
+	if len(offsets) == 0 {
+		return nil, nil
+	}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// Only read the part of the file holding this key's records
+	first, last := offsets[0], offsets[len(offsets)-1]
+	data := make([]byte, last-first+int64(recordFixedSize+len(key)))
+	if _, err := file.ReadAt(data, first); err != nil {
+		return nil, err
+	}
+
+	points := make([]Point, 0, len(offsets))
+	for _, offset := range offsets {
+		_, p, _, ok := decodeRecord(data[offset-first:])
+		if !ok {
+			return nil, fmt.Errorf("corrupt record in %s at %d", path, offset)
+		}
+		if p.Time.Before(from) || p.Time.After(to) {
+			continue
+		}
+		points = append(points, p)
+	}
+	return points, nil
+}
+
+func (seg *segment) close() error {
+	if seg.file == nil {
+		return nil
+	}
+	err := seg.file.Close()
+	seg.file = nil
+	return err
+}
+
+func encodeRecord(key string, p Point) []byte {
+	b := make([]byte, recordFixedSize+len(key))
+	binary.LittleEndian.PutUint16(b, uint16(len(key)))
+	n := 2 + copy(b[2:], key)
+	binary.LittleEndian.PutUint64(b[n:], uint64(p.Time.UnixNano()))
+	binary.LittleEndian.PutUint64(b[n+8:], math.Float64bits(p.Min))
+	binary.LittleEndian.PutUint64(b[n+16:], math.Float64bits(p.Max))
+	binary.LittleEndian.PutUint64(b[n+24:], math.Float64bits(p.Sum))
+	binary.LittleEndian.PutUint32(b[n+32:], uint32(p.Count))
+	return b
+}
+
+// decodeRecord decodes the record at the start of b and returns its length.
+// ok is false when b holds less than a whole record.
+func decodeRecord(b []byte) (key string, p Point, n int, ok bool) {
+	if len(b) < 2 {
+		return "", Point{}, 0, false
+	}
+	keyLen := int(binary.LittleEndian.Uint16(b))
+	if len(b) < recordFixedSize+keyLen {
+		return "", Point{}, 0, false
+	}
+	key = string(b[2 : 2+keyLen])
+	n = 2 + keyLen
+	p = Point{
+		Time:  time.Unix(0, int64(binary.LittleEndian.Uint64(b[n:]))),
+		Min:   math.Float64frombits(binary.LittleEndian.Uint64(b[n+8:])),
+		Max:   math.Float64frombits(binary.LittleEndian.Uint64(b[n+16:])),
+		Sum:   math.Float64frombits(binary.LittleEndian.Uint64(b[n+24:])),
+		Count: int(binary.LittleEndian.Uint32(b[n+32:])),
+	}
+	return key, p, n + 36, true
+}
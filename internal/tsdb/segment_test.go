@@ -0,0 +1,90 @@
+package tsdb
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRecordRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		p    Point
+	}{
+		{"raw sample", Key{Series: "nginx", Field: "cpuPercent"}.String(), Point{Time: time.Unix(1700000000, 123456789), Min: 12.5, Max: 12.5, Sum: 12.5, Count: 1}},
+		{"bucket", Key{Series: HostSeries, Field: "usedMB"}.String(), Point{Time: time.Unix(1700000040, 0), Min: -3, Max: 8192.25, Sum: 1e9, Count: 60}},
+		{"empty key", "", Point{Time: time.Unix(0, 0)}},
+		{"special values", "k", Point{Time: time.Unix(1, 0), Min: math.Inf(-1), Max: math.Inf(1), Sum: math.MaxFloat64, Count: math.MaxInt32}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := encodeRecord(tt.key, tt.p)
+			if len(b) != recordFixedSize+len(tt.key) {
+				t.Fatalf("encoded %d bytes, want %d", len(b), recordFixedSize+len(tt.key))
+			}
+			key, p, n, ok := decodeRecord(append(b, 0xff, 0xff)) // Followed by the next record
+			if !ok || n != len(b) {
+				t.Fatalf("decodeRecord() = n %d, ok %v", n, ok)
+			}
+			if key != tt.key || !p.Time.Equal(tt.p.Time) || p.Min != tt.p.Min || p.Max != tt.p.Max || p.Sum != tt.p.Sum || p.Count != tt.p.Count {
+				t.Errorf("decodeRecord() = %q %+v, want %q %+v", key, p, tt.key, tt.p)
+			}
+			for cut := 0; cut < len(b); cut++ {
+				if _, _, _, ok := decodeRecord(b[:cut]); ok {
+					t.Errorf("decoded a record cut to %d bytes", cut)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadSegmentCutsTornTail(t *testing.T) {
+	start := time.Unix(1700000000, 0).Truncate(time.Hour)
+	whole := append(encodeRecord("a", Point{Time: start, Count: 1}), encodeRecord("b", Point{Time: start.Add(time.Second), Count: 1})...)
+	next := encodeRecord("a", Point{Time: start.Add(2 * time.Second), Count: 1})
+
+	tests := []struct {
+		name string
+		tail []byte
+	}{
+		{"no tail", nil},
+		{"one byte", next[:1]},
+		{"key only", next[:3]},
+		{"all but the count", next[:len(next)-4]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), strconv.FormatInt(start.Unix(), 10)+".seg")
+			if err := os.WriteFile(path, append(append([]byte{}, whole...), tt.tail...), 0644); err != nil {
+				t.Fatal(err)
+			}
+			seg, err := loadSegment(path, time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if seg.size != int64(len(whole)) || len(seg.offsets["a"]) != 1 || len(seg.offsets["b"]) != 1 {
+				t.Errorf("indexed %d bytes and %v, want %d bytes and a record each for a and b", seg.size, seg.offsets, len(whole))
+			}
+			if info, err := os.Stat(path); err != nil || info.Size() != int64(len(whole)) {
+				t.Errorf("file left at %v bytes (%v), want %d", info.Size(), err, len(whole))
+			}
+
+			// Later appends follow the last whole record
+			if err := seg.append("a", Point{Time: start.Add(3 * time.Second), Count: 1}); err != nil {
+				t.Fatal(err)
+			}
+			defer seg.close()
+			points, err := readRecords(seg.path, "a", seg.offsets["a"], start, seg.end)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(points) != 2 || !points[1].Time.Equal(start.Add(3*time.Second)) {
+				t.Errorf("read back %+v", points)
+			}
+		})
+	}
+}
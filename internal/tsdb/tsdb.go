@@ -0,0 +1,382 @@
+// Package tsdb is an embedded time-series store for numeric service and host
+// samples. Samples are appended to per-tier segment files and downsampled into
+// coarser tiers as they arrive, so long ranges can be read without scanning
+// every raw sample.
+package tsdb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HostSeries names the series holding host_resources samples.
+const HostSeries = "@host"
+
+// Tier is a resolution at which samples are kept.
+type Tier struct {
+	Name       string        `json:"name"`
+	Resolution time.Duration `json:"resolution"` // Bucket size; 0 keeps every sample
+	Retention  time.Duration `json:"retention"`
+	Span       time.Duration `json:"-"` // Time covered by one segment file
+}
+
+// Tiers are the resolutions samples are kept at, finest first.
+var Tiers = []Tier{
+	{Name: "raw", Resolution: 0, Retention: 24 * time.Hour, Span: time.Hour},
+	{Name: "1m", Resolution: time.Minute, Retention: 30 * 24 * time.Hour, Span: 24 * time.Hour},
+	{Name: "1h", Resolution: time.Hour, Retention: 365 * 24 * time.Hour, Span: 7 * 24 * time.Hour},
+}
+
+// TierByName finds a tier by its name.
+func TierByName(name string) (Tier, bool) {
+	for _, tier := range Tiers {
+		if tier.Name == name {
+			return tier, true
+		}
+	}
+	return Tier{}, false
+}
+
+// Point is a raw sample, or the aggregate of one bucket of a downsampled tier.
+type Point struct {
+	Time  time.Time `json:"time"` // Sample time, or bucket start
+	Min   float64   `json:"min"`
+	Max   float64   `json:"max"`
+	Sum   float64   `json:"sum"`
+	Count int       `json:"count"` // 1 for raw samples
+}
+
+// Avg returns the mean of the samples in the point.
+func (p Point) Avg() float64 {
+	if p.Count == 0 {
+		return 0
+	}
+	return p.Sum / float64(p.Count)
+}
+
+func (p *Point) add(value float64) {
+	if p.Count == 0 || value < p.Min {
+		p.Min = value
+	}
+	if p.Count == 0 || value > p.Max {
+		p.Max = value
+	}
+	p.Sum += value
+	p.Count++
+}
+
+func (p *Point) merge(o Point) {
+	if o.Count == 0 {
+		return
+	}
+	if p.Count == 0 || o.Min < p.Min {
+		p.Min = o.Min
+	}
+	if p.Count == 0 || o.Max > p.Max {
+		p.Max = o.Max
+	}
+	p.Sum += o.Sum
+	p.Count += o.Count
+}
+
+// Key identifies a series field, e.g. the cpuPercent of one service.
+type Key struct {
+	Series string `json:"series"`
+	Field  string `json:"field"`
+}
+
+func (k Key) String() string {
+	return k.Series + "\x00" + k.Field
+}
+
+func parseKey(s string) Key {
+	series, field, _ := strings.Cut(s, "\x00")
+	return Key{Series: series, Field: field}
+}
+
+// Store keeps samples on disk under dir, one directory of segments per tier.
+type Store struct {
+	dir string
+
+	mutex    sync.Mutex
+	segments [][]*segment        // Per tier, oldest first
+	buckets  []map[string]*Point // Per tier, the bucket being filled for each key; unused for raw
+}
+
+// Open loads the segments under dir, creating it if needed.
+func Open(dir string) (*Store, error) {
+	s := &Store{
+		dir:      dir,
+		segments: make([][]*segment, len(Tiers)),
+		buckets:  make([]map[string]*Point, len(Tiers)),
+	}
+	for i, tier := range Tiers {
+		s.buckets[i] = make(map[string]*Point)
+
+		tierDir := filepath.Join(dir, tier.Name)
+		if err := os.MkdirAll(tierDir, 0755); err != nil {
+			return nil, err
+		}
+		paths, err := filepath.Glob(filepath.Join(tierDir, "*.seg"))
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			seg, err := loadSegment(path, tier.Span)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load %s: %w", path, err)
+			}
+			s.segments[i] = append(s.segments[i], seg)
+		}
+		sort.Slice(s.segments[i], func(a, b int) bool {
+			return s.segments[i][a].start.Before(s.segments[i][b].start)
+		})
+	}
+	return s, nil
+}
+
+// Append stores a sample and folds it into the buckets of the downsampled tiers.
+// A bucket is written out once a sample for a later bucket arrives, or by Compact.
+func (s *Store) Append(series, field string, t time.Time, value float64) error {
+	key := Key{Series: series, Field: field}.String()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.write(0, key, Point{Time: t, Min: value, Max: value, Sum: value, Count: 1}); err != nil {
+		return err
+	}
+	for i, tier := range Tiers {
+		if tier.Resolution == 0 {
+			continue
+		}
+		start := t.Truncate(tier.Resolution)
+		bucket := s.buckets[i][key]
+		if bucket != nil && start.After(bucket.Time) {
+			if err := s.write(i, key, *bucket); err != nil {
+				return err
+			}
+			bucket = nil
+		}
+		if bucket == nil {
+			bucket = &Point{Time: start}
+			s.buckets[i][key] = bucket
+		}
+		// Samples older than the open bucket only make it into the raw tier
+		if !start.Before(bucket.Time) {
+			bucket.add(value)
+		}
+	}
+	return nil
+}
+
+// write appends a point to the segment of a tier covering its time. Called with mutex held.
+func (s *Store) write(tierIndex int, key string, p Point) error {
+	tier := Tiers[tierIndex]
+	start := p.Time.Truncate(tier.Span)
+
+	segments := s.segments[tierIndex]
+	var seg *segment
+	// Writes nearly always go to the newest segment
+	for i := len(segments) - 1; i >= 0; i-- {
+		if segments[i].start.Equal(start) {
+			seg = segments[i]
+			break
+		}
+	}
+	if seg == nil {
+		seg = &segment{
+			start:   start,
+			end:     start.Add(tier.Span),
+			path:    segmentPath(s.dir, tier, start),
+			offsets: make(map[string][]int64),
+		}
+		segments = append(segments, seg)
+		sort.Slice(segments, func(a, b int) bool { return segments[a].start.Before(segments[b].start) })
+		s.segments[tierIndex] = segments
+	}
+	return seg.append(key, p)
+}
+
+// Query returns the points of a series field between from and to, from the
+// coarsest tier that is at least as fine as step and still retains from. When no
+// such tier reaches back far enough, the finest tier that does is used.
+func (s *Store) Query(series, field string, from, to time.Time, step time.Duration) (Tier, []Point, error) {
+	tier := PickTier(from, step, time.Now())
+	points, err := s.QueryTier(tier, series, field, from, to)
+	return tier, points, err
+}
+
+// PickTier chooses the tier Query reads for a range starting at from.
+func PickTier(from time.Time, step time.Duration, now time.Time) Tier {
+	for i := len(Tiers) - 1; i >= 0; i-- {
+		if Tiers[i].Resolution <= step && !from.Before(now.Add(-Tiers[i].Retention)) {
+			return Tiers[i]
+		}
+	}
+	for _, tier := range Tiers {
+		if !from.Before(now.Add(-tier.Retention)) {
+			return tier
+		}
+	}
+	return Tiers[len(Tiers)-1]
+}
+
+// QueryTier returns the points of a series field in one tier between from and
+// to, oldest first. Downsampled tiers include the bucket from falls into and the
+// bucket still being filled.
+func (s *Store) QueryTier(tier Tier, series, field string, from, to time.Time) ([]Point, error) {
+	tierIndex := -1
+	for i := range Tiers {
+		if Tiers[i].Name == tier.Name {
+			tierIndex = i
+		}
+	}
+	if tierIndex < 0 {
+		return nil, fmt.Errorf("unknown tier: %s", tier.Name)
+	}
+	key := Key{Series: series, Field: field}.String()
+	// Include the bucket that from falls into
+	if tier.Resolution > 0 {
+		from = from.Truncate(tier.Resolution)
+	}
+
+	// Segment files are only appended to, so the records indexed now can be read
+	// after the mutex is released without holding up Append
+	type segmentRead struct {
+		path    string
+		offsets []int64
+	}
+	var reads []segmentRead
+	var points []Point
+
+	s.mutex.Lock()
+	for _, seg := range s.segments[tierIndex] {
+		if !seg.end.After(from) || seg.start.After(to) {
+			continue
+		}
+		if offsets := seg.offsets[key]; len(offsets) > 0 {
+			reads = append(reads, segmentRead{path: seg.path, offsets: offsets[:len(offsets):len(offsets)]})
+		}
+	}
+	if bucket := s.buckets[tierIndex][key]; bucket != nil && !bucket.Time.Before(from) && !bucket.Time.After(to) {
+		points = append(points, *bucket)
+	}
+	s.mutex.Unlock()
+
+	for _, r := range reads {
+		segPoints, err := readRecords(r.path, key, r.offsets, from, to)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, segPoints...)
+	}
+
+	sort.SliceStable(points, func(a, b int) bool { return points[a].Time.Before(points[b].Time) })
+	if tier.Resolution == 0 {
+		return points, nil
+	}
+	// A bucket written out before a restart can be written again afterwards
+	merged := points[:0]
+	for _, p := range points {
+		if n := len(merged); n > 0 && merged[n-1].Time.Equal(p.Time) {
+			merged[n-1].merge(p)
+			continue
+		}
+		merged = append(merged, p)
+	}
+	return merged, nil
+}
+
+// Keys lists the series fields held in any tier.
+func (s *Store) Keys() []Key {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	seen := make(map[string]bool)
+	for _, segments := range s.segments {
+		for _, seg := range segments {
+			for key := range seg.offsets {
+				seen[key] = true
+			}
+		}
+	}
+	keys := make([]Key, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, parseKey(key))
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		if keys[a].Series != keys[b].Series {
+			return keys[a].Series < keys[b].Series
+		}
+		return keys[a].Field < keys[b].Field
+	})
+	return keys
+}
+
+// Compact writes out buckets that have ended, closes segments that no longer
+// receive writes and deletes segments past their tier's retention.
+func (s *Store) Compact(now time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var firstErr error
+	keep := func(err error) {
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	for i, tier := range Tiers {
+		for key, bucket := range s.buckets[i] {
+			// Buckets of series that stopped reporting would otherwise stay open forever
+			if !bucket.Time.Add(tier.Resolution).After(now) {
+				keep(s.write(i, key, *bucket))
+				delete(s.buckets[i], key)
+			}
+		}
+
+		kept := s.segments[i][:0]
+		for _, seg := range s.segments[i] {
+			if seg.end.Before(now.Add(-tier.Retention)) {
+				keep(seg.close())
+				keep(os.Remove(seg.path))
+				continue
+			}
+			// Late samples reopen the file if they come
+			if seg.end.Before(now.Add(-tier.Resolution - time.Minute)) {
+				keep(seg.close())
+			}
+			kept = append(kept, seg)
+		}
+		s.segments[i] = kept
+	}
+	return firstErr
+}
+
+// Close writes out the buckets being filled and closes all segments.
+func (s *Store) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var firstErr error
+	for i := range Tiers {
+		for key, bucket := range s.buckets[i] {
+			if err := s.write(i, key, *bucket); err != nil && firstErr == nil {
+				firstErr = err
+			}
+			delete(s.buckets[i], key)
+		}
+		for _, seg := range s.segments[i] {
+			if err := seg.close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
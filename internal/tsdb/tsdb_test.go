@@ -0,0 +1,117 @@
+package tsdb
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestAppendRollsBucketsOver(t *testing.T) {
+	s, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.Local)
+	samples := []struct {
+		after time.Duration
+		value float64
+	}{
+		{10 * time.Second, 4},
+		{40 * time.Second, 8},
+		{30 * time.Second, 2},  // Out of order within the open bucket
+		{70 * time.Second, 1},  // Starts the next minute and writes out the first
+		{5 * time.Second, 100}, // Late for a bucket already written out
+	}
+	for _, sample := range samples {
+		if err := s.Append("web", "cpuPercent", start.Add(sample.after), sample.value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	raw, err := s.QueryTier(Tiers[0], "web", "cpuPercent", start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(raw) != len(samples) {
+		t.Errorf("raw tier has %d points, want %d", len(raw), len(samples))
+	}
+
+	minutes, err := s.QueryTier(Tiers[1], "web", "cpuPercent", start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Point{
+		{Time: start, Min: 2, Max: 8, Sum: 14, Count: 3},
+		{Time: start.Add(time.Minute), Min: 1, Max: 1, Sum: 1, Count: 1}, // Still being filled
+	}
+	if len(minutes) != len(want) {
+		t.Fatalf("1m tier = %+v, want %+v", minutes, want)
+	}
+	for i := range want {
+		if got := minutes[i]; !got.Time.Equal(want[i].Time) || got.Min != want[i].Min || got.Max != want[i].Max || got.Sum != want[i].Sum || got.Count != want[i].Count {
+			t.Errorf("1m point %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+	key := Key{Series: "web", Field: "cpuPercent"}.String()
+	if n := len(s.segments[1][0].offsets[key]); n != 1 {
+		t.Errorf("%d 1m records written, want the ended bucket only", n)
+	}
+}
+
+func TestCompactRetention(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	now := time.Date(2025, 6, 1, 12, 0, 30, 0, time.Local)
+	for _, at := range []time.Time{now.Add(-48 * time.Hour), now.Add(-10 * time.Second)} {
+		if err := s.Append("web", "memoryMB", at, 64); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expired := s.segments[0][0].path
+
+	if err := s.Compact(now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(expired); !os.IsNotExist(err) {
+		t.Errorf("raw segment past retention still on disk: %v", err)
+	}
+	if len(s.segments[0]) != 1 || !s.segments[0][0].start.Equal(now.Truncate(time.Hour)) {
+		t.Errorf("raw tier kept %d segments, want the current one", len(s.segments[0]))
+	}
+	// The 1m and 1h tiers still retain two days ago
+	for _, tier := range Tiers[1:] {
+		points, err := s.QueryTier(tier, "web", "memoryMB", now.Add(-49*time.Hour), now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(points) != 2 {
+			t.Errorf("%s tier has %d points, want 2", tier.Name, len(points))
+		}
+	}
+	// The current minute hasn't ended, so its bucket stays open
+	if len(s.buckets[1]) != 1 {
+		t.Errorf("%d open 1m buckets after Compact, want 1", len(s.buckets[1]))
+	}
+
+	// Once it has, Compact writes it out
+	if err := s.Compact(now.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.buckets[1]) != 0 {
+		t.Errorf("%d open 1m buckets after the minute ended, want 0", len(s.buckets[1]))
+	}
+	points, err := s.QueryTier(Tiers[1], "web", "memoryMB", now.Add(-time.Minute), now.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != 1 || points[0].Count != 1 {
+		t.Errorf("written out bucket = %+v", points)
+	}
+}
@@ -3,20 +3,29 @@ package main
 import (
 	"context"
 	"embed"
+	"flag"
 	"fmt"
 	"io/fs"
 	"net/http"
 	"os/exec"
+	"strings"
+	"sync"
 
+	"github.com/ethan-mdev/service-watch/internal/alerts"
+	"github.com/ethan-mdev/service-watch/internal/core"
 	"github.com/ethan-mdev/service-watch/internal/handlers"
 	"github.com/ethan-mdev/service-watch/internal/logger"
 	"github.com/ethan-mdev/service-watch/internal/monitor"
+	"github.com/ethan-mdev/service-watch/internal/notify"
 	"github.com/ethan-mdev/service-watch/internal/platform"
+	"github.com/ethan-mdev/service-watch/internal/scheduler"
 	"github.com/ethan-mdev/service-watch/internal/sse"
 	"github.com/ethan-mdev/service-watch/internal/storage"
+	"github.com/ethan-mdev/service-watch/internal/tsdb"
 	"github.com/getlantern/systray"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 //go:embed dist
@@ -25,7 +34,25 @@ var webFS embed.FS
 //go:embed icon.ico
 var iconData []byte
 
+var (
+	backend          = flag.String("backend", "os", "comma-separated service backends: os (Windows SCM / systemd), supervisor, docker")
+	supervisorConfig = flag.String("supervisor-config", "services.json", "process definitions for the supervisor backend")
+	dockerHost       = flag.String("docker-host", platform.DefaultDockerHost, "Docker Engine address for the docker backend")
+	watchlistStore   = flag.String("watchlist-store", "json", "where the watchlist and schedules are kept: json (watchlist.json, schedules.json) or sqlite")
+	watchlistDB      = flag.String("watchlist-db", "data/watchlist.db", "database file for the sqlite store")
+	allowExec        = flag.Bool("allow-exec", false, "accept exec health checks and hooks through the API; they run as the daemon's user")
+)
+
+var (
+	// shutdown is cancelled when the tray exits
+	shutdown, stopServer = context.WithCancel(context.Background())
+	// flushing tracks work that has to reach the disk before the process exits
+	flushing sync.WaitGroup
+)
+
 func main() {
+	flag.Parse()
+
 	// Start the server in a goroutine so it doesn't block
 	go startServer()
 
@@ -46,19 +73,81 @@ func startServer() {
 	defer appLogger.Close()
 
 	// Initialize service manager
-	svcMgr := platform.MakeServiceManager()
+	svcMgr, err := makeServiceManager()
+	if err != nil {
+		panic(fmt.Sprintf("Failed to initialize service manager: %v", err))
+	}
 
-	// Initialize watchlist manager
-	watchlistMgr := storage.NewJSONWatchlist("watchlist.json", svcMgr)
+	// Initialize watchlist and schedule managers
+	watchlistMgr, scheduleMgr, err := makeStores(svcMgr)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to initialize watchlist: %v", err))
+	}
 
 	// Initialize service watcher with logger
-	go monitor.Start(context.Background(), watchlistMgr, svcMgr, appLogger)
+	watcher := monitor.New(watchlistMgr, svcMgr, appLogger)
+	go watcher.Run(context.Background())
+
+	// Initialize scheduler for cron-driven service actions
+	sched := scheduler.New(scheduleMgr, watchlistMgr, svcMgr, appLogger)
+	go sched.Run(context.Background())
+
+	// Initialize webhook notifier on the event stream
+	webhooks, err := notify.LoadWebhooks("webhooks.json")
+	if err != nil {
+		panic(fmt.Sprintf("Failed to load webhooks: %v", err))
+	}
+	deliveryLog := &lumberjack.Logger{
+		Filename:   "logs/webhooks.jsonl",
+		MaxSize:    10, // MB
+		MaxBackups: 3,
+		Compress:   true,
+	}
+	notifier := notify.New(webhooks, &http.Client{}, deliveryLog)
+	go notifier.Run(context.Background(), broadcaster, appLogger)
+
+	// Initialize time-series store for service and host samples
+	samples, err := tsdb.Open("data/metrics")
+	if err != nil {
+		panic(fmt.Sprintf("Failed to open metrics store: %v", err))
+	}
+	flushing.Add(1)
+	go func() {
+		defer flushing.Done()
+		samples.Run(shutdown, broadcaster, appLogger)
+	}()
+
+	// Initialize alert rules engine on the event stream
+	alertRules, err := alerts.LoadRules("alerts.json")
+	if err != nil {
+		panic(fmt.Sprintf("Failed to load alert rules: %v", err))
+	}
+	alertEngine := alerts.New(alertRules, appLogger)
+	go alertEngine.Run(context.Background(), broadcaster)
+
+	// Initialize email alerts if configured
+	emailCfg, err := notify.LoadEmailConfig("email.json")
+	if err != nil {
+		panic(fmt.Sprintf("Failed to load email config: %v", err))
+	}
+	if emailCfg != nil {
+		emailer, err := notify.NewEmailer(*emailCfg, appLogger)
+		if err != nil {
+			panic(fmt.Sprintf("Failed to initialize email alerts: %v", err))
+		}
+		go emailer.Run(context.Background(), broadcaster)
+	}
 
 	// Create HTTP handlers
 	svcHTTP := handlers.NewServiceHTTP(svcMgr)
-	watchlistHTTP := handlers.NewWatchlistHTTP(watchlistMgr)
+	watchlistHTTP := handlers.NewWatchlistHTTP(watchlistMgr, watcher, *allowExec)
 	eventsHTTP := handlers.NewEventsHTTP(broadcaster)
-	metricsHTTP := handlers.NewMetricsHTTP("logs/events.jsonl")
+	metricsHTTP := handlers.NewMetricsHTTP("logs/events.jsonl", watchlistMgr, samples)
+	groupsHTTP := handlers.NewGroupsHTTP(watchlistMgr, svcMgr, appLogger)
+	schedulesHTTP := handlers.NewSchedulesHTTP(scheduleMgr, svcMgr, sched)
+	webhooksHTTP := handlers.NewWebhooksHTTP(notifier)
+	alertsHTTP := handlers.NewAlertsHTTP(alertEngine)
+	prometheusHTTP := handlers.NewPrometheusHTTP(watcher, eventsHTTP)
 
 	// Setup router
 	r := chi.NewRouter()
@@ -69,7 +158,12 @@ func startServer() {
 	r.Mount("/v1/services", svcHTTP.Routes())
 	r.Mount("/v1/watchlist", watchlistHTTP.Routes())
 	r.Mount("/v1/metrics", metricsHTTP.Routes())
+	r.Mount("/v1/groups", groupsHTTP.Routes())
+	r.Mount("/v1/schedules", schedulesHTTP.Routes())
+	r.Mount("/v1/webhooks", webhooksHTTP.Routes())
+	r.Mount("/v1/alerts", alertsHTTP.Routes())
 	r.Get("/v1/events", eventsHTTP.Stream)
+	r.Get("/metrics", prometheusHTTP.Metrics)
 
 	// Serve API docs at /docs
 	r.Get("/docs", func(w http.ResponseWriter, r *http.Request) {
@@ -104,6 +198,53 @@ func startServer() {
 	}
 }
 
+// makeServiceManager builds the ServiceManager selected by the -backend flag.
+// Several backends are combined into a composite with namespaced service names.
+func makeServiceManager() (core.ServiceManager, error) {
+	var backends []platform.Backend
+	for _, name := range strings.Split(*backend, ",") {
+		b, err := makeBackend(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		backends = append(backends, b)
+	}
+
+	if len(backends) == 1 {
+		return backends[0].Manager, nil
+	}
+	return platform.NewComposite(backends...), nil
+}
+
+func makeBackend(name string) (platform.Backend, error) {
+	switch name {
+	case "os", platform.OSBackendName:
+		return platform.Backend{Name: platform.OSBackendName, Manager: platform.MakeServiceManager()}, nil
+	case "supervisor":
+		mgr, err := platform.NewSupervisor(*supervisorConfig, "logs/services")
+		return platform.Backend{Name: name, Manager: mgr}, err
+	case "docker":
+		mgr, err := platform.NewDocker(*dockerHost)
+		return platform.Backend{Name: name, Manager: mgr}, err
+	default:
+		return platform.Backend{}, fmt.Errorf("unknown backend: %s", name)
+	}
+}
+
+// makeStores builds the watchlist and schedule managers selected by the
+// -watchlist-store flag; schedules are kept in the same store as the watchlist.
+func makeStores(svcMgr core.ServiceManager) (core.WatchlistManager, core.ScheduleManager, error) {
+	switch *watchlistStore {
+	case "json":
+		return storage.NewJSONWatchlist("watchlist.json", svcMgr), storage.NewJSONSchedules("schedules.json"), nil
+	case "sqlite":
+		// A new database starts from the items in watchlist.json and schedules.json
+		return storage.OpenSQLite(*watchlistDB, "watchlist.json", "schedules.json", svcMgr)
+	default:
+		return nil, nil, fmt.Errorf("unknown watchlist store: %s", *watchlistStore)
+	}
+}
+
 func onTrayReady() {
 	systray.SetIcon(iconData)
 	systray.SetTitle("Service Watch")
@@ -130,7 +271,9 @@ func onTrayReady() {
 }
 
 func onTrayExit() {
-	// Cleanup
+	// Let the metrics store write out the buckets being filled
+	stopServer()
+	flushing.Wait()
 }
 
 func openBrowser(url string) {
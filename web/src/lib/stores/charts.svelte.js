@@ -1,9 +1,60 @@
 // src/stores/charts.svelte.js
+import { metricsAPI } from './metrics.svelte.js';
+
+// Ranges offered on charts; 'live' follows SSE, the others load stored history
+export const chartRanges = ['live', '1h', '24h', '168h', '720h'];
+
 export const chartsState = $state({
   pinned: [],                       
   series: {},                        
+  history: {},                       // Per service, same shape as series
+  ranges: {},                        // Per service, one of chartRanges
   maxPoints: 600,
 
+  rangeOf(n) {
+    return this.ranges[n] ?? 'live';
+  },
+
+  // Data to draw for a service: live points or the loaded history
+  dataFor(n) {
+    return this.rangeOf(n) === 'live' ? this.series[n] : this.history[n];
+  },
+
+  async setRange(n, range, totalSystemMB) {
+    this.ranges[n] = range;
+    if (range !== 'live') {
+      await this.loadHistory(n, range, totalSystemMB);
+    }
+  },
+
+  // Loads average CPU and memory over the range, bucketed to roughly 300 points
+  async loadHistory(n, range, totalSystemMB = 16384) {
+    const [cpu, mem] = await Promise.all([
+      metricsAPI.getSeries({ service: n, field: 'cpuPercent', from: range, agg: 'avg' }),
+      metricsAPI.getSeries({ service: n, field: 'memoryMB', from: range, agg: 'avg' })
+    ]);
+
+    // Buckets without samples are left out, so line the two fields up by time
+    const byTime = new Map();
+    for (const b of cpu?.items ?? []) {
+      byTime.set(b.time, { cpu: b.value, mem: null });
+    }
+    for (const b of mem?.items ?? []) {
+      const entry = byTime.get(b.time) ?? { cpu: null, mem: null };
+      entry.mem = totalSystemMB > 0 ? (b.value / totalSystemMB) * 100 : 0;
+      byTime.set(b.time, entry);
+    }
+
+    const times = [...byTime.keys()].sort();
+    this.history[n] = {
+      t: times.map(t => Date.parse(t) / 1000),
+      cpu: times.map(t => byTime.get(t).cpu),
+      mem: times.map(t => byTime.get(t).mem),
+      memIsMB: false
+    };
+    console.log(`Loaded ${times.length} history points for ${n} over ${range}`);
+  },
+
   isPinned(n) { 
     return this.pinned.includes(n); 
   },
@@ -30,6 +81,8 @@ export const chartsState = $state({
     if (index > -1) {
       this.pinned.splice(index, 1);
     }
+    delete this.ranges[n];
+    delete this.history[n];
   },
 
   ensure(n) {
@@ -64,6 +64,29 @@ export const metricsAPI = {
         return data?.count || 0;
     },
 
+    // Bucketed history from the time-series store: { items: [{ time, value, count }], summary }
+    async getSeries(params = {}) {
+        try {
+            const queryParams = new URLSearchParams();
+            if (params.service) queryParams.set('service', params.service);
+            queryParams.set('field', params.field);
+            if (params.from) queryParams.set('from', params.from);
+            if (params.to) queryParams.set('to', params.to);
+            if (params.step) queryParams.set('step', params.step);
+            if (params.agg) queryParams.set('agg', params.agg);
+
+            const response = await fetch(`/v1/metrics/series?${queryParams.toString()}`);
+            if (response.ok) {
+                return await response.json();
+            }
+            console.error('Failed to fetch series:', response.statusText);
+            return null;
+        } catch (err) {
+            console.error('Error fetching series:', err);
+            return null;
+        }
+    },
+
     // Periodic refresh
     startPeriodicRefresh(params, intervalMs = 5 * 60 * 1000) {
         return setInterval(() => {